@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
@@ -10,15 +11,27 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	// Embedded zone data so user time zones resolve on images without tzdata.
+	_ "time/tzdata"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 
 	"go-todo-service/internal/config"
 	"go-todo-service/internal/handlers"
+	"go-todo-service/internal/repository/memory"
 	"go-todo-service/internal/repository/postgres"
 	authsvc "go-todo-service/internal/service/auth"
+	exportsvc "go-todo-service/internal/service/export"
+	"go-todo-service/internal/service/lockout"
+	patsvc "go-todo-service/internal/service/pat"
+	projectsvc "go-todo-service/internal/service/project"
+	tagsvc "go-todo-service/internal/service/tag"
 	tasksrv "go-todo-service/internal/service/task"
+	"go-todo-service/pkg/jwt"
 	"go-todo-service/pkg/logger"
+	"go-todo-service/pkg/mailer"
+	"go-todo-service/pkg/oidc"
+	"go-todo-service/pkg/password"
 )
 
 func main() {
@@ -30,6 +43,24 @@ func main() {
 		os.Exit(1)
 	}
 
+	keys, err := setupKeySet(cfg)
+	if err != nil {
+		log.Error("failed to load signing keys", map[string]any{"error": err.Error()})
+		os.Exit(1)
+	}
+
+	mail, err := setupMailer(cfg)
+	if err != nil {
+		log.Error("failed to configure mailer", map[string]any{"error": err.Error()})
+		os.Exit(1)
+	}
+	// Sending in the background keeps SMTP latency out of responses that
+	// must not reveal whether an email went out.
+	mailQueue := mailer.NewQueue(mail, 100, func(msg mailer.Message, err error) {
+		log.Error("mail delivery failed", map[string]any{"error": err.Error(), "subject": msg.Subject})
+	})
+	defer mailQueue.Close()
+
 	db, err := setupDatabase(cfg)
 	if err != nil {
 		log.Error("database connection failed", map[string]any{"error": err.Error()})
@@ -39,15 +70,81 @@ func main() {
 
 	userRepo := postgres.NewUserRepository(db)
 	taskRepo := postgres.NewTaskRepository(db)
+	tagRepo := postgres.NewTagRepository(db)
+	projectRepo := postgres.NewProjectRepository(db)
+	refreshTokenRepo := postgres.NewRefreshTokenRepository(db)
+	sessionRepo := postgres.NewSessionRepository(db)
+	revokedTokenRepo := postgres.NewRevokedTokenRepository(db)
+	accessTokenRepo := postgres.NewPersonalAccessTokenRepository(db)
+	userTokenRepo := postgres.NewUserTokenRepository(db)
+	mfaRepo := postgres.NewMFARepository(db)
+
+	hasher, err := password.NewHasher(password.Params{
+		Memory:      uint32(cfg.Argon2Memory),
+		Iterations:  uint32(cfg.Argon2Iterations),
+		Parallelism: uint8(cfg.Argon2Parallelism),
+	})
+	if err != nil {
+		log.Error("invalid password hashing parameters", map[string]any{"error": err.Error()})
+		os.Exit(1)
+	}
+
+	policy, err := setupPasswordPolicy(cfg)
+	if err != nil {
+		log.Error("failed to load password policy", map[string]any{"error": err.Error()})
+		os.Exit(1)
+	}
 
-	authService := authsvc.New(userRepo, cfg.JWTSecret, cfg.JWTTTL)
+	authService := authsvc.New(userRepo, keys, cfg.JWTTTL)
+	authService.WithPasswordHasher(hasher)
+	authService.WithPasswordPolicy(policy)
+	authService.WithRefreshTokens(refreshTokenRepo, cfg.RefreshTokenTTL)
+	authService.WithRevokedTokens(revokedTokenRepo)
+	authService.WithUserTokens(userTokenRepo)
+	authService.WithMailer(mailQueue, cfg.AppBaseURL)
+	authService.WithVerificationPolicy(authsvc.VerificationPolicy(cfg.EmailVerification))
+	authService.WithMFA(mfaRepo, cfg.MFAIssuer)
+	authService.WithLockout(setupLockout(cfg, db))
+	authService.WithSessions(sessionRepo)
+	authService.WithAccessTokens(accessTokenRepo)
+	authService.WithAccountStatusCache(cfg.AccountStatusCacheTTL)
+	if cfg.OIDCIssuerURL != "" {
+		provider, err := oidc.NewProvider(oidc.Config{
+			IssuerURL:    cfg.OIDCIssuerURL,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       cfg.OIDCScopes,
+		})
+		if err != nil {
+			log.Error("invalid single sign-on configuration", map[string]any{"error": err.Error()})
+			os.Exit(1)
+		}
+		authService.WithOIDC(provider, postgres.NewIdentityRepository(db), []byte(cfg.OIDCStateSecret))
+	}
+	projectService := projectsvc.New(projectRepo)
+	authService.WithProjects(projectService)
 	taskService := tasksrv.New(taskRepo)
+	taskService.WithUsers(userRepo)
+	taskService.WithTags(tagRepo)
+	taskService.WithProjects(projectService)
+	tagService := tagsvc.New(tagRepo)
+	accessTokenService := patsvc.New(accessTokenRepo)
+	exportService := exportsvc.New(userRepo, taskService, tagService, projectService, postgres.NewArchiveRepository(db))
 
 	authHandler := handlers.NewAuthHandler(authService, log)
 	taskHandler := handlers.NewTaskHandler(taskService, log)
-	authMiddleware := handlers.NewAuthMiddleware(cfg.JWTSecret, log)
+	tagHandler := handlers.NewTagHandler(tagService, log)
+	projectHandler := handlers.NewProjectHandler(projectService, log)
+	tokenHandler := handlers.NewTokenHandler(accessTokenService, log)
+	exportHandler := handlers.NewExportHandler(exportService, log)
+	authMiddleware := handlers.NewAuthMiddleware(keys, revokedTokenRepo, accessTokenService, log)
+	authMiddleware.WithAccountStatus(authService)
+	keysHandler := handlers.NewKeysHandler(keys)
 
-	router := handlers.NewRouter(authHandler, taskHandler, authMiddleware, log)
+	realIP := handlers.NewRealIPMiddleware(cfg.TrustedProxies)
+
+	router := handlers.NewRouter(authHandler, taskHandler, tagHandler, projectHandler, tokenHandler, exportHandler, keysHandler, authMiddleware, realIP, log)
 
 	server := &http.Server{
 		Addr:         ":" + cfg.ServerPort,
@@ -78,6 +175,74 @@ func main() {
 	log.Info("server stopped", map[string]any{})
 }
 
+func setupKeySet(cfg config.Config) (*jwt.KeySet, error) {
+	var keys []*jwt.Key
+	if cfg.JWTSecret != "" {
+		key, err := jwt.NewHMACKey(config.DefaultJWTKeyID, []byte(cfg.JWTSecret))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	for _, spec := range cfg.JWTKeys {
+		data, err := os.ReadFile(spec.Path)
+		if err != nil {
+			return nil, fmt.Errorf("read key %q: %w", spec.ID, err)
+		}
+		var key *jwt.Key
+		if jwt.Algorithm(spec.Algorithm) == jwt.HS256 {
+			secret := bytes.TrimSpace(data)
+			if len(secret) < 32 {
+				return nil, fmt.Errorf("key %q: HS256 secret must be at least 32 bytes", spec.ID)
+			}
+			key, err = jwt.NewHMACKey(spec.ID, secret)
+		} else {
+			key, err = jwt.ParsePrivateKeyPEM(spec.ID, data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse key %q: %w", spec.ID, err)
+		}
+		if string(key.Algorithm) != spec.Algorithm {
+			return nil, fmt.Errorf("key %q: file holds a %s key, configured as %s", spec.ID, key.Algorithm, spec.Algorithm)
+		}
+		keys = append(keys, key)
+	}
+	return jwt.NewKeySet(cfg.JWTSigningKeyID, keys...)
+}
+
+func setupPasswordPolicy(cfg config.Config) (password.Policy, error) {
+	policy := password.Policy{
+		MinLength: cfg.PasswordMinLength,
+		MaxLength: cfg.PasswordMaxLength,
+	}
+	if cfg.BreachedPasswordsDir != "" {
+		breached, err := password.NewRangeDir(cfg.BreachedPasswordsDir)
+		if err != nil {
+			return password.Policy{}, err
+		}
+		policy.Breached = breached
+	}
+	return policy, nil
+}
+
+func setupLockout(cfg config.Config, db *sql.DB) *lockout.Service {
+	policy := lockout.DefaultPolicy()
+	policy.MaxAccountFailures = cfg.LoginMaxFailures
+	policy.MaxIPFailures = cfg.LoginMaxFailuresPerIP
+	policy.LockoutDuration = cfg.LoginLockoutDuration
+	if cfg.LoginThrottleStore == "memory" {
+		return lockout.New(memory.NewLoginThrottleRepository(), policy)
+	}
+	return lockout.New(postgres.NewLoginThrottleRepository(db), policy)
+}
+
+func setupMailer(cfg config.Config) (mailer.Mailer, error) {
+	if cfg.MailDriver == "smtp" {
+		return mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom), nil
+	}
+	return mailer.NewFileMailer(cfg.MailDir, cfg.MailFrom)
+}
+
 func setupDatabase(cfg config.Config) (*sql.DB, error) {
 	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
 		cfg.DBUser,
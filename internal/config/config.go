@@ -2,14 +2,22 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"net/netip"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Config contains runtime configuration for the API.
 type Config struct {
 	ServerPort string
+	// TrustedProxies lists the peers whose X-Forwarded-For and X-Real-IP
+	// headers name the client address. Other peers are taken at their
+	// connection address.
+	TrustedProxies []netip.Prefix
 
 	DBHost     string
 	DBPort     string
@@ -18,8 +26,79 @@ type Config struct {
 	DBName     string
 	DBSSLMode  string
 
+	// JWTSecret, when set, is registered as an HS256 key with id DefaultJWTKeyID.
 	JWTSecret string
 	JWTTTL    time.Duration
+	// JWTKeys lists additional signing keys loaded from files.
+	JWTKeys []JWTKeySpec
+	// JWTSigningKeyID selects the key used for new tokens.
+	JWTSigningKeyID string
+
+	RefreshTokenTTL time.Duration
+
+	// EmailVerification is "off", "login" (unverified accounts cannot log in)
+	// or "writes" (unverified accounts cannot modify tasks).
+	EmailVerification string
+
+	// Argon2 cost parameters for password hashing; memory is in KiB.
+	Argon2Memory      int
+	Argon2Iterations  int
+	Argon2Parallelism int
+
+	PasswordMinLength int
+	PasswordMaxLength int
+	// BreachedPasswordsDir holds SHA-1 range files (PREFIX.txt); empty disables the check.
+	BreachedPasswordsDir string
+
+	// LoginMaxFailures locks an account after this many consecutive failed logins.
+	LoginMaxFailures int
+	// LoginMaxFailuresPerIP blocks a client address after this many failed logins.
+	LoginMaxFailuresPerIP int
+	LoginLockoutDuration  time.Duration
+	// LoginThrottleStore is "postgres" to share counters between replicas or "memory".
+	LoginThrottleStore string
+
+	// AccountStatusCacheTTL is how long a user's disabled state is cached
+	// when authenticating requests.
+	AccountStatusCacheTTL time.Duration
+
+	// MFAIssuer names the service in authenticator apps.
+	MFAIssuer string
+
+	// AppBaseURL prefixes links sent by email.
+	AppBaseURL string
+
+	// MailDriver selects delivery: "smtp", or "file" to write messages into MailDir.
+	MailDriver   string
+	MailFrom     string
+	MailDir      string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// OIDCIssuerURL enables single sign-on through the OpenID provider it
+	// names; the client settings below are then required.
+	OIDCIssuerURL    string
+	OIDCClientID     string
+	OIDCClientSecret string
+	// OIDCRedirectURL is the public address of /auth/oidc/callback.
+	OIDCRedirectURL string
+	OIDCScopes      []string
+	// OIDCStateSecret keys the derivation of each login's state, nonce and
+	// PKCE verifier. Defaults to JWTSecret.
+	OIDCStateSecret string
+}
+
+// DefaultJWTKeyID is the key id given to JWT_SECRET.
+const DefaultJWTKeyID = "default"
+
+// JWTKeySpec describes a signing key configured through JWT_KEYS.
+type JWTKeySpec struct {
+	ID        string
+	Algorithm string
+	// Path points to a PEM private key for RS256/EdDSA or a file holding the HS256 secret.
+	Path string
 }
 
 // Load reads configuration from environment variables, applying sensible defaults.
@@ -33,15 +112,132 @@ func Load() (Config, error) {
 		DBName:     getEnv("DB_NAME", "todo"),
 		DBSSLMode:  getEnv("DB_SSL_MODE", "disable"),
 		JWTSecret:  os.Getenv("JWT_SECRET"),
+
+		EmailVerification: getEnv("REQUIRE_EMAIL_VERIFICATION", "off"),
+
+		BreachedPasswordsDir: os.Getenv("BREACHED_PASSWORDS_DIR"),
+
+		LoginThrottleStore: getEnv("LOGIN_THROTTLE_STORE", "postgres"),
+
+		MFAIssuer: getEnv("MFA_ISSUER", "go-todo-service"),
+
+		AppBaseURL:   getEnv("APP_BASE_URL", "http://localhost:8080"),
+		MailDriver:   getEnv("MAIL_DRIVER", "file"),
+		MailFrom:     getEnv("MAIL_FROM", "no-reply@localhost"),
+		MailDir:      getEnv("MAIL_DIR", "mail"),
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+
+		OIDCIssuerURL:    os.Getenv("OIDC_ISSUER_URL"),
+		OIDCClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		OIDCClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		OIDCScopes:       strings.Fields(getEnv("OIDC_SCOPES", "openid email profile")),
+		OIDCStateSecret:  getEnv("OIDC_STATE_SECRET", os.Getenv("JWT_SECRET")),
+	}
+
+	switch cfg.MailDriver {
+	case "file":
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return Config{}, errors.New("SMTP_HOST must be provided when MAIL_DRIVER=smtp")
+		}
+	default:
+		return Config{}, errors.New("MAIL_DRIVER must be smtp or file")
+	}
+
+	if cfg.OIDCIssuerURL != "" {
+		if cfg.OIDCClientID == "" || cfg.OIDCRedirectURL == "" {
+			return Config{}, errors.New("OIDC_CLIENT_ID and OIDC_REDIRECT_URL must be provided when OIDC_ISSUER_URL is set")
+		}
+		if !slices.Contains(cfg.OIDCScopes, "openid") {
+			return Config{}, errors.New("OIDC_SCOPES must include openid")
+		}
+		if len(cfg.OIDCStateSecret) < 32 {
+			return Config{}, errors.New("OIDC_STATE_SECRET must be at least 32 characters when OIDC_ISSUER_URL is set and JWT_SECRET is not")
+		}
+	}
+
+	switch cfg.EmailVerification {
+	case "off", "login", "writes":
+	default:
+		return Config{}, errors.New("REQUIRE_EMAIL_VERIFICATION must be off, login or writes")
+	}
+
+	switch cfg.LoginThrottleStore {
+	case "postgres", "memory":
+	default:
+		return Config{}, errors.New("LOGIN_THROTTLE_STORE must be postgres or memory")
+	}
+	var err error
+	if cfg.LoginMaxFailures, err = positiveIntEnv("LOGIN_MAX_FAILURES", 10); err != nil {
+		return Config{}, err
+	}
+	if cfg.LoginMaxFailuresPerIP, err = positiveIntEnv("LOGIN_MAX_FAILURES_PER_IP", 100); err != nil {
+		return Config{}, err
+	}
+	lockoutMinutes, err := positiveIntEnv("LOGIN_LOCKOUT_MINUTES", 15)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.LoginLockoutDuration = time.Duration(lockoutMinutes) * time.Minute
+
+	statusSeconds, err := positiveIntEnv("ACCOUNT_STATUS_CACHE_SECONDS", 30)
+	if err != nil {
+		return Config{}, err
 	}
+	cfg.AccountStatusCacheTTL = time.Duration(statusSeconds) * time.Second
 
-	if cfg.JWTSecret == "" {
-		return Config{}, errors.New("JWT_SECRET must be provided")
+	if cfg.PasswordMinLength, err = positiveIntEnv("PASSWORD_MIN_LENGTH", 6); err != nil {
+		return Config{}, err
 	}
-	if len(cfg.JWTSecret) < 32 {
+	if cfg.PasswordMaxLength, err = positiveIntEnv("PASSWORD_MAX_LENGTH", 128); err != nil {
+		return Config{}, err
+	}
+	if cfg.PasswordMaxLength < cfg.PasswordMinLength {
+		return Config{}, errors.New("PASSWORD_MAX_LENGTH must not be below PASSWORD_MIN_LENGTH")
+	}
+	if cfg.Argon2Memory, err = positiveIntEnv("PASSWORD_ARGON2_MEMORY_KIB", 19*1024); err != nil {
+		return Config{}, err
+	}
+	if cfg.Argon2Iterations, err = positiveIntEnv("PASSWORD_ARGON2_ITERATIONS", 2); err != nil {
+		return Config{}, err
+	}
+	if cfg.Argon2Parallelism, err = positiveIntEnv("PASSWORD_ARGON2_PARALLELISM", 1); err != nil {
+		return Config{}, err
+	}
+	if cfg.Argon2Parallelism > 255 {
+		return Config{}, errors.New("PASSWORD_ARGON2_PARALLELISM must be at most 255")
+	}
+
+	if cfg.TrustedProxies, err = parseTrustedProxies(os.Getenv("TRUSTED_PROXIES")); err != nil {
+		return Config{}, err
+	}
+
+	keys, err := parseJWTKeys(os.Getenv("JWT_KEYS"))
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.JWTKeys = keys
+
+	if cfg.JWTSecret == "" && len(cfg.JWTKeys) == 0 {
+		return Config{}, errors.New("JWT_SECRET or JWT_KEYS must be provided")
+	}
+	if cfg.JWTSecret != "" && len(cfg.JWTSecret) < 32 {
 		return Config{}, errors.New("JWT_SECRET must be at least 32 characters")
 	}
 
+	cfg.JWTSigningKeyID = os.Getenv("JWT_SIGNING_KEY_ID")
+	if cfg.JWTSigningKeyID == "" {
+		if len(cfg.JWTKeys) > 0 {
+			cfg.JWTSigningKeyID = cfg.JWTKeys[0].ID
+		} else {
+			cfg.JWTSigningKeyID = DefaultJWTKeyID
+		}
+	}
+
 	cfg.JWTTTL = 15 * time.Minute
 	if ttlStr := os.Getenv("JWT_TTL_MINUTES"); ttlStr != "" {
 		minutes, err := strconv.Atoi(ttlStr)
@@ -51,9 +247,74 @@ func Load() (Config, error) {
 		cfg.JWTTTL = time.Duration(minutes) * time.Minute
 	}
 
+	cfg.RefreshTokenTTL = 30 * 24 * time.Hour
+	if ttlStr := os.Getenv("REFRESH_TOKEN_TTL_HOURS"); ttlStr != "" {
+		hours, err := strconv.Atoi(ttlStr)
+		if err != nil || hours <= 0 {
+			return Config{}, errors.New("REFRESH_TOKEN_TTL_HOURS must be a positive integer")
+		}
+		cfg.RefreshTokenTTL = time.Duration(hours) * time.Hour
+	}
+
 	return cfg, nil
 }
 
+// parseJWTKeys reads a comma-separated list of kid:alg:path entries.
+func parseJWTKeys(value string) ([]JWTKeySpec, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var specs []JWTKeySpec
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("JWT_KEYS entry %q must be kid:alg:path", entry)
+		}
+		switch parts[1] {
+		case "HS256", "RS256", "EdDSA":
+		default:
+			return nil, fmt.Errorf("JWT_KEYS entry %q has unsupported algorithm", entry)
+		}
+		specs = append(specs, JWTKeySpec{ID: parts[0], Algorithm: parts[1], Path: parts[2]})
+	}
+	return specs, nil
+}
+
+// parseTrustedProxies reads a comma-separated list of IP addresses and CIDR
+// ranges.
+func parseTrustedProxies(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES entry %q must be an IP address or CIDR range", entry)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return prefixes, nil
+}
+
+// positiveIntEnv reads an optional positive integer variable.
+func positiveIntEnv(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer", key)
+	}
+	return n, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
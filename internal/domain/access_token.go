@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// Scope names a permission that can be granted to a personal access token.
+type Scope string
+
+const (
+	ScopeTasksRead  Scope = "tasks:read"
+	ScopeTasksWrite Scope = "tasks:write"
+)
+
+// AssignableScopes lists the scopes a personal access token may carry.
+var AssignableScopes = []Scope{ScopeTasksRead, ScopeTasksWrite}
+
+// PersonalAccessToken is a user-managed credential for scripts and CI. Only a
+// digest of the token is stored.
+type PersonalAccessToken struct {
+	ID         string
+	UserID     string
+	Name       string
+	TokenHash  string
+	Scopes     []Scope
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "strings"
+
+// DefaultColor is given to tags and projects created without a colour.
+const DefaultColor = "#808080"
+
+// NormalizeColor accepts a #rrggbb hex colour in either case and returns it
+// in lower case.
+func NormalizeColor(value string) (string, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if len(value) != 7 || value[0] != '#' {
+		return "", false
+	}
+	for _, c := range value[1:] {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return "", false
+		}
+	}
+	return value, true
+}
@@ -7,6 +7,9 @@ var (
 	ErrNotFound = errors.New("not found")
 	// ErrConflict represents unique constraint violations.
 	ErrConflict = errors.New("conflict")
+	// ErrConcurrentUpdate indicates a write based on a read that another
+	// write has since superseded.
+	ErrConcurrentUpdate = errors.New("modified concurrently")
 	// ErrInvalidCredentials indicates authentication failure.
 	ErrInvalidCredentials = errors.New("invalid credentials")
 )
@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// Identity links an account at an external OpenID provider, named by its
+// issuer and subject, to a user.
+type Identity struct {
+	Issuer    string
+	Subject   string
+	UserID    string
+	Email     string
+	CreatedAt time.Time
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// LoginThrottle tracks consecutive failed sign-in attempts for one key, such
+// as an account email or a client IP.
+type LoginThrottle struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	// BlockedUntil is set while further attempts are refused.
+	BlockedUntil *time.Time
+}
+
+// BlockedAt reports whether attempts are refused at the given time.
+func (t *LoginThrottle) BlockedAt(at time.Time) bool {
+	return t != nil && t.BlockedUntil != nil && at.Before(*t.BlockedUntil)
+}
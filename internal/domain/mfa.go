@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// MFAEnrollment holds a user's TOTP secret. Two-factor authentication is
+// active once ConfirmedAt is set.
+type MFAEnrollment struct {
+	UserID string
+	Secret string
+	// LastUsedStep is the newest accepted TOTP time step; older or equal
+	// steps are refused so a code cannot be replayed.
+	LastUsedStep int64
+	ConfirmedAt  *time.Time
+	CreatedAt    time.Time
+}
+
+// Enabled reports whether the enrollment has been confirmed.
+func (e *MFAEnrollment) Enabled() bool {
+	return e != nil && e.ConfirmedAt != nil
+}
+
+// RecoveryCode is a single-use fallback for a lost authenticator. Only a
+// digest of the code is stored.
+type RecoveryCode struct {
+	ID        string
+	UserID    string
+	CodeHash  string
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
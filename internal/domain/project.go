@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// InboxName is the name given to the inbox project created for every user.
+const InboxName = "Inbox"
+
+// Project groups a user's tasks. Every user has exactly one inbox project,
+// which collects tasks filed nowhere else and cannot be archived or deleted.
+type Project struct {
+	ID          string
+	UserID      string
+	Name        string
+	Description string
+	// Color is a #rrggbb hex colour.
+	Color    string
+	Archived bool
+	// SortOrder positions the project in listings, lowest first.
+	SortOrder int
+	Inbox     bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// RefreshToken is a long-lived credential exchanged for new access tokens.
+// Every rotation issues a successor in the same family; presenting a token
+// that was already rotated revokes the whole family.
+type RefreshToken struct {
+	ID        string
+	UserID    string
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// Session records one login. Its ID is the refresh token family ID and the
+// sid claim of every access token issued to the login.
+type Session struct {
+	ID        string
+	UserID    string
+	UserAgent string
+	IP        string
+	CreatedAt time.Time
+	// LastSeenAt is refreshed, with some delay, as the session's tokens are used.
+	LastSeenAt time.Time
+	// ExpiresAt is when the session's current refresh token expires.
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// Tag is a user-defined label for tasks. Names are unique per user,
+// ignoring case.
+type Tag struct {
+	ID     string
+	UserID string
+	Name   string
+	// Color is a #rrggbb hex colour.
+	Color     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
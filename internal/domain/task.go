@@ -10,6 +10,71 @@ const (
 	TaskStatusDone    TaskStatus = "done"
 )
 
+// MaxTaskDepth bounds how many levels a task hierarchy may have, counting
+// the top-level task.
+const MaxTaskDepth = 5
+
+// TaskPriority ranks how urgent a task is.
+type TaskPriority string
+
+const (
+	TaskPriorityNone   TaskPriority = "none"
+	TaskPriorityLow    TaskPriority = "low"
+	TaskPriorityMedium TaskPriority = "medium"
+	TaskPriorityHigh   TaskPriority = "high"
+	TaskPriorityUrgent TaskPriority = "urgent"
+)
+
+// taskPriorities lists the priorities from least to most urgent.
+var taskPriorities = []TaskPriority{TaskPriorityNone, TaskPriorityLow, TaskPriorityMedium, TaskPriorityHigh, TaskPriorityUrgent}
+
+// Rank orders priorities from 0 (none) to 4 (urgent). Unknown priorities
+// rank -1.
+func (p TaskPriority) Rank() int {
+	for i, priority := range taskPriorities {
+		if priority == p {
+			return i
+		}
+	}
+	return -1
+}
+
+// Valid reports whether p is a known priority.
+func (p TaskPriority) Valid() bool {
+	return p.Rank() >= 0
+}
+
+// TaskPriorityFromRank is the inverse of Rank. Out of range ranks yield
+// TaskPriorityNone.
+func TaskPriorityFromRank(rank int) TaskPriority {
+	if rank < 0 || rank >= len(taskPriorities) {
+		return TaskPriorityNone
+	}
+	return taskPriorities[rank]
+}
+
+// RecurrenceBasis selects what the next occurrence of a recurring task is
+// scheduled from.
+type RecurrenceBasis string
+
+const (
+	// RecurFromDue continues the schedule from the completed task's due date.
+	RecurFromDue RecurrenceBasis = "due"
+	// RecurFromCompletion restarts the schedule from the day the task was done.
+	RecurFromCompletion RecurrenceBasis = "completion"
+)
+
+// Valid reports whether b is a known basis.
+func (b RecurrenceBasis) Valid() bool {
+	return b == RecurFromDue || b == RecurFromCompletion
+}
+
+// SubtaskProgress counts a task's direct subtasks and how many are done.
+type SubtaskProgress struct {
+	Total int
+	Done  int
+}
+
 // Task represents a todo entry owned by a user.
 type Task struct {
 	ID          string
@@ -17,6 +82,29 @@ type Task struct {
 	Title       string
 	Description string
 	Status      TaskStatus
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	Priority    TaskPriority
+	// ProjectID is the project the task is filed under; empty when projects
+	// are not in use.
+	ProjectID string
+	// ParentID is set on subtasks and names the task they belong to.
+	ParentID string
+	// DueAt is nil when the task has no deadline. For all-day tasks only the
+	// calendar date is meaningful and the value is stored as midnight UTC.
+	DueAt     *time.Time
+	DueAllDay bool
+	// Recurrence is an RFC 5545 RRULE such as "FREQ=WEEKLY;BYDAY=MO"; empty
+	// for one-off tasks. Recurring tasks always have a due date.
+	Recurrence      string
+	RecurrenceBasis RecurrenceBasis
+	// Tags is filled in by the task service when reading tasks, sorted by
+	// name. Repositories leave it nil.
+	Tags []Tag
+	// Subtasks summarises the direct subtasks. Like Tags it is filled in by
+	// the task service.
+	Subtasks SubtaskProgress
+	// NextOccurrence is set by the task service on a recurring task it has
+	// just completed and holds the follow-up task it created.
+	NextOccurrence *Task
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 }
@@ -7,6 +7,79 @@ type User struct {
 	ID           string
 	Email        string
 	PasswordHash string
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	// DisplayName is optional and shown instead of the email where set.
+	DisplayName string
+	// TimeZone is an IANA zone name such as "Europe/Berlin" used for
+	// calendar computations like "due today".
+	TimeZone string
+	// Locale is a BCP 47 language tag such as "en-GB".
+	Locale string
+	Role   Role
+	// DisabledAt is set while an administrator has blocked the account.
+	DisabledAt *time.Time
+	// DisabledReason is the administrator's note on why the account was
+	// blocked. It is not shown to the user.
+	DisabledReason string
+	// DisabledUntil ends a temporary suspension; nil blocks the account
+	// until it is re-enabled.
+	DisabledUntil *time.Time
+	// PasswordResetRequired refuses password logins until the user sets a
+	// new password through the reset flow.
+	PasswordResetRequired bool
+	// EmailVerifiedAt is nil until the user redeems a verification link.
+	EmailVerifiedAt *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// Role grants access to groups of endpoints.
+type Role string
+
+const (
+	// RoleUser is the role of every ordinary account.
+	RoleUser Role = "user"
+	// RoleAdmin may manage other accounts under /admin.
+	RoleAdmin Role = "admin"
+)
+
+// EffectiveRole returns the user's role, treating an unset role as RoleUser.
+func (u *User) EffectiveRole() Role {
+	if u.Role == "" {
+		return RoleUser
+	}
+	return u.Role
+}
+
+// Disabled reports whether the account is blocked at now. Suspensions lapse
+// on their own once DisabledUntil has passed.
+func (u *User) Disabled(now time.Time) bool {
+	if u.DisabledAt == nil {
+		return false
+	}
+	return u.DisabledUntil == nil || now.Before(*u.DisabledUntil)
+}
+
+// Valid reports whether r is a known role.
+func (r Role) Valid() bool {
+	return r == RoleUser || r == RoleAdmin
+}
+
+const (
+	// DefaultTimeZone applies to users who have not chosen a time zone.
+	DefaultTimeZone = "UTC"
+	// DefaultLocale applies to users who have not chosen a locale.
+	DefaultLocale = "en"
+)
+
+// Location resolves the user's time zone, falling back to UTC when it is
+// unset or unknown to this host.
+func (u *User) Location() *time.Location {
+	if u.TimeZone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(u.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
 }
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// TokenPurpose scopes a single-use user token to one flow.
+type TokenPurpose string
+
+const (
+	TokenPurposePasswordReset     TokenPurpose = "password_reset"
+	TokenPurposeEmailVerification TokenPurpose = "email_verification"
+	TokenPurposeMagicLink         TokenPurpose = "magic_link"
+)
+
+// UserToken is a single-use, time-limited token emailed to a user. Only a
+// digest of the token is stored.
+type UserToken struct {
+	ID         string
+	UserID     string
+	Purpose    TokenPurpose
+	TokenHash  string
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	ConsumedAt *time.Time
+}
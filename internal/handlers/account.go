@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/service/auth"
+	"go-todo-service/pkg/password"
+)
+
+// Me handles GET /me.
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	account, err := h.service.GetAccount(r.Context(), userID)
+	if err != nil {
+		h.respondAccountError(w, r, err, "could not load account")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, presentAccount(account))
+}
+
+// UpdateMe handles PATCH /me.
+func (h *AuthHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var payload struct {
+		DisplayName *string `json:"display_name"`
+		TimeZone    *string `json:"time_zone"`
+		Locale      *string `json:"locale"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	account, err := h.service.UpdateProfile(r.Context(), userID, auth.ProfileUpdate{
+		DisplayName: payload.DisplayName,
+		TimeZone:    payload.TimeZone,
+		Locale:      payload.Locale,
+	})
+	if err != nil {
+		h.respondAccountError(w, r, err, "could not update account")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, presentAccount(account))
+}
+
+// ChangePassword handles POST /me/password. Other sessions are signed out.
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var payload struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	if err := h.service.ChangePassword(r.Context(), claims, payload.CurrentPassword, payload.NewPassword); err != nil {
+		h.respondAccountError(w, r, err, "could not change password")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"message": "password updated",
+	})
+}
+
+// ChangeEmail handles POST /me/email. The new address must be verified again.
+func (h *AuthHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var payload struct {
+		CurrentPassword string `json:"current_password"`
+		Email           string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	account, err := h.service.ChangeEmail(r.Context(), claims, payload.CurrentPassword, payload.Email)
+	if err != nil {
+		h.respondAccountError(w, r, err, "could not change email")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, presentAccount(account))
+}
+
+// DeleteMe handles DELETE /me, removing the account and all of its tasks.
+func (h *AuthHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var payload struct {
+		CurrentPassword string `json:"current_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	if err := h.service.DeleteAccount(r.Context(), claims, payload.CurrentPassword); err != nil {
+		h.respondAccountError(w, r, err, "could not delete account")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) respondAccountError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	var policyErr *password.PolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		respondPasswordRejected(w, r, policyErr)
+	case errors.Is(err, auth.ErrIncorrectPassword), errors.Is(err, auth.ErrReauthenticationRequired):
+		respondError(w, r, http.StatusForbidden, err.Error())
+	case errors.Is(err, auth.ErrInvalidEmail), errors.Is(err, auth.ErrInvalidDisplayName),
+		errors.Is(err, auth.ErrInvalidTimeZone), errors.Is(err, auth.ErrInvalidLocale), errors.Is(err, auth.ErrEmailUnchanged):
+		respondError(w, r, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrConflict):
+		respondError(w, r, http.StatusConflict, "email already registered")
+	case errors.Is(err, domain.ErrConcurrentUpdate):
+		respondError(w, r, http.StatusConflict, "account was changed by another request, try again")
+	case errors.Is(err, domain.ErrNotFound):
+		respondError(w, r, http.StatusNotFound, "account not found")
+	default:
+		h.log.Error(msg, map[string]any{"error": err.Error()})
+		respondError(w, r, http.StatusInternalServerError, msg)
+	}
+}
+
+func presentAccount(account *auth.Account) map[string]any {
+	return map[string]any{
+		"id":                account.ID,
+		"email":             account.Email,
+		"display_name":      account.DisplayName,
+		"time_zone":         account.TimeZone,
+		"locale":            account.Locale,
+		"role":              account.EffectiveRole(),
+		"email_verified_at": account.EmailVerifiedAt,
+		"mfa_enabled":       account.MFAEnabled,
+		"created_at":        account.CreatedAt,
+		"updated_at":        account.UpdatedAt,
+	}
+}
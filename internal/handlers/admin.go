@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/service/auth"
+)
+
+// ListUsers handles GET /admin/users.
+func (h *AuthHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	opts := auth.UserListOptions{
+		Query:  query.Get("q"),
+		Cursor: query.Get("cursor"),
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			respondError(w, r, http.StatusBadRequest, auth.ErrInvalidUserLimit.Error())
+			return
+		}
+		opts.Limit = limit
+	}
+
+	page, err := h.service.ListUsers(r.Context(), opts)
+	if err != nil {
+		h.respondAdminError(w, r, err, "could not list users")
+		return
+	}
+
+	items := make([]map[string]any, 0, len(page.Users))
+	for i := range page.Users {
+		items = append(items, presentAdminUser(&page.Users[i]))
+	}
+	var nextCursor *string
+	if page.NextCursor != "" {
+		nextCursor = &page.NextCursor
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"users":       items,
+		"next_cursor": nextCursor,
+	})
+}
+
+// GetUser handles GET /admin/users/{id}.
+func (h *AuthHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.GetUser(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondAdminError(w, r, err, "could not load user")
+		return
+	}
+	respondJSON(w, http.StatusOK, presentAdminUser(user))
+}
+
+// SetUserRole handles PUT /admin/users/{id}/role.
+func (h *AuthHandler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var payload struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	user, err := h.service.SetUserRole(r.Context(), actorID, chi.URLParam(r, "id"), domain.Role(payload.Role))
+	if err != nil {
+		h.respondAdminError(w, r, err, "could not change role")
+		return
+	}
+	respondJSON(w, http.StatusOK, presentAdminUser(user))
+}
+
+// DisableUser handles POST /admin/users/{id}/disable.
+func (h *AuthHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// The body is optional; without one the account is disabled
+	// indefinitely and without a reason.
+	var payload struct {
+		Reason string     `json:"reason"`
+		Until  *time.Time `json:"until"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	opts := auth.DisableOptions{Reason: payload.Reason, Until: payload.Until}
+	user, err := h.service.DisableUser(r.Context(), actorID, chi.URLParam(r, "id"), opts)
+	if err != nil {
+		h.respondAdminError(w, r, err, "could not disable user")
+		return
+	}
+	respondJSON(w, http.StatusOK, presentAdminUser(user))
+}
+
+// EnableUser handles POST /admin/users/{id}/enable.
+func (h *AuthHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.EnableUser(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondAdminError(w, r, err, "could not enable user")
+		return
+	}
+	respondJSON(w, http.StatusOK, presentAdminUser(user))
+}
+
+// ForcePasswordReset handles POST /admin/users/{id}/password-reset.
+func (h *AuthHandler) ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.ForcePasswordReset(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondAdminError(w, r, err, "could not force password reset")
+		return
+	}
+	respondJSON(w, http.StatusOK, presentAdminUser(user))
+}
+
+// ListLockouts handles GET /admin/lockouts.
+func (h *AuthHandler) ListLockouts(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.service.ListBlockedLogins(r.Context())
+	if err != nil {
+		h.respondAdminError(w, r, err, "could not list lockouts")
+		return
+	}
+
+	items := make([]map[string]any, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, map[string]any{
+			"kind":          entry.Kind,
+			"subject":       entry.Subject,
+			"failures":      entry.Failures,
+			"last_failure":  entry.LastFailure,
+			"blocked_until": entry.BlockedUntil,
+		})
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"lockouts": items})
+}
+
+// Unlock handles DELETE /admin/lockouts/{kind}/{subject}.
+func (h *AuthHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	subject := strings.TrimSpace(chi.URLParam(r, "subject"))
+	if err := h.service.UnlockLogin(r.Context(), chi.URLParam(r, "kind"), subject); err != nil {
+		h.respondAdminError(w, r, err, "could not unlock")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) respondAdminError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		respondError(w, r, http.StatusNotFound, "user not found")
+	case errors.Is(err, auth.ErrInvalidRole),
+		errors.Is(err, auth.ErrInvalidUserCursor),
+		errors.Is(err, auth.ErrInvalidUserLimit),
+		errors.Is(err, auth.ErrInvalidLockoutKind),
+		errors.Is(err, auth.ErrInvalidDisableReason),
+		errors.Is(err, auth.ErrInvalidSuspension):
+		respondError(w, r, http.StatusBadRequest, err.Error())
+	case errors.Is(err, auth.ErrCannotModifySelf):
+		respondError(w, r, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrConcurrentUpdate):
+		respondError(w, r, http.StatusConflict, "user was changed by another request, try again")
+	case errors.Is(err, auth.ErrLockoutUnavailable):
+		respondError(w, r, http.StatusNotImplemented, err.Error())
+	default:
+		h.log.Error(msg, map[string]any{"error": err.Error()})
+		respondError(w, r, http.StatusInternalServerError, msg)
+	}
+}
+
+func presentAdminUser(user *domain.User) map[string]any {
+	return map[string]any{
+		"id":                      user.ID,
+		"email":                   user.Email,
+		"display_name":            user.DisplayName,
+		"role":                    user.EffectiveRole(),
+		"email_verified_at":       user.EmailVerifiedAt,
+		"disabled":                user.Disabled(time.Now()),
+		"disabled_at":             user.DisabledAt,
+		"disabled_reason":         user.DisabledReason,
+		"disabled_until":          user.DisabledUntil,
+		"password_reset_required": user.PasswordResetRequired,
+		"created_at":              user.CreatedAt,
+		"updated_at":              user.UpdatedAt,
+	}
+}
@@ -3,11 +3,18 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"io"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 
 	"go-todo-service/internal/domain"
 	"go-todo-service/internal/service/auth"
+	"go-todo-service/internal/service/lockout"
 	"go-todo-service/pkg/logger"
+	"go-todo-service/pkg/password"
 )
 
 // AuthHandler exposes authentication endpoints.
@@ -37,8 +44,11 @@ func (h *AuthHandler) Signup(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.service.Signup(r.Context(), payload.Email, payload.Password)
 	if err != nil {
+		var policyErr *password.PolicyError
 		switch {
-		case errors.Is(err, auth.ErrInvalidEmail), errors.Is(err, auth.ErrWeakPassword):
+		case errors.As(err, &policyErr):
+			respondPasswordRejected(w, r, policyErr)
+		case errors.Is(err, auth.ErrInvalidEmail):
 			respondError(w, r, http.StatusBadRequest, err.Error())
 		case errors.Is(err, domain.ErrConflict):
 			respondError(w, r, http.StatusConflict, "email already registered")
@@ -49,13 +59,23 @@ func (h *AuthHandler) Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := h.service.SendVerificationEmail(r.Context(), user.ID); err != nil {
+		h.log.Error("verification email failed", map[string]any{
+			"error":      err.Error(),
+			"request_id": requestIDFromContextOrEmpty(r.Context()),
+		})
+	}
+
 	respondJSON(w, http.StatusCreated, map[string]any{
 		"message": "signup successful",
 		"user": map[string]any{
-			"id":         user.ID,
-			"email":      user.Email,
-			"created_at": user.CreatedAt,
-			"updated_at": user.UpdatedAt,
+			"id":                user.ID,
+			"email":             user.Email,
+			"time_zone":         user.TimeZone,
+			"locale":            user.Locale,
+			"email_verified_at": user.EmailVerifiedAt,
+			"created_at":        user.CreatedAt,
+			"updated_at":        user.UpdatedAt,
 		},
 	})
 }
@@ -71,18 +91,282 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.service.Login(r.Context(), payload.Email, payload.Password)
+	tokens, err := h.service.Login(r.Context(), payload.Email, payload.Password, clientInfo(r))
 	if err != nil {
-		if errors.Is(err, domain.ErrInvalidCredentials) {
+		var blocked *lockout.BlockedError
+		switch {
+		case errors.As(err, &blocked):
+			respondBlocked(w, r, blocked)
+		case errors.Is(err, domain.ErrInvalidCredentials):
 			respondError(w, r, http.StatusUnauthorized, "invalid credentials")
-		} else {
+		case errors.Is(err, auth.ErrEmailNotVerified),
+			errors.Is(err, auth.ErrAccountDisabled),
+			errors.Is(err, auth.ErrPasswordResetRequired):
+			respondError(w, r, http.StatusForbidden, err.Error())
+		default:
 			h.log.Error("login failed", map[string]any{"error": err.Error()})
 			respondError(w, r, http.StatusInternalServerError, "could not login user")
 		}
 		return
 	}
 
+	respondJSON(w, http.StatusOK, presentTokens(tokens))
+}
+
+// Refresh handles POST /auth/refresh.
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	tokens, err := h.service.Refresh(r.Context(), payload.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidRefreshToken):
+			respondError(w, r, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, auth.ErrRefreshTokenReused):
+			h.log.Info("refresh token reuse detected", map[string]any{
+				"request_id": requestIDFromContextOrEmpty(r.Context()),
+			})
+			respondError(w, r, http.StatusUnauthorized, "invalid refresh token")
+		case errors.Is(err, auth.ErrAccountDisabled):
+			respondError(w, r, http.StatusForbidden, err.Error())
+		default:
+			h.log.Error("refresh failed", map[string]any{"error": err.Error()})
+			respondError(w, r, http.StatusInternalServerError, "could not refresh token")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, presentTokens(tokens))
+}
+
+// Logout handles POST /auth/logout.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var payload struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+			respondError(w, r, http.StatusBadRequest, "invalid json payload")
+			return
+		}
+	}
+
+	if err := h.service.Logout(r.Context(), claims, payload.RefreshToken); err != nil {
+		if errors.Is(err, auth.ErrTokenNotRevocable) {
+			respondError(w, r, http.StatusBadRequest, err.Error())
+		} else {
+			h.log.Error("logout failed", map[string]any{"error": err.Error()})
+			respondError(w, r, http.StatusInternalServerError, "could not logout")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ForgotPassword handles POST /auth/password/forgot. It answers 202 whether
+// or not the email is registered, and 429 only once the client IP has made
+// too many requests.
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(r.Context(), payload.Email, clientInfo(r)); err != nil {
+		var blocked *lockout.BlockedError
+		if errors.As(err, &blocked) {
+			respondBlocked(w, r, blocked)
+			return
+		}
+		h.log.Error("password reset request failed", map[string]any{
+			"error":      err.Error(),
+			"request_id": requestIDFromContextOrEmpty(r.Context()),
+		})
+	}
+
+	respondJSON(w, http.StatusAccepted, map[string]string{
+		"message": "if the email is registered, a reset link has been sent",
+	})
+}
+
+// ResetPassword handles POST /auth/password/reset.
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	if err := h.service.ResetPassword(r.Context(), payload.Token, payload.Password); err != nil {
+		var policyErr *password.PolicyError
+		switch {
+		case errors.As(err, &policyErr):
+			respondPasswordRejected(w, r, policyErr)
+		case errors.Is(err, auth.ErrInvalidResetToken):
+			respondError(w, r, http.StatusBadRequest, err.Error())
+		default:
+			h.log.Error("password reset failed", map[string]any{"error": err.Error()})
+			respondError(w, r, http.StatusInternalServerError, "could not reset password")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"message": "password updated",
+	})
+}
+
+// VerifyEmail handles POST /auth/verify.
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	if err := h.service.VerifyEmail(r.Context(), payload.Token); err != nil {
+		if errors.Is(err, auth.ErrInvalidVerificationToken) {
+			respondError(w, r, http.StatusBadRequest, err.Error())
+		} else {
+			h.log.Error("email verification failed", map[string]any{"error": err.Error()})
+			respondError(w, r, http.StatusInternalServerError, "could not verify email")
+		}
+		return
+	}
+
 	respondJSON(w, http.StatusOK, map[string]string{
-		"token": token,
+		"message": "email verified",
+	})
+}
+
+// ResendVerification handles POST /auth/verify/resend. Like ForgotPassword it
+// always answers 202; repeated requests within the throttle window are
+// dropped without telling the caller.
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	if err := h.service.ResendVerificationEmail(r.Context(), payload.Email); err != nil {
+		h.log.Error("verification resend failed", map[string]any{
+			"error":      err.Error(),
+			"request_id": requestIDFromContextOrEmpty(r.Context()),
+		})
+	}
+
+	respondJSON(w, http.StatusAccepted, map[string]string{
+		"message": "if the email is registered and unverified, a verification link has been sent",
 	})
 }
+
+// RequireVerifiedEmail rejects requests from users whose email is unverified
+// when the verification policy restricts writes. It must run after the auth
+// middleware.
+func (h *AuthHandler) RequireVerifiedEmail(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := UserIDFromContext(r.Context())
+		if !ok {
+			respondError(w, r, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if err := h.service.CheckWriteAllowed(r.Context(), userID); err != nil {
+			if errors.Is(err, auth.ErrEmailNotVerified) {
+				respondError(w, r, http.StatusForbidden, err.Error())
+			} else {
+				h.log.Error("verification check failed", map[string]any{"error": err.Error()})
+				respondError(w, r, http.StatusInternalServerError, "could not authorize request")
+			}
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// respondPasswordRejected answers 400 with every failed policy rule so
+// clients can show them next to the password field.
+func respondPasswordRejected(w http.ResponseWriter, r *http.Request, policyErr *password.PolicyError) {
+	response := map[string]any{
+		"error":   password.ErrPolicyViolation.Error(),
+		"reasons": policyErr.Violations,
+	}
+	if requestID, ok := RequestIDFromContext(r.Context()); ok {
+		response["request_id"] = requestID
+	}
+	respondJSON(w, http.StatusBadRequest, response)
+}
+
+// respondBlocked answers a refused sign-in attempt: 423 for a locked account,
+// 429 while backing off or when the client IP is blocked.
+func respondBlocked(w http.ResponseWriter, r *http.Request, blocked *lockout.BlockedError) {
+	w.Header().Set("Retry-After", retryAfterSeconds(blocked.RetryAfter))
+	if blocked.Locked {
+		respondError(w, r, http.StatusLocked, "account temporarily locked after repeated failed attempts")
+		return
+	}
+	respondError(w, r, http.StatusTooManyRequests, "too many attempts, try again later")
+}
+
+// clientInfo extracts the client address and user agent. RealIPMiddleware
+// has already replaced RemoteAddr with the forwarded address when a trusted
+// proxy sent one.
+func clientInfo(r *http.Request) auth.ClientInfo {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return auth.ClientInfo{IP: ip, UserAgent: r.UserAgent()}
+}
+
+// retryAfterSeconds formats a Retry-After header value, rounding up.
+func retryAfterSeconds(d time.Duration) string {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds)
+}
+
+func presentTokens(tokens *auth.Tokens) map[string]any {
+	if tokens.MFAToken != "" {
+		return map[string]any{
+			"mfa_required":   true,
+			"mfa_token":      tokens.MFAToken,
+			"mfa_expires_at": tokens.MFAExpiresAt,
+		}
+	}
+	response := map[string]any{
+		"token":      tokens.AccessToken,
+		"token_type": "Bearer",
+		"expires_at": tokens.AccessExpiresAt,
+	}
+	if tokens.RefreshToken != "" {
+		response["refresh_token"] = tokens.RefreshToken
+		response["refresh_expires_at"] = tokens.RefreshExpiresAt
+	}
+	return response
+}
@@ -1,12 +1,19 @@
 package handlers
 
-import "context"
+import (
+	"context"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/pkg/jwt"
+)
 
 type contextKey string
 
 const (
 	userIDKey    contextKey = "userID"
 	requestIDKey contextKey = "requestID"
+	claimsKey    contextKey = "claims"
+	scopesKey    contextKey = "scopes"
 )
 
 // WithUserID stores the authenticated user id in the context.
@@ -20,6 +27,30 @@ func UserIDFromContext(ctx context.Context) (string, bool) {
 	return value, ok && value != ""
 }
 
+// WithClaims stores the validated access-token claims in the context.
+func WithClaims(ctx context.Context, claims *jwt.Claims) context.Context {
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// ClaimsFromContext extracts the validated access-token claims if present.
+func ClaimsFromContext(ctx context.Context) (*jwt.Claims, bool) {
+	value, ok := ctx.Value(claimsKey).(*jwt.Claims)
+	return value, ok && value != nil
+}
+
+// WithScopes records that the request was authenticated with a personal
+// access token limited to the given scopes.
+func WithScopes(ctx context.Context, scopes []domain.Scope) context.Context {
+	return context.WithValue(ctx, scopesKey, scopes)
+}
+
+// ScopesFromContext returns the scopes of a personal access token. The boolean
+// is false for JWT sessions, which are not scope-restricted.
+func ScopesFromContext(ctx context.Context) ([]domain.Scope, bool) {
+	value, ok := ctx.Value(scopesKey).([]domain.Scope)
+	return value, ok
+}
+
 // WithRequestID stores the request ID on the context.
 func WithRequestID(ctx context.Context, requestID string) context.Context {
 	return context.WithValue(ctx, requestIDKey, requestID)
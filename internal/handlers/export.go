@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/service/export"
+	"go-todo-service/pkg/logger"
+)
+
+const (
+	// exportTimeout bounds an export, replacing the server write timeout and
+	// the router's request timeout for that route.
+	exportTimeout = 10 * time.Minute
+	// importTimeout bounds uploading and applying an import, replacing the
+	// server read and write timeouts and the router's request timeout so an
+	// archive up to export.MaxArchiveSize fits on a slow uplink.
+	importTimeout = 10 * time.Minute
+)
+
+// ExportHandler serves account data export and import.
+type ExportHandler struct {
+	service *export.Service
+	log     *logger.Logger
+}
+
+// NewExportHandler constructs the handler.
+func NewExportHandler(service *export.Service, log *logger.Logger) *ExportHandler {
+	return &ExportHandler{service: service, log: log}
+}
+
+// Export handles GET /me/export by streaming a zip archive.
+func (h *ExportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	deadline := time.Now().Add(exportTimeout)
+	if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.log.Error("export write deadline failed", map[string]any{"error": err.Error(), "user_id": userID})
+	}
+	ctx, cancel := context.WithDeadline(r.Context(), deadline)
+	defer cancel()
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="todo-export-%s.zip"`, userID))
+	out := &streamWriter{ResponseWriter: w, contentType: "application/zip"}
+	if err := h.service.Export(ctx, userID, out); err != nil {
+		h.log.Error("export failed", map[string]any{"error": err.Error(), "user_id": userID})
+		// Once archive bytes are out the status is sent; the truncated
+		// archive then fails to open on the client.
+		if out.started {
+			return
+		}
+		w.Header().Del("Content-Disposition")
+		if errors.Is(err, domain.ErrNotFound) {
+			respondError(w, r, http.StatusNotFound, "account not found")
+		} else {
+			respondError(w, r, http.StatusInternalServerError, "could not export account")
+		}
+	}
+}
+
+// Import handles POST /me/import. The body is an archive produced by Export.
+func (h *ExportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	deadline := time.Now().Add(importTimeout)
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.log.Error("import read deadline failed", map[string]any{"error": err.Error(), "user_id": userID})
+	}
+	if err := rc.SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.log.Error("import write deadline failed", map[string]any{"error": err.Error(), "user_id": userID})
+	}
+	ctx, cancel := context.WithDeadline(r.Context(), deadline)
+	defer cancel()
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, export.MaxArchiveSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, r, http.StatusRequestEntityTooLarge, "archive too large")
+		} else {
+			respondError(w, r, http.StatusBadRequest, "could not read archive")
+		}
+		return
+	}
+
+	mode := export.ConflictMode(r.URL.Query().Get("on_conflict"))
+	result, err := h.service.Import(ctx, userID, bytes.NewReader(body), int64(len(body)), mode)
+	if err != nil {
+		switch {
+		case errors.Is(err, export.ErrInvalidArchive),
+			errors.Is(err, export.ErrUnsupportedVersion),
+			errors.Is(err, export.ErrInvalidConflictMode):
+			respondError(w, r, http.StatusBadRequest, err.Error())
+		case errors.Is(err, domain.ErrConflict):
+			respondError(w, r, http.StatusConflict, "tags changed during import; try again")
+		default:
+			h.log.Error("import failed", map[string]any{"error": err.Error()})
+			respondError(w, r, http.StatusInternalServerError, "could not import archive")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"created": result.Created,
+		"updated": result.Updated,
+		"skipped": result.Skipped,
+		"id_map":  result.IDMap,
+	})
+}
+
+// streamWriter sets the content type on the first write so errors raised
+// before any output can still be answered as JSON.
+type streamWriter struct {
+	http.ResponseWriter
+	contentType string
+	started     bool
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.Header().Set("Content-Type", w.contentType)
+	}
+	return w.ResponseWriter.Write(p)
+}
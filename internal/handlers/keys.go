@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go-todo-service/pkg/jwt"
+)
+
+// KeysHandler publishes the public keys used to verify access tokens.
+type KeysHandler struct {
+	keys *jwt.KeySet
+}
+
+// NewKeysHandler constructs the handler.
+func NewKeysHandler(keys *jwt.KeySet) *KeysHandler {
+	return &KeysHandler{keys: keys}
+}
+
+// JWKS handles GET /.well-known/jwks.json.
+func (h *KeysHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondJSON(w, http.StatusOK, h.keys.JWKS())
+}
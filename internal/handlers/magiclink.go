@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go-todo-service/internal/service/auth"
+	"go-todo-service/internal/service/lockout"
+)
+
+// RequestMagicLink handles POST /auth/magic-link.
+func (h *AuthHandler) RequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	if err := h.service.RequestMagicLink(r.Context(), payload.Email, clientInfo(r)); err != nil {
+		var blocked *lockout.BlockedError
+		switch {
+		case errors.As(err, &blocked):
+			respondBlocked(w, r, blocked)
+			return
+		case errors.Is(err, auth.ErrInvalidEmail):
+			respondError(w, r, http.StatusBadRequest, err.Error())
+			return
+		case errors.Is(err, auth.ErrMagicLinkUnavailable):
+			respondError(w, r, http.StatusNotImplemented, err.Error())
+			return
+		default:
+			h.log.Error("magic link request failed", map[string]any{
+				"error":      err.Error(),
+				"request_id": requestIDFromContextOrEmpty(r.Context()),
+			})
+		}
+	}
+
+	respondJSON(w, http.StatusAccepted, map[string]string{
+		"message": "if the email is registered, a sign-in link has been sent",
+	})
+}
+
+// ConsumeMagicLink handles POST /auth/magic-link/consume.
+func (h *AuthHandler) ConsumeMagicLink(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	tokens, err := h.service.ConsumeMagicLink(r.Context(), payload.Token, clientInfo(r))
+	if err != nil {
+		var blocked *lockout.BlockedError
+		switch {
+		case errors.As(err, &blocked):
+			respondBlocked(w, r, blocked)
+		case errors.Is(err, auth.ErrInvalidMagicLink):
+			respondError(w, r, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, auth.ErrAccountDisabled), errors.Is(err, auth.ErrPasswordResetRequired):
+			respondError(w, r, http.StatusForbidden, err.Error())
+		case errors.Is(err, auth.ErrMagicLinkUnavailable):
+			respondError(w, r, http.StatusNotImplemented, err.Error())
+		default:
+			h.log.Error("magic link sign-in failed", map[string]any{"error": err.Error()})
+			respondError(w, r, http.StatusInternalServerError, "could not login user")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, presentTokens(tokens))
+}
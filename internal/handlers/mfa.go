@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go-todo-service/internal/service/auth"
+	"go-todo-service/internal/service/lockout"
+)
+
+// EnrollMFA handles POST /auth/mfa/enroll.
+func (h *AuthHandler) EnrollMFA(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	setup, err := h.service.BeginMFAEnrollment(r.Context(), userID)
+	if err != nil {
+		h.respondMFAError(w, r, err, "could not start enrollment")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"secret":      setup.Secret,
+		"otpauth_uri": setup.URI,
+	})
+}
+
+// ConfirmMFA handles POST /auth/mfa/confirm.
+func (h *AuthHandler) ConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var payload struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	codes, err := h.service.ConfirmMFAEnrollment(r.Context(), userID, payload.Code)
+	if err != nil {
+		h.respondMFAError(w, r, err, "could not confirm enrollment")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"recovery_codes": codes,
+	})
+}
+
+// DisableMFA handles POST /auth/mfa/disable.
+func (h *AuthHandler) DisableMFA(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var payload struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	if err := h.service.DisableMFA(r.Context(), userID, payload.Code); err != nil {
+		h.respondMFAError(w, r, err, "could not disable two-factor authentication")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyMFA handles POST /auth/mfa/verify, the second step of a login.
+func (h *AuthHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	tokens, err := h.service.VerifyMFA(r.Context(), payload.MFAToken, payload.Code, clientInfo(r))
+	if err != nil {
+		var blocked *lockout.BlockedError
+		switch {
+		case errors.As(err, &blocked):
+			respondBlocked(w, r, blocked)
+		case errors.Is(err, auth.ErrInvalidMFAChallenge), errors.Is(err, auth.ErrInvalidMFACode):
+			respondError(w, r, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, auth.ErrAccountDisabled), errors.Is(err, auth.ErrPasswordResetRequired):
+			respondError(w, r, http.StatusForbidden, err.Error())
+		default:
+			h.log.Error("mfa verification failed", map[string]any{"error": err.Error()})
+			respondError(w, r, http.StatusInternalServerError, "could not verify code")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, presentTokens(tokens))
+}
+
+func (h *AuthHandler) respondMFAError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidMFACode):
+		respondError(w, r, http.StatusBadRequest, err.Error())
+	case errors.Is(err, auth.ErrMFAAlreadyEnabled), errors.Is(err, auth.ErrMFANotEnrolled):
+		respondError(w, r, http.StatusConflict, err.Error())
+	case errors.Is(err, auth.ErrMFANotConfigured):
+		respondError(w, r, http.StatusNotImplemented, err.Error())
+	default:
+		h.log.Error(msg, map[string]any{"error": err.Error()})
+		respondError(w, r, http.StatusInternalServerError, msg)
+	}
+}
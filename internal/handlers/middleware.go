@@ -4,24 +4,45 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository"
+	"go-todo-service/internal/service/auth"
+	"go-todo-service/internal/service/pat"
 	"go-todo-service/pkg/jwt"
 	"go-todo-service/pkg/logger"
 )
 
-// AuthMiddleware verifies JWT tokens on protected routes.
+// AuthMiddleware verifies JWT tokens and personal access tokens on protected routes.
 type AuthMiddleware struct {
-	secret string
-	log    *logger.Logger
+	keys          *jwt.KeySet
+	revokedTokens repository.RevokedTokenRepository
+	accessTokens  *pat.Service
+	accounts      *auth.Service
+	log           *logger.Logger
 }
 
-// NewAuthMiddleware constructs the middleware.
-func NewAuthMiddleware(secret string, log *logger.Logger) *AuthMiddleware {
+// NewAuthMiddleware constructs the middleware. revokedTokens may be nil to
+// skip the denylist check and accessTokens may be nil to reject personal
+// access tokens.
+func NewAuthMiddleware(keys *jwt.KeySet, revokedTokens repository.RevokedTokenRepository, accessTokens *pat.Service, log *logger.Logger) *AuthMiddleware {
 	return &AuthMiddleware{
-		secret: secret,
-		log:    log,
+		keys:          keys,
+		revokedTokens: revokedTokens,
+		accessTokens:  accessTokens,
+		log:           log,
+	}
+}
+
+// WithAccountStatus rejects tokens of disabled, suspended or deleted
+// accounts and access tokens of ended sessions, using accounts to look up
+// their state.
+func (m *AuthMiddleware) WithAccountStatus(accounts *auth.Service) {
+	if accounts != nil {
+		m.accounts = accounts
 	}
 }
 
@@ -40,7 +61,12 @@ func (m *AuthMiddleware) Wrap(next http.Handler) http.Handler {
 			return
 		}
 		token := strings.TrimSpace(parts[1])
-		claims, err := jwt.ParseAndValidate(token, m.secret, time.Now())
+		if pat.IsToken(token) {
+			m.authenticateAccessToken(w, r, next, token)
+			return
+		}
+
+		claims, err := jwt.ParseAndValidate(token, m.keys, time.Now())
 		if err != nil {
 			switch {
 			case errors.Is(err, jwt.ErrExpiredToken):
@@ -57,11 +83,138 @@ func (m *AuthMiddleware) Wrap(next http.Handler) http.Handler {
 			return
 		}
 
+		if claims.Purpose != "" {
+			m.respondUnauthorized(w, r, "invalid token")
+			return
+		}
+
+		if m.revokedTokens != nil && claims.ID != "" {
+			revoked, err := m.revokedTokens.IsRevoked(r.Context(), claims.ID)
+			if err != nil {
+				m.log.Error("token revocation check failed", map[string]any{
+					"error":      err.Error(),
+					"request_id": requestIDFromContextOrEmpty(r.Context()),
+				})
+				respondError(w, r, http.StatusInternalServerError, "could not validate token")
+				return
+			}
+			if revoked {
+				m.respondUnauthorized(w, r, "token revoked")
+				return
+			}
+		}
+
+		if !m.checkAccount(w, r, claims.Subject) || !m.checkSession(w, r, claims) {
+			return
+		}
+
 		ctx := WithUserID(r.Context(), claims.Subject)
+		ctx = WithClaims(ctx, claims)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+func (m *AuthMiddleware) authenticateAccessToken(w http.ResponseWriter, r *http.Request, next http.Handler, token string) {
+	if m.accessTokens == nil {
+		m.respondUnauthorized(w, r, "invalid token")
+		return
+	}
+	accessToken, err := m.accessTokens.Authenticate(r.Context(), token)
+	if err != nil {
+		if errors.Is(err, pat.ErrInvalidToken) {
+			m.respondUnauthorized(w, r, "invalid token")
+			return
+		}
+		m.log.Error("personal access token validation failed", map[string]any{
+			"error":      err.Error(),
+			"request_id": requestIDFromContextOrEmpty(r.Context()),
+		})
+		respondError(w, r, http.StatusInternalServerError, "could not validate token")
+		return
+	}
+
+	if !m.checkAccount(w, r, accessToken.UserID) {
+		return
+	}
+
+	ctx := WithUserID(r.Context(), accessToken.UserID)
+	ctx = WithScopes(ctx, accessToken.Scopes)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// checkAccount reports whether the token owner may still use the API and
+// responds otherwise.
+func (m *AuthMiddleware) checkAccount(w http.ResponseWriter, r *http.Request, userID string) bool {
+	if m.accounts == nil {
+		return true
+	}
+	err := m.accounts.CheckAccountActive(r.Context(), userID)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, auth.ErrAccountDisabled):
+		m.respondUnauthorized(w, r, "account disabled")
+	case errors.Is(err, domain.ErrNotFound):
+		m.respondUnauthorized(w, r, "invalid token")
+	default:
+		m.log.Error("account status check failed", map[string]any{
+			"error":      err.Error(),
+			"request_id": requestIDFromContextOrEmpty(r.Context()),
+		})
+		respondError(w, r, http.StatusInternalServerError, "could not validate token")
+	}
+	return false
+}
+
+// RequireScope limits personal access tokens to those granted scope. JWT
+// sessions are not scope-restricted.
+func RequireScope(scope domain.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if scopes, restricted := ScopesFromContext(r.Context()); restricted && !slices.Contains(scopes, scope) {
+				respondError(w, r, http.StatusForbidden, "token lacks required scope "+string(scope))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireRole admits JWT sessions whose role claim is one of roles. Personal
+// access tokens never carry a role and are refused.
+func RequireRole(roles ...domain.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				respondError(w, r, http.StatusForbidden, "forbidden")
+				return
+			}
+			role := domain.Role(claims.Role)
+			if role == "" {
+				role = domain.RoleUser
+			}
+			if !slices.Contains(roles, role) {
+				respondError(w, r, http.StatusForbidden, "forbidden")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession rejects requests authenticated with a personal access token,
+// for endpoints that must only be reachable from an interactive login.
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, restricted := ScopesFromContext(r.Context()); restricted {
+			respondError(w, r, http.StatusForbidden, "personal access tokens cannot access this endpoint")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (m *AuthMiddleware) respondUnauthorized(w http.ResponseWriter, r *http.Request, message string) {
 	m.log.Info("auth failure", map[string]any{
 		"reason":     message,
@@ -71,6 +224,28 @@ func (m *AuthMiddleware) respondUnauthorized(w http.ResponseWriter, r *http.Requ
 	respondError(w, r, http.StatusUnauthorized, message)
 }
 
+// checkSession reports whether the session behind an access token is still
+// active and responds otherwise.
+func (m *AuthMiddleware) checkSession(w http.ResponseWriter, r *http.Request, claims *jwt.Claims) bool {
+	if m.accounts == nil {
+		return true
+	}
+	err := m.accounts.CheckSession(r.Context(), claims.Subject, claims.SessionID)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, auth.ErrSessionRevoked):
+		m.respondUnauthorized(w, r, "session revoked")
+	default:
+		m.log.Error("session check failed", map[string]any{
+			"error":      err.Error(),
+			"request_id": requestIDFromContextOrEmpty(r.Context()),
+		})
+		respondError(w, r, http.StatusInternalServerError, "could not validate token")
+	}
+	return false
+}
+
 func requestIDFromContextOrEmpty(ctx context.Context) string {
 	if id, ok := RequestIDFromContext(ctx); ok {
 		return id
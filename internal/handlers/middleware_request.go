@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"net"
 	"net/http"
+	"net/netip"
 	"runtime/debug"
+	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5/middleware"
+
 	"go-todo-service/pkg/logger"
 	"go-todo-service/pkg/uuid"
 )
@@ -61,6 +66,103 @@ func (m *RequestLoggerMiddleware) Wrap(next http.Handler) http.Handler {
 	})
 }
 
+// RealIPMiddleware replaces RemoteAddr with the client address forwarded by
+// a trusted proxy. Forwarding headers sent by any other peer are ignored, so
+// clients cannot pick the address their requests are attributed to.
+type RealIPMiddleware struct {
+	trusted []netip.Prefix
+}
+
+// NewRealIPMiddleware constructs the middleware. With no trusted proxies
+// every request keeps its connection address.
+func NewRealIPMiddleware(trusted []netip.Prefix) *RealIPMiddleware {
+	return &RealIPMiddleware{trusted: trusted}
+}
+
+// Wrap applies the forwarded address of requests from trusted proxies.
+func (m *RealIPMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip, ok := m.forwardedIP(r); ok {
+			r.RemoteAddr = ip
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// forwardedIP reads the client address from a request sent by a trusted
+// proxy. Proxies append the address they received a request from to
+// X-Forwarded-For, so the client is the rightmost entry that is not itself a
+// trusted proxy; everything left of it could have been sent by the client.
+func (m *RealIPMiddleware) forwardedIP(r *http.Request) (string, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer, err := netip.ParseAddr(host)
+	if err != nil || !m.trusts(peer) {
+		return "", false
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		var client netip.Addr
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				return "", false
+			}
+			client = addr
+			if !m.trusts(addr) {
+				break
+			}
+		}
+		return client.Unmap().String(), true
+	}
+	if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return addr.Unmap().String(), true
+	}
+	return "", false
+}
+
+func (m *RealIPMiddleware) trusts(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range m.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// TimeoutMiddleware cancels request contexts after a fixed timeout, except
+// on paths that manage their own deadline.
+type TimeoutMiddleware struct {
+	timeout time.Duration
+	exempt  map[string]bool
+}
+
+// NewTimeoutMiddleware constructs the middleware. Requests to the exempt
+// paths are passed through untouched.
+func NewTimeoutMiddleware(timeout time.Duration, exempt ...string) *TimeoutMiddleware {
+	paths := make(map[string]bool, len(exempt))
+	for _, path := range exempt {
+		paths[path] = true
+	}
+	return &TimeoutMiddleware{timeout: timeout, exempt: paths}
+}
+
+// Wrap applies the timeout to non-exempt requests.
+func (m *TimeoutMiddleware) Wrap(next http.Handler) http.Handler {
+	limited := middleware.Timeout(m.timeout)(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.exempt[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		limited.ServeHTTP(w, r)
+	})
+}
+
 // RecoveryMiddleware captures panics and returns a 500 response.
 type RecoveryMiddleware struct {
 	log *logger.Logger
@@ -96,3 +198,8 @@ func (r *responseRecorder) WriteHeader(statusCode int) {
 	r.status = statusCode
 	r.ResponseWriter.WriteHeader(statusCode)
 }
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
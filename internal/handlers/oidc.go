@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/service/auth"
+)
+
+const (
+	// oidcStateCookie carries the signed login state between the redirect to
+	// the identity provider and the callback.
+	oidcStateCookie = "oidc_state"
+	oidcCookiePath  = "/auth/oidc"
+)
+
+// BeginOIDCLogin handles GET /auth/oidc/login by redirecting to the identity provider.
+func (h *AuthHandler) BeginOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	login, err := h.service.BeginOIDCLogin(r.Context())
+	if err != nil {
+		if errors.Is(err, auth.ErrOIDCUnavailable) {
+			respondError(w, r, http.StatusNotImplemented, err.Error())
+			return
+		}
+		h.log.Error("oidc login failed", map[string]any{"error": err.Error()})
+		respondError(w, r, http.StatusBadGateway, "identity provider unavailable")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    login.State,
+		Path:     oidcCookiePath,
+		Expires:  login.ExpiresAt,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		// Lax so the cookie accompanies the top-level redirect back from the provider.
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, login.URL, http.StatusFound)
+}
+
+// CompleteOIDCLogin handles GET /auth/oidc/callback.
+func (h *AuthHandler) CompleteOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	// The state is single-use whatever the outcome.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     oidcCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	query := r.URL.Query()
+	if query.Get("error") != "" {
+		respondError(w, r, http.StatusUnauthorized, "sign-in refused by identity provider: "+query.Get("error"))
+		return
+	}
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, auth.ErrInvalidOIDCState.Error())
+		return
+	}
+
+	tokens, err := h.service.CompleteOIDCLogin(r.Context(), cookie.Value, query.Get("state"), query.Get("code"), clientInfo(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidOIDCState):
+			respondError(w, r, http.StatusBadRequest, err.Error())
+		case errors.Is(err, auth.ErrOIDCFailed):
+			h.log.Error("oidc callback rejected", map[string]any{"error": err.Error()})
+			respondError(w, r, http.StatusUnauthorized, auth.ErrOIDCFailed.Error())
+		case errors.Is(err, auth.ErrOIDCEmailNotVerified), errors.Is(err, auth.ErrAccountDisabled),
+			errors.Is(err, auth.ErrPasswordResetRequired):
+			respondError(w, r, http.StatusForbidden, err.Error())
+		case errors.Is(err, auth.ErrOIDCAccountUnverified):
+			respondError(w, r, http.StatusConflict, err.Error())
+		case errors.Is(err, domain.ErrConflict):
+			respondError(w, r, http.StatusConflict, "account already exists")
+		case errors.Is(err, auth.ErrOIDCUnavailable):
+			respondError(w, r, http.StatusNotImplemented, err.Error())
+		default:
+			h.log.Error("oidc callback failed", map[string]any{"error": err.Error()})
+			respondError(w, r, http.StatusInternalServerError, "could not login user")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, presentTokens(tokens))
+}
+
+// isHTTPS reports whether the client reached the service over TLS, directly
+// or through a proxy that says so.
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// optional records whether a JSON field was present in a payload and whether
+// it was explicitly null, so partial updates can tell "unset" from "clear".
+type optional[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (o *optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-todo-service/internal/domain"
+	projectsvc "go-todo-service/internal/service/project"
+	"go-todo-service/pkg/logger"
+)
+
+// ProjectHandler exposes project management endpoints.
+type ProjectHandler struct {
+	service *projectsvc.Service
+	log     *logger.Logger
+}
+
+// NewProjectHandler constructs the handler.
+func NewProjectHandler(service *projectsvc.Service, log *logger.Logger) *ProjectHandler {
+	return &ProjectHandler{service: service, log: log}
+}
+
+// List handles GET /projects.
+func (h *ProjectHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var includeArchived bool
+	if value := r.URL.Query().Get("archived"); value != "" {
+		var err error
+		if includeArchived, err = strconv.ParseBool(value); err != nil {
+			respondError(w, r, http.StatusBadRequest, "archived must be a boolean")
+			return
+		}
+	}
+
+	projects, err := h.service.List(r.Context(), userID, includeArchived)
+	if err != nil {
+		h.log.Error("list projects failed", map[string]any{"error": err.Error()})
+		respondError(w, r, http.StatusInternalServerError, "could not list projects")
+		return
+	}
+
+	items := make([]map[string]any, 0, len(projects))
+	for _, project := range projects {
+		items = append(items, presentProject(project))
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"projects": items})
+}
+
+// Create handles POST /projects.
+func (h *ProjectHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var payload struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Color       string `json:"color"`
+		SortOrder   *int   `json:"sort_order"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	project, err := h.service.Create(r.Context(), userID, projectsvc.CreateInput{
+		Name:        payload.Name,
+		Description: payload.Description,
+		Color:       payload.Color,
+		SortOrder:   payload.SortOrder,
+	})
+	if err != nil {
+		h.respondProjectError(w, r, err, "could not create project")
+		return
+	}
+	respondJSON(w, http.StatusCreated, presentProject(*project))
+}
+
+// Get handles GET /projects/{id}.
+func (h *ProjectHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	project, err := h.service.Get(r.Context(), userID, strings.TrimSpace(chi.URLParam(r, "id")))
+	if err != nil {
+		h.respondProjectError(w, r, err, "could not fetch project")
+		return
+	}
+	respondJSON(w, http.StatusOK, presentProject(*project))
+}
+
+// Update handles PATCH /projects/{id}.
+func (h *ProjectHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var payload struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		Color       *string `json:"color"`
+		Archived    *bool   `json:"archived"`
+		SortOrder   *int    `json:"sort_order"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	project, err := h.service.Update(r.Context(), userID, id, projectsvc.UpdateInput{
+		Name:        payload.Name,
+		Description: payload.Description,
+		Color:       payload.Color,
+		Archived:    payload.Archived,
+		SortOrder:   payload.SortOrder,
+	})
+	if err != nil {
+		h.respondProjectError(w, r, err, "could not update project")
+		return
+	}
+	respondJSON(w, http.StatusOK, presentProject(*project))
+}
+
+// Delete handles DELETE /projects/{id}?mode=move|cascade.
+func (h *ProjectHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	mode := projectsvc.DeleteMode(r.URL.Query().Get("mode"))
+	if err := h.service.Delete(r.Context(), userID, id, mode); err != nil {
+		h.respondProjectError(w, r, err, "could not delete project")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ProjectHandler) respondProjectError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		respondError(w, r, http.StatusNotFound, "project not found")
+	case errors.Is(err, projectsvc.ErrInboxProtected):
+		respondError(w, r, http.StatusConflict, err.Error())
+	case errors.Is(err, projectsvc.ErrNameRequired), errors.Is(err, projectsvc.ErrInvalidName),
+		errors.Is(err, projectsvc.ErrDescriptionTooLong), errors.Is(err, projectsvc.ErrInvalidColor),
+		errors.Is(err, projectsvc.ErrInvalidDeleteMode):
+		respondError(w, r, http.StatusBadRequest, err.Error())
+	default:
+		h.log.Error(msg, map[string]any{"error": err.Error()})
+		respondError(w, r, http.StatusInternalServerError, msg)
+	}
+}
+
+func presentProject(project domain.Project) map[string]any {
+	return map[string]any{
+		"id":          project.ID,
+		"name":        project.Name,
+		"description": project.Description,
+		"color":       project.Color,
+		"archived":    project.Archived,
+		"sort_order":  project.SortOrder,
+		"inbox":       project.Inbox,
+		"created_at":  project.CreatedAt,
+		"updated_at":  project.UpdatedAt,
+	}
+}
@@ -5,37 +5,131 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 
+	"go-todo-service/internal/domain"
 	"go-todo-service/pkg/logger"
 )
 
 // NewRouter wires HTTP routes to handlers with production-grade middleware.
-func NewRouter(authHandler *AuthHandler, taskHandler *TaskHandler, authMiddleware *AuthMiddleware, log *logger.Logger) http.Handler {
+func NewRouter(authHandler *AuthHandler, taskHandler *TaskHandler, tagHandler *TagHandler, projectHandler *ProjectHandler, tokenHandler *TokenHandler, exportHandler *ExportHandler, keysHandler *KeysHandler, authMiddleware *AuthMiddleware, realIP *RealIPMiddleware, log *logger.Logger) http.Handler {
 	r := chi.NewRouter()
 
-	r.Use(middleware.RealIP)
+	r.Use(realIP.Wrap)
 	r.Use(NewRecoveryMiddleware(log).Wrap)
 	r.Use(NewRequestIDMiddleware().Wrap)
 	r.Use(NewRequestLoggerMiddleware(log).Wrap)
-	r.Use(middleware.Timeout(60 * time.Second))
+	// Exports stream for longer than the server write timeout and set
+	// their own deadline.
+	r.Use(NewTimeoutMiddleware(60*time.Second, "/me/export", "/me/import").Wrap)
 
 	docsHandler := NewDocsHandler()
 	r.Get("/docs", docsHandler.UI)
 	r.Get("/docs/openapi.yaml", docsHandler.SpecYAML)
 	r.Get("/docs/openapi.json", docsHandler.SpecJSON)
 
+	r.Get("/.well-known/jwks.json", keysHandler.JWKS)
+
 	r.Post("/auth/signup", authHandler.Signup)
 	r.Post("/auth/login", authHandler.Login)
+	r.Post("/auth/refresh", authHandler.Refresh)
+	r.Post("/auth/magic-link", authHandler.RequestMagicLink)
+	r.Post("/auth/magic-link/consume", authHandler.ConsumeMagicLink)
+	r.Get("/auth/oidc/login", authHandler.BeginOIDCLogin)
+	r.Get("/auth/oidc/callback", authHandler.CompleteOIDCLogin)
+	r.Post("/auth/password/forgot", authHandler.ForgotPassword)
+	r.Post("/auth/password/reset", authHandler.ResetPassword)
+	r.Post("/auth/verify", authHandler.VerifyEmail)
+	r.Post("/auth/verify/resend", authHandler.ResendVerification)
+	r.Post("/auth/mfa/verify", authHandler.VerifyMFA)
+	r.With(authMiddleware.Wrap, RequireSession).Post("/auth/logout", authHandler.Logout)
+
+	r.Route("/auth/mfa", func(sub chi.Router) {
+		sub.Use(authMiddleware.Wrap)
+		sub.Use(RequireSession)
+
+		sub.Post("/enroll", authHandler.EnrollMFA)
+		sub.Post("/confirm", authHandler.ConfirmMFA)
+		sub.Post("/disable", authHandler.DisableMFA)
+	})
+
+	r.Route("/me", func(sub chi.Router) {
+		sub.Use(authMiddleware.Wrap)
+		sub.Use(RequireSession)
+
+		sub.Get("/", authHandler.Me)
+		sub.Patch("/", authHandler.UpdateMe)
+		sub.Delete("/", authHandler.DeleteMe)
+		sub.Post("/password", authHandler.ChangePassword)
+		sub.Post("/email", authHandler.ChangeEmail)
+		sub.Get("/sessions", authHandler.ListSessions)
+		sub.Delete("/sessions/{id}", authHandler.RevokeSession)
+		sub.Get("/export", exportHandler.Export)
+		sub.With(authHandler.RequireVerifiedEmail).Post("/import", exportHandler.Import)
+	})
+
+	r.Route("/admin", func(sub chi.Router) {
+		sub.Use(authMiddleware.Wrap)
+		sub.Use(RequireSession)
+		sub.Use(RequireRole(domain.RoleAdmin))
+
+		sub.Get("/users", authHandler.ListUsers)
+		sub.Get("/users/{id}", authHandler.GetUser)
+		sub.Put("/users/{id}/role", authHandler.SetUserRole)
+		sub.Post("/users/{id}/disable", authHandler.DisableUser)
+		sub.Post("/users/{id}/enable", authHandler.EnableUser)
+		sub.Post("/users/{id}/password-reset", authHandler.ForcePasswordReset)
+		sub.Get("/lockouts", authHandler.ListLockouts)
+		sub.Delete("/lockouts/{kind}/{subject}", authHandler.Unlock)
+	})
+
+	r.Route("/auth/tokens", func(sub chi.Router) {
+		sub.Use(authMiddleware.Wrap)
+		sub.Use(RequireSession)
+
+		sub.Get("/", tokenHandler.List)
+		sub.Post("/", tokenHandler.Create)
+		sub.Delete("/{id}", tokenHandler.Revoke)
+	})
 
 	r.Route("/tasks", func(sub chi.Router) {
 		sub.Use(authMiddleware.Wrap)
 
-		sub.Get("/", taskHandler.List)
-		sub.Post("/", taskHandler.Create)
-		sub.Get("/{id}", taskHandler.Get)
-		sub.Put("/{id}", taskHandler.Update)
-		sub.Delete("/{id}", taskHandler.Delete)
+		read := sub.With(RequireScope(domain.ScopeTasksRead))
+		write := sub.With(RequireScope(domain.ScopeTasksWrite), authHandler.RequireVerifiedEmail)
+
+		read.Get("/", taskHandler.List)
+		write.Post("/", taskHandler.Create)
+		read.Get("/{id}", taskHandler.Get)
+		write.Put("/{id}", taskHandler.Update)
+		write.Delete("/{id}", taskHandler.Delete)
+		read.Get("/{id}/subtasks", taskHandler.ListSubtasks)
+	})
+
+	r.Route("/tags", func(sub chi.Router) {
+		sub.Use(authMiddleware.Wrap)
+
+		read := sub.With(RequireScope(domain.ScopeTasksRead))
+		write := sub.With(RequireScope(domain.ScopeTasksWrite), authHandler.RequireVerifiedEmail)
+
+		read.Get("/", tagHandler.List)
+		write.Post("/", tagHandler.Create)
+		read.Get("/{id}", tagHandler.Get)
+		write.Put("/{id}", tagHandler.Update)
+		write.Delete("/{id}", tagHandler.Delete)
+	})
+
+	r.Route("/projects", func(sub chi.Router) {
+		sub.Use(authMiddleware.Wrap)
+
+		read := sub.With(RequireScope(domain.ScopeTasksRead))
+		write := sub.With(RequireScope(domain.ScopeTasksWrite), authHandler.RequireVerifiedEmail)
+
+		read.Get("/", projectHandler.List)
+		write.Post("/", projectHandler.Create)
+		read.Get("/{id}", projectHandler.Get)
+		write.Patch("/{id}", projectHandler.Update)
+		write.Delete("/{id}", projectHandler.Delete)
+		read.Get("/{id}/tasks", taskHandler.ListByProject)
 	})
 
 	return r
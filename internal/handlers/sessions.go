@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/service/auth"
+)
+
+// ListSessions handles GET /me/sessions.
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	sessions, err := h.service.ListSessions(r.Context(), userID)
+	if err != nil {
+		h.respondSessionError(w, r, err, "could not list sessions")
+		return
+	}
+
+	var currentID string
+	if claims, ok := ClaimsFromContext(r.Context()); ok {
+		currentID = claims.SessionID
+	}
+	items := make([]map[string]any, 0, len(sessions))
+	for _, session := range sessions {
+		items = append(items, presentSession(session, currentID))
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"sessions": items})
+}
+
+// RevokeSession handles DELETE /me/sessions/{id}.
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.service.RevokeSession(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+		h.respondSessionError(w, r, err, "could not revoke session")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) respondSessionError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		respondError(w, r, http.StatusNotFound, "session not found")
+	case errors.Is(err, auth.ErrSessionsUnavailable):
+		respondError(w, r, http.StatusNotImplemented, err.Error())
+	default:
+		h.log.Error(msg, map[string]any{"error": err.Error()})
+		respondError(w, r, http.StatusInternalServerError, msg)
+	}
+}
+
+func presentSession(session domain.Session, currentID string) map[string]any {
+	return map[string]any{
+		"id":           session.ID,
+		"user_agent":   session.UserAgent,
+		"ip":           session.IP,
+		"created_at":   session.CreatedAt,
+		"last_seen_at": session.LastSeenAt,
+		"expires_at":   session.ExpiresAt,
+		"current":      session.ID == currentID,
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-todo-service/internal/domain"
+	tagsvc "go-todo-service/internal/service/tag"
+	"go-todo-service/pkg/logger"
+)
+
+// TagHandler exposes tag management endpoints.
+type TagHandler struct {
+	service *tagsvc.Service
+	log     *logger.Logger
+}
+
+// NewTagHandler constructs the handler.
+func NewTagHandler(service *tagsvc.Service, log *logger.Logger) *TagHandler {
+	return &TagHandler{service: service, log: log}
+}
+
+type tagPayload struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// List handles GET /tags.
+func (h *TagHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	tags, err := h.service.List(r.Context(), userID)
+	if err != nil {
+		h.log.Error("list tags failed", map[string]any{"error": err.Error()})
+		respondError(w, r, http.StatusInternalServerError, "could not list tags")
+		return
+	}
+
+	items := make([]map[string]any, 0, len(tags))
+	for _, tag := range tags {
+		items = append(items, presentTag(tag))
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"tags": items})
+}
+
+// Create handles POST /tags.
+func (h *TagHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var payload tagPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	tag, err := h.service.Create(r.Context(), userID, tagsvc.Input{Name: payload.Name, Color: payload.Color})
+	if err != nil {
+		h.respondTagError(w, r, err, "could not create tag")
+		return
+	}
+	respondJSON(w, http.StatusCreated, presentTag(*tag))
+}
+
+// Get handles GET /tags/{id}.
+func (h *TagHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	tag, err := h.service.Get(r.Context(), userID, strings.TrimSpace(chi.URLParam(r, "id")))
+	if err != nil {
+		h.respondTagError(w, r, err, "could not fetch tag")
+		return
+	}
+	respondJSON(w, http.StatusOK, presentTag(*tag))
+}
+
+// Update handles PUT /tags/{id}.
+func (h *TagHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var payload tagPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	tag, err := h.service.Update(r.Context(), userID, id, tagsvc.Input{Name: payload.Name, Color: payload.Color})
+	if err != nil {
+		h.respondTagError(w, r, err, "could not update tag")
+		return
+	}
+	respondJSON(w, http.StatusOK, presentTag(*tag))
+}
+
+// Delete handles DELETE /tags/{id}.
+func (h *TagHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), userID, strings.TrimSpace(chi.URLParam(r, "id"))); err != nil {
+		h.respondTagError(w, r, err, "could not delete tag")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TagHandler) respondTagError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		respondError(w, r, http.StatusNotFound, "tag not found")
+	case errors.Is(err, domain.ErrConflict):
+		respondError(w, r, http.StatusConflict, "a tag with this name already exists")
+	case errors.Is(err, tagsvc.ErrNameRequired), errors.Is(err, tagsvc.ErrInvalidName),
+		errors.Is(err, tagsvc.ErrInvalidColor):
+		respondError(w, r, http.StatusBadRequest, err.Error())
+	default:
+		h.log.Error(msg, map[string]any{"error": err.Error()})
+		respondError(w, r, http.StatusInternalServerError, msg)
+	}
+}
+
+func presentTag(tag domain.Tag) map[string]any {
+	return map[string]any{
+		"id":         tag.ID,
+		"name":       tag.Name,
+		"color":      tag.Color,
+		"created_at": tag.CreatedAt,
+		"updated_at": tag.UpdatedAt,
+	}
+}
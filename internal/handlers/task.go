@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
@@ -32,18 +34,103 @@ func (h *TaskHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tasks, err := h.service.ListTasks(r.Context(), userID)
+	opts, err := parseListOptions(r)
 	if err != nil {
-		h.log.Error("list tasks failed", map[string]any{"error": err.Error()})
-		respondError(w, r, http.StatusInternalServerError, "could not list tasks")
+		respondError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	opts.ProjectID = r.URL.Query().Get("project_id")
+	h.list(w, r, userID, opts, false)
+}
+
+// ListByProject handles GET /projects/{id}/tasks, accepting the filters of GET /tasks.
+func (h *TaskHandler) ListByProject(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	opts, err := parseListOptions(r)
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	opts.ProjectID = strings.TrimSpace(chi.URLParam(r, "id"))
+	if opts.ProjectID == "" {
+		respondError(w, r, http.StatusNotFound, "project not found")
+		return
+	}
+	h.list(w, r, userID, opts, true)
+}
+
+// ListSubtasks handles GET /tasks/{id}/subtasks, accepting the filters of GET /tasks.
+func (h *TaskHandler) ListSubtasks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
-	response := make([]map[string]any, 0, len(tasks))
-	for _, task := range tasks {
-		response = append(response, presentTask(task))
+	opts, err := parseListOptions(r)
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	opts.ParentID = strings.TrimSpace(chi.URLParam(r, "id"))
+	if opts.ParentID == "" {
+		respondError(w, r, http.StatusNotFound, "task not found")
+		return
+	}
+	h.list(w, r, userID, opts, true)
+}
+
+// list responds with a page of tasks. An unknown project or parent task is
+// reported as 404 when it was named in the path and as 400 when it came from
+// a query parameter.
+func (h *TaskHandler) list(w http.ResponseWriter, r *http.Request, userID string, opts tasksvc.ListOptions, inPath bool) {
+	page, err := h.service.ListTasks(r.Context(), userID, opts)
+	if err != nil {
+		switch {
+		case errors.Is(err, tasksvc.ErrUnknownProject) && inPath:
+			respondError(w, r, http.StatusNotFound, "project not found")
+		case errors.Is(err, tasksvc.ErrUnknownParent) && inPath:
+			respondError(w, r, http.StatusNotFound, "task not found")
+		case errors.Is(err, tasksvc.ErrInvalidDueRange),
+			errors.Is(err, tasksvc.ErrInvalidStatus),
+			errors.Is(err, tasksvc.ErrInvalidPriority),
+			errors.Is(err, tasksvc.ErrInvalidSort),
+			errors.Is(err, tasksvc.ErrInvalidCursor),
+			errors.Is(err, tasksvc.ErrInvalidLimit),
+			errors.Is(err, tasksvc.ErrInvalidDueWindow),
+			errors.Is(err, tasksvc.ErrInvalidTagMatch),
+			errors.Is(err, tasksvc.ErrUnknownProject):
+			respondError(w, r, http.StatusBadRequest, err.Error())
+		default:
+			h.log.Error("list tasks failed", map[string]any{"error": err.Error()})
+			respondError(w, r, http.StatusInternalServerError, "could not list tasks")
+		}
+		return
+	}
+
+	items := make([]map[string]any, 0, len(page.Tasks))
+	if opts.Tree {
+		for _, node := range page.Tree {
+			items = append(items, presentTaskNode(node))
+		}
+	} else {
+		for _, task := range page.Tasks {
+			items = append(items, presentTask(task))
+		}
+	}
+	var nextCursor *string
+	if page.NextCursor != "" {
+		nextCursor = &page.NextCursor
 	}
-	respondJSON(w, http.StatusOK, response)
+	respondJSON(w, http.StatusOK, map[string]any{
+		"tasks":       items,
+		"next_cursor": nextCursor,
+	})
 }
 
 // Create handles POST /tasks.
@@ -55,19 +142,56 @@ func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var payload struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
+		Title       string   `json:"title"`
+		Description string   `json:"description"`
+		Priority    string   `json:"priority"`
+		DueAt       *string  `json:"due_at"`
+		DueAllDay   bool     `json:"due_all_day"`
+		Tags        []string `json:"tags"`
+		ProjectID   string   `json:"project_id"`
+		ParentID    string   `json:"parent_id"`
+		// Recurrence is an RRULE such as "FREQ=WEEKLY;BYDAY=MO".
+		Recurrence      string `json:"recurrence"`
+		RecurrenceBasis string `json:"recurrence_basis"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		respondError(w, r, http.StatusBadRequest, "invalid json payload")
 		return
 	}
 
-	task, err := h.service.CreateTask(r.Context(), userID, payload.Title, payload.Description)
+	input := tasksvc.CreateTaskInput{
+		Title:           payload.Title,
+		Description:     payload.Description,
+		Priority:        payload.Priority,
+		DueAllDay:       payload.DueAllDay,
+		Tags:            payload.Tags,
+		ProjectID:       payload.ProjectID,
+		ParentID:        payload.ParentID,
+		Recurrence:      payload.Recurrence,
+		RecurrenceBasis: payload.RecurrenceBasis,
+	}
+	if payload.DueAt != nil {
+		dueAt, dateOnly, err := parseDue(*payload.DueAt)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		input.DueAt = &dueAt
+		input.DueAllDay = input.DueAllDay || dateOnly
+	}
+
+	task, err := h.service.CreateTask(r.Context(), userID, input)
 	if err != nil {
 		switch {
-		case errors.Is(err, tasksvc.ErrTitleRequired), errors.Is(err, tasksvc.ErrUserRequired):
+		case errors.Is(err, tasksvc.ErrTitleRequired), errors.Is(err, tasksvc.ErrUserRequired),
+			errors.Is(err, tasksvc.ErrInvalidPriority), errors.Is(err, tasksvc.ErrUnknownTag),
+			errors.Is(err, tasksvc.ErrUnknownProject), errors.Is(err, tasksvc.ErrProjectArchived),
+			errors.Is(err, tasksvc.ErrUnknownParent), errors.Is(err, tasksvc.ErrTooDeep),
+			errors.Is(err, tasksvc.ErrInvalidRecurrence), errors.Is(err, tasksvc.ErrInvalidRecurrenceBasis),
+			errors.Is(err, tasksvc.ErrRecurrenceNeedsDue):
 			respondError(w, r, http.StatusBadRequest, err.Error())
+		case errors.Is(err, domain.ErrConcurrentUpdate):
+			respondError(w, r, http.StatusConflict, "subtasks were moved by another request, try again")
 		default:
 			h.log.Error("create task failed", map[string]any{"error": err.Error()})
 			respondError(w, r, http.StatusInternalServerError, "could not create task")
@@ -119,22 +243,66 @@ func (h *TaskHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var payload struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		Status      string `json:"status"`
+		Title       string           `json:"title"`
+		Description string           `json:"description"`
+		Status      string           `json:"status"`
+		Priority    string           `json:"priority"`
+		DueAt       optional[string] `json:"due_at"`
+		DueAllDay   bool             `json:"due_all_day"`
+		// Tags replaces the task's tags when present; null or absent keeps them.
+		Tags []string `json:"tags"`
+		// ProjectID moves the task when set.
+		ProjectID string `json:"project_id"`
+		// ParentID moves the task under another task; null makes it top-level.
+		ParentID optional[string] `json:"parent_id"`
+		// Recurrence replaces the RRULE; null makes the task a one-off.
+		Recurrence      optional[string] `json:"recurrence"`
+		RecurrenceBasis string           `json:"recurrence_basis"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		respondError(w, r, http.StatusBadRequest, "invalid json payload")
 		return
 	}
 
-	task, err := h.service.UpdateTask(r.Context(), userID, id, payload.Title, payload.Description, payload.Status)
+	input := tasksvc.UpdateTaskInput{
+		Title:           payload.Title,
+		Description:     payload.Description,
+		Status:          payload.Status,
+		Priority:        payload.Priority,
+		DueAllDay:       payload.DueAllDay,
+		ClearDue:        payload.DueAt.Null,
+		Tags:            payload.Tags,
+		ProjectID:       payload.ProjectID,
+		ParentID:        payload.ParentID.Value,
+		ClearParent:     payload.ParentID.Null,
+		Recurrence:      payload.Recurrence.Value,
+		ClearRecurrence: payload.Recurrence.Null,
+		RecurrenceBasis: payload.RecurrenceBasis,
+	}
+	if payload.DueAt.Set && !payload.DueAt.Null {
+		dueAt, dateOnly, err := parseDue(payload.DueAt.Value)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		input.DueAt = &dueAt
+		input.DueAllDay = input.DueAllDay || dateOnly
+	}
+
+	task, err := h.service.UpdateTask(r.Context(), userID, id, input)
 	if err != nil {
 		switch {
 		case errors.Is(err, domain.ErrNotFound):
 			respondError(w, r, http.StatusNotFound, "task not found")
-		case errors.Is(err, tasksvc.ErrInvalidStatus):
+		case errors.Is(err, tasksvc.ErrInvalidStatus), errors.Is(err, tasksvc.ErrInvalidPriority),
+			errors.Is(err, tasksvc.ErrUnknownTag), errors.Is(err, tasksvc.ErrUnknownProject),
+			errors.Is(err, tasksvc.ErrProjectArchived), errors.Is(err, tasksvc.ErrUnknownParent),
+			errors.Is(err, tasksvc.ErrParentCycle), errors.Is(err, tasksvc.ErrTooDeep),
+			errors.Is(err, tasksvc.ErrInvalidRecurrence), errors.Is(err, tasksvc.ErrInvalidRecurrenceBasis),
+			errors.Is(err, tasksvc.ErrRecurrenceNeedsDue):
 			respondError(w, r, http.StatusBadRequest, err.Error())
+		case errors.Is(err, domain.ErrConcurrentUpdate):
+			respondError(w, r, http.StatusConflict, "subtasks were moved by another request, try again")
 		default:
 			h.log.Error("update task failed", map[string]any{"error": err.Error(), "task_id": id})
 			respondError(w, r, http.StatusInternalServerError, "could not update task")
@@ -172,13 +340,134 @@ func (h *TaskHandler) Delete(w http.ResponseWriter, r *http.Request) {
 }
 
 func presentTask(task domain.Task) map[string]any {
-	return map[string]any{
-		"id":          task.ID,
-		"title":       task.Title,
-		"description": task.Description,
-		"status":      task.Status,
-		"user_id":     task.UserID,
-		"created_at":  task.CreatedAt,
-		"updated_at":  task.UpdatedAt,
+	tags := make([]map[string]any, 0, len(task.Tags))
+	for _, tag := range task.Tags {
+		tags = append(tags, map[string]any{
+			"id":    tag.ID,
+			"name":  tag.Name,
+			"color": tag.Color,
+		})
+	}
+	var projectID, parentID, recurrence *string
+	if task.ProjectID != "" {
+		projectID = &task.ProjectID
+	}
+	if task.ParentID != "" {
+		parentID = &task.ParentID
+	}
+	if task.Recurrence != "" {
+		recurrence = &task.Recurrence
+	}
+	// Roll-up of the direct subtasks.
+	subtasks := map[string]any{
+		"total": task.Subtasks.Total,
+		"done":  task.Subtasks.Done,
+	}
+	item := map[string]any{
+		"id":               task.ID,
+		"project_id":       projectID,
+		"parent_id":        parentID,
+		"title":            task.Title,
+		"description":      task.Description,
+		"status":           task.Status,
+		"priority":         task.Priority,
+		"user_id":          task.UserID,
+		"due_at":           task.DueAt,
+		"due_all_day":      task.DueAllDay,
+		"recurrence":       recurrence,
+		"recurrence_basis": task.RecurrenceBasis,
+		"tags":             tags,
+		"subtasks":         subtasks,
+		"created_at":       task.CreatedAt,
+		"updated_at":       task.UpdatedAt,
+	}
+	// Set when completing a recurring task created its next occurrence.
+	if task.NextOccurrence != nil {
+		item["next_occurrence"] = presentTask(*task.NextOccurrence)
+	}
+	return item
+}
+
+// presentTaskNode presents a task with its subtree under "children".
+func presentTaskNode(node tasksvc.TaskNode) map[string]any {
+	children := make([]map[string]any, 0, len(node.Children))
+	for _, child := range node.Children {
+		children = append(children, presentTaskNode(child))
+	}
+	item := presentTask(node.Task)
+	item["children"] = children
+	return item
+}
+
+func parseListOptions(r *http.Request) (tasksvc.ListOptions, error) {
+	query := r.URL.Query()
+	opts := tasksvc.ListOptions{
+		Status: query.Get("status"),
+		Query:  query.Get("q"),
+		Sort:   query.Get("sort"),
+		Cursor: query.Get("cursor"),
+		// Validated by the service, which knows the user's time zone.
+		DueWithin: tasksvc.DueWindow(query.Get("due")),
+		Tags:      query["tag"],
+		TagMatch:  tasksvc.TagMatch(query.Get("tag_match")),
+	}
+	// Accepts repeated parameters and comma-separated lists.
+	for _, value := range query["priority"] {
+		for _, priority := range strings.Split(value, ",") {
+			if priority = strings.TrimSpace(priority); priority != "" {
+				opts.Priorities = append(opts.Priorities, priority)
+			}
+		}
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return opts, tasksvc.ErrInvalidLimit
+		}
+		opts.Limit = limit
+	}
+
+	if value := query.Get("due_before"); value != "" {
+		dueBefore, _, err := parseDue(value)
+		if err != nil {
+			return opts, errors.New("due_before must be an RFC 3339 timestamp or YYYY-MM-DD date")
+		}
+		opts.DueBefore = &dueBefore
+	}
+	if value := query.Get("due_after"); value != "" {
+		dueAfter, _, err := parseDue(value)
+		if err != nil {
+			return opts, errors.New("due_after must be an RFC 3339 timestamp or YYYY-MM-DD date")
+		}
+		opts.DueAfter = &dueAfter
+	}
+	switch query.Get("view") {
+	case "", "flat":
+	case "tree":
+		opts.Tree = true
+	default:
+		return opts, errors.New("view must be one of flat, tree")
+	}
+	if value := query.Get("overdue"); value != "" {
+		overdue, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, errors.New("overdue must be a boolean")
+		}
+		opts.Overdue = overdue
+	}
+	return opts, nil
+}
+
+// parseDue accepts an RFC 3339 timestamp or a YYYY-MM-DD date. The boolean
+// result reports whether a bare date was supplied.
+func parseDue(value string) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, true, nil
 	}
+	return time.Time{}, false, errors.New("due_at must be an RFC 3339 timestamp or YYYY-MM-DD date")
 }
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/service/pat"
+	"go-todo-service/pkg/logger"
+)
+
+// TokenHandler exposes personal access token management endpoints.
+type TokenHandler struct {
+	service *pat.Service
+	log     *logger.Logger
+}
+
+// NewTokenHandler constructs the handler.
+func NewTokenHandler(service *pat.Service, log *logger.Logger) *TokenHandler {
+	return &TokenHandler{service: service, log: log}
+}
+
+// Create handles POST /auth/tokens.
+func (h *TokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var payload struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		respondError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	token, plain, err := h.service.Create(r.Context(), userID, pat.CreateInput{
+		Name:      payload.Name,
+		Scopes:    payload.Scopes,
+		ExpiresAt: payload.ExpiresAt,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, pat.ErrNameRequired),
+			errors.Is(err, pat.ErrScopesRequired),
+			errors.Is(err, pat.ErrInvalidScope),
+			errors.Is(err, pat.ErrInvalidExpiry):
+			respondError(w, r, http.StatusBadRequest, err.Error())
+		default:
+			h.log.Error("create access token failed", map[string]any{"error": err.Error()})
+			respondError(w, r, http.StatusInternalServerError, "could not create token")
+		}
+		return
+	}
+
+	response := presentAccessToken(*token)
+	response["token"] = plain
+	respondJSON(w, http.StatusCreated, response)
+}
+
+// List handles GET /auth/tokens.
+func (h *TokenHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	tokens, err := h.service.List(r.Context(), userID)
+	if err != nil {
+		h.log.Error("list access tokens failed", map[string]any{"error": err.Error()})
+		respondError(w, r, http.StatusInternalServerError, "could not list tokens")
+		return
+	}
+
+	response := make([]map[string]any, 0, len(tokens))
+	for _, token := range tokens {
+		response = append(response, presentAccessToken(token))
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+// Revoke handles DELETE /auth/tokens/{id}.
+func (h *TokenHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	if id == "" {
+		respondError(w, r, http.StatusNotFound, "token not found")
+		return
+	}
+
+	if err := h.service.Revoke(r.Context(), userID, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			respondError(w, r, http.StatusNotFound, "token not found")
+		} else {
+			h.log.Error("revoke access token failed", map[string]any{"error": err.Error(), "token_id": id})
+			respondError(w, r, http.StatusInternalServerError, "could not revoke token")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func presentAccessToken(token domain.PersonalAccessToken) map[string]any {
+	scopes := token.Scopes
+	if scopes == nil {
+		scopes = []domain.Scope{}
+	}
+	return map[string]any{
+		"id":           token.ID,
+		"name":         token.Name,
+		"scopes":       scopes,
+		"expires_at":   token.ExpiresAt,
+		"last_used_at": token.LastUsedAt,
+		"created_at":   token.CreatedAt,
+		"revoked_at":   token.RevokedAt,
+	}
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-todo-service/internal/domain"
+)
+
+// PersonalAccessTokenRepository defines persistence operations for personal access tokens.
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *domain.PersonalAccessToken) error
+	ListByUser(ctx context.Context, userID string) ([]domain.PersonalAccessToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*domain.PersonalAccessToken, error)
+	// Revoke marks the user's token as revoked, returning domain.ErrNotFound when
+	// no active token with that id belongs to the user.
+	Revoke(ctx context.Context, userID, id string, at time.Time) error
+	// RevokeByUser marks every active token of the user as revoked.
+	RevokeByUser(ctx context.Context, userID string, at time.Time) error
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"go-todo-service/internal/domain"
+)
+
+// ArchiveRepository stores the contents of an imported export archive.
+type ArchiveRepository interface {
+	// Restore writes the batch in one transaction, so either all of it is
+	// stored or none of it.
+	Restore(ctx context.Context, batch ArchiveBatch) error
+}
+
+// ArchiveBatch is the set of changes made by one import.
+type ArchiveBatch struct {
+	// Projects holds new projects, stored before any task.
+	Projects []domain.Project
+	// Tags holds new tags, stored before any task.
+	Tags []domain.Tag
+	// Create holds new tasks.
+	Create []domain.Task
+	// Replace holds existing tasks to overwrite.
+	Replace []domain.Task
+	// TaskTags replaces the tags of the listed tasks, keyed by task id.
+	TaskTags map[string][]string
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"go-todo-service/internal/domain"
+)
+
+// IdentityRepository defines persistence operations for linked external identities.
+type IdentityRepository interface {
+	// Create links an identity. It returns domain.ErrConflict when the
+	// identity is already linked.
+	Create(ctx context.Context, identity *domain.Identity) error
+	GetBySubject(ctx context.Context, issuer, subject string) (*domain.Identity, error)
+}
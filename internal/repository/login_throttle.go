@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-todo-service/internal/domain"
+)
+
+// LoginThrottleRepository stores failed sign-in counters shared by all API instances.
+type LoginThrottleRepository interface {
+	// Get returns the counter for key or domain.ErrNotFound.
+	Get(ctx context.Context, key string) (*domain.LoginThrottle, error)
+	// RecordFailure increments the counter for key and returns it. Counters
+	// whose last failure is older than window restart at one.
+	RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (*domain.LoginThrottle, error)
+	// Block refuses attempts for key until the given time.
+	Block(ctx context.Context, key string, until time.Time) error
+	// Reset forgets the counter for key.
+	Reset(ctx context.Context, key string) error
+	// ListBlocked returns counters that are blocked at the given time.
+	ListBlocked(ctx context.Context, at time.Time) ([]domain.LoginThrottle, error)
+}
@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go-todo-service/internal/domain"
+)
+
+// LoginThrottleRepository keeps failed sign-in counters in process memory.
+// Counters are only shared within one instance, so replicated deployments
+// should use the PostgreSQL store instead.
+type LoginThrottleRepository struct {
+	mu      sync.Mutex
+	entries map[string]*domain.LoginThrottle
+}
+
+// NewLoginThrottleRepository constructs the repository.
+func NewLoginThrottleRepository() *LoginThrottleRepository {
+	return &LoginThrottleRepository{
+		entries: make(map[string]*domain.LoginThrottle),
+	}
+}
+
+// Get returns a copy of the counter for key.
+func (r *LoginThrottleRepository) Get(ctx context.Context, key string) (*domain.LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[key]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return copyThrottle(entry), nil
+}
+
+// RecordFailure increments the counter for key and prunes stale entries.
+func (r *LoginThrottleRepository) RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (*domain.LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cutoff := at.Add(-window)
+	for k, entry := range r.entries {
+		if k != key && entry.LastFailureAt.Before(cutoff) && !entry.BlockedAt(at) {
+			delete(r.entries, k)
+		}
+	}
+
+	entry, ok := r.entries[key]
+	if !ok || entry.LastFailureAt.Before(cutoff) {
+		entry = &domain.LoginThrottle{Key: key}
+		r.entries[key] = entry
+	}
+	entry.Failures++
+	entry.LastFailureAt = at
+	return copyThrottle(entry), nil
+}
+
+// Block refuses attempts for key until the given time.
+func (r *LoginThrottleRepository) Block(ctx context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[key]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	entry.BlockedUntil = &until
+	return nil
+}
+
+// Reset forgets the counter for key.
+func (r *LoginThrottleRepository) Reset(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.entries, key)
+	return nil
+}
+
+// ListBlocked returns counters blocked at the given time, longest block first.
+func (r *LoginThrottleRepository) ListBlocked(ctx context.Context, at time.Time) ([]domain.LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var throttles []domain.LoginThrottle
+	for _, entry := range r.entries {
+		if entry.BlockedAt(at) {
+			throttles = append(throttles, *copyThrottle(entry))
+		}
+	}
+	sort.Slice(throttles, func(i, j int) bool {
+		if !throttles[i].BlockedUntil.Equal(*throttles[j].BlockedUntil) {
+			return throttles[i].BlockedUntil.After(*throttles[j].BlockedUntil)
+		}
+		return throttles[i].Key < throttles[j].Key
+	})
+	return throttles, nil
+}
+
+func copyThrottle(entry *domain.LoginThrottle) *domain.LoginThrottle {
+	c := *entry
+	if entry.BlockedUntil != nil {
+		until := *entry.BlockedUntil
+		c.BlockedUntil = &until
+	}
+	return &c
+}
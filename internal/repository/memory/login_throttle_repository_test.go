@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-todo-service/internal/domain"
+)
+
+func TestLoginThrottleRepositoryCountsWithinWindow(t *testing.T) {
+	repo := NewLoginThrottleRepository()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		entry, err := repo.RecordFailure(ctx, "account:a", now, time.Minute)
+		if err != nil {
+			t.Fatalf("record failure: %v", err)
+		}
+		if entry.Failures != i {
+			t.Fatalf("expected %d failures, got %d", i, entry.Failures)
+		}
+	}
+
+	if err := repo.Block(ctx, "account:a", now.Add(time.Hour)); err != nil {
+		t.Fatalf("block: %v", err)
+	}
+	blocked, err := repo.ListBlocked(ctx, now)
+	if err != nil || len(blocked) != 1 || blocked[0].Key != "account:a" {
+		t.Fatalf("expected one blocked entry, got %+v (err %v)", blocked, err)
+	}
+
+	entry, err := repo.RecordFailure(ctx, "account:a", now.Add(2*time.Minute), time.Minute)
+	if err != nil {
+		t.Fatalf("record failure: %v", err)
+	}
+	if entry.Failures != 1 || entry.BlockedUntil != nil {
+		t.Fatalf("expected counter to restart after the window, got %+v", entry)
+	}
+
+	if err := repo.Reset(ctx, "account:a"); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if _, err := repo.Get(ctx, "account:a"); err != domain.ErrNotFound {
+		t.Fatalf("expected ErrNotFound after reset, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RevokedTokenRepository is an in-process access-token denylist. Entries are
+// evicted once the token they refer to has expired, so memory stays bounded by
+// the number of live revoked tokens. Intended for tests and single-instance
+// deployments.
+type RevokedTokenRepository struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	now     func() time.Time
+}
+
+// NewRevokedTokenRepository constructs the repository.
+func NewRevokedTokenRepository() *RevokedTokenRepository {
+	return &RevokedTokenRepository{
+		entries: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// WithNow overrides the time source (testing).
+func (r *RevokedTokenRepository) WithNow(fn func() time.Time) {
+	if fn != nil {
+		r.now = fn
+	}
+}
+
+// Revoke denylists the token id until expiresAt.
+func (r *RevokedTokenRepository) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.evictExpired()
+	if current, ok := r.entries[tokenID]; !ok || expiresAt.After(current) {
+		r.entries[tokenID] = expiresAt
+	}
+	return nil
+}
+
+// Claim denylists the token id until expiresAt and reports whether it was
+// not denylisted before.
+func (r *RevokedTokenRepository) Claim(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.evictExpired()
+	if _, ok := r.entries[tokenID]; ok {
+		return false, nil
+	}
+	r.entries[tokenID] = expiresAt
+	return true, nil
+}
+
+// IsRevoked reports whether the token id is denylisted and not yet expired.
+func (r *RevokedTokenRepository) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expiresAt, ok := r.entries[tokenID]
+	if !ok {
+		return false, nil
+	}
+	if !r.now().Before(expiresAt) {
+		delete(r.entries, tokenID)
+		return false, nil
+	}
+	return true, nil
+}
+
+// Len returns the number of entries currently held.
+func (r *RevokedTokenRepository) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
+
+func (r *RevokedTokenRepository) evictExpired() {
+	now := r.now()
+	for id, expiresAt := range r.entries {
+		if !now.Before(expiresAt) {
+			delete(r.entries, id)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRevokedTokenRepositoryEvictsAfterExpiry(t *testing.T) {
+	repo := NewRevokedTokenRepository()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo.WithNow(func() time.Time { return now })
+	ctx := context.Background()
+
+	if err := repo.Revoke(ctx, "jti-1", now.Add(time.Minute)); err != nil {
+		t.Fatalf("revoke failed: %v", err)
+	}
+	revoked, err := repo.IsRevoked(ctx, "jti-1")
+	if err != nil || !revoked {
+		t.Fatalf("expected token to be revoked, got %v (err %v)", revoked, err)
+	}
+	if revoked, _ := repo.IsRevoked(ctx, "jti-2"); revoked {
+		t.Fatal("expected unknown token not to be revoked")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if err := repo.Revoke(ctx, "jti-3", now.Add(time.Minute)); err != nil {
+		t.Fatalf("revoke failed: %v", err)
+	}
+	if repo.Len() != 1 {
+		t.Fatalf("expected expired entry to be evicted, have %d entries", repo.Len())
+	}
+	if revoked, _ := repo.IsRevoked(ctx, "jti-1"); revoked {
+		t.Fatal("expected expired entry to be forgotten")
+	}
+}
+
+func TestRevokedTokenRepositoryClaimsOnce(t *testing.T) {
+	repo := NewRevokedTokenRepository()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo.WithNow(func() time.Time { return now })
+	ctx := context.Background()
+
+	if claimed, err := repo.Claim(ctx, "jti-1", now.Add(time.Minute)); err != nil || !claimed {
+		t.Fatalf("expected first claim to succeed, got %v (err %v)", claimed, err)
+	}
+	if claimed, _ := repo.Claim(ctx, "jti-1", now.Add(time.Minute)); claimed {
+		t.Fatal("expected second claim to fail")
+	}
+	if revoked, _ := repo.IsRevoked(ctx, "jti-1"); !revoked {
+		t.Fatal("expected claimed token to be revoked")
+	}
+}
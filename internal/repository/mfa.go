@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-todo-service/internal/domain"
+)
+
+// MFARepository defines persistence operations for TOTP enrollments and recovery codes.
+type MFARepository interface {
+	GetByUser(ctx context.Context, userID string) (*domain.MFAEnrollment, error)
+	// SavePending stores a new unconfirmed enrollment, replacing any earlier
+	// unconfirmed one. It returns domain.ErrConflict if MFA is already enabled.
+	SavePending(ctx context.Context, enrollment *domain.MFAEnrollment) error
+	// Confirm enables MFA, records the step of the confirming code and
+	// replaces the user's recovery codes.
+	Confirm(ctx context.Context, userID string, step int64, at time.Time, codes []domain.RecoveryCode) error
+	// UseStep advances the last used step, returning domain.ErrConflict when
+	// step is not newer than the stored one.
+	UseStep(ctx context.Context, userID string, step int64) error
+	// UseRecoveryCode marks an unused code as used or returns domain.ErrNotFound.
+	UseRecoveryCode(ctx context.Context, userID, codeHash string, at time.Time) error
+	// Delete removes the enrollment and its recovery codes.
+	Delete(ctx context.Context, userID string) error
+}
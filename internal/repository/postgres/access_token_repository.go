@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"go-todo-service/internal/domain"
+)
+
+const accessTokenColumns = `id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at, revoked_at`
+
+// PersonalAccessTokenRepository stores hashed personal access tokens in PostgreSQL.
+type PersonalAccessTokenRepository struct {
+	db *sql.DB
+}
+
+// NewPersonalAccessTokenRepository constructs the repository.
+func NewPersonalAccessTokenRepository(db *sql.DB) *PersonalAccessTokenRepository {
+	return &PersonalAccessTokenRepository{db: db}
+}
+
+// Create inserts a token row. Scopes are stored space-delimited.
+func (r *PersonalAccessTokenRepository) Create(ctx context.Context, token *domain.PersonalAccessToken) error {
+	const query = `
+		INSERT INTO personal_access_tokens (id, user_id, name, token_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.db.ExecContext(ctx, query,
+		token.ID,
+		token.UserID,
+		token.Name,
+		token.TokenHash,
+		joinScopes(token.Scopes),
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// ListByUser returns a user's tokens, including revoked ones, newest first.
+func (r *PersonalAccessTokenRepository) ListByUser(ctx context.Context, userID string) ([]domain.PersonalAccessToken, error) {
+	const query = `
+		SELECT ` + accessTokenColumns + `
+		FROM personal_access_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []domain.PersonalAccessToken
+	for rows.Next() {
+		token, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// GetByHash fetches a token by the digest of its plaintext value.
+func (r *PersonalAccessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.PersonalAccessToken, error) {
+	const query = `
+		SELECT ` + accessTokenColumns + `
+		FROM personal_access_tokens
+		WHERE token_hash = $1`
+	token, err := scanAccessToken(r.db.QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return token, nil
+}
+
+// Revoke marks a user's active token as revoked.
+func (r *PersonalAccessTokenRepository) Revoke(ctx context.Context, userID, id string, at time.Time) error {
+	const query = `
+		UPDATE personal_access_tokens
+		SET revoked_at = $1
+		WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, at, id, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// RevokeByUser marks every active token of the user as revoked.
+func (r *PersonalAccessTokenRepository) RevokeByUser(ctx context.Context, userID string, at time.Time) error {
+	const query = `
+		UPDATE personal_access_tokens
+		SET revoked_at = $1
+		WHERE user_id = $2 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, at, userID)
+	return err
+}
+
+// TouchLastUsed records when the token was last presented.
+func (r *PersonalAccessTokenRepository) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	const query = `
+		UPDATE personal_access_tokens
+		SET last_used_at = $1
+		WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, at, id)
+	return err
+}
+
+func scanAccessToken(row rowScanner) (*domain.PersonalAccessToken, error) {
+	token := &domain.PersonalAccessToken{}
+	var scopes string
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	if err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.TokenHash, &scopes, &expiresAt, &lastUsedAt, &token.CreatedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	for _, scope := range strings.Fields(scopes) {
+		token.Scopes = append(token.Scopes, domain.Scope(scope))
+	}
+	token.ExpiresAt = nullTimePtr(expiresAt)
+	token.LastUsedAt = nullTimePtr(lastUsedAt)
+	token.RevokedAt = nullTimePtr(revokedAt)
+	return token, nil
+}
+
+func joinScopes(scopes []domain.Scope) string {
+	parts := make([]string, len(scopes))
+	for i, scope := range scopes {
+		parts[i] = string(scope)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"go-todo-service/internal/repository"
+)
+
+// ArchiveRepository restores export archives in PostgreSQL.
+type ArchiveRepository struct {
+	db *sql.DB
+}
+
+// NewArchiveRepository constructs the repository.
+func NewArchiveRepository(db *sql.DB) *ArchiveRepository {
+	return &ArchiveRepository{db: db}
+}
+
+// Restore writes the batch in one transaction.
+func (r *ArchiveRepository) Restore(ctx context.Context, batch repository.ArchiveBatch) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range batch.Projects {
+		if err := insertProject(ctx, tx, &batch.Projects[i]); err != nil {
+			return err
+		}
+	}
+	for i := range batch.Tags {
+		if err := insertTag(ctx, tx, &batch.Tags[i]); err != nil {
+			return err
+		}
+	}
+	for i := range batch.Create {
+		if err := insertTask(ctx, tx, &batch.Create[i]); err != nil {
+			return err
+		}
+	}
+	for i := range batch.Replace {
+		if err := updateTask(ctx, tx, &batch.Replace[i]); err != nil {
+			return err
+		}
+	}
+	const insertTaskTag = `
+		INSERT INTO task_tags (task_id, tag_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`
+	for taskID, tagIDs := range batch.TaskTags {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM task_tags WHERE task_id = $1`, taskID); err != nil {
+			return err
+		}
+		for _, tagID := range tagIDs {
+			if _, err := tx.ExecContext(ctx, insertTaskTag, taskID, tagID); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
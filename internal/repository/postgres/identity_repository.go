@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go-todo-service/internal/domain"
+)
+
+// IdentityRepository stores linked OpenID identities in PostgreSQL.
+type IdentityRepository struct {
+	db *sql.DB
+}
+
+// NewIdentityRepository constructs the repository.
+func NewIdentityRepository(db *sql.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+// Create inserts an identity row.
+func (r *IdentityRepository) Create(ctx context.Context, identity *domain.Identity) error {
+	const query = `
+		INSERT INTO user_identities (issuer, subject, user_id, email, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.db.ExecContext(ctx, query,
+		identity.Issuer,
+		identity.Subject,
+		identity.UserID,
+		identity.Email,
+		identity.CreatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// GetBySubject fetches the identity a provider knows as subject.
+func (r *IdentityRepository) GetBySubject(ctx context.Context, issuer, subject string) (*domain.Identity, error) {
+	const query = `
+		SELECT issuer, subject, user_id, email, created_at
+		FROM user_identities
+		WHERE issuer = $1 AND subject = $2`
+	var identity domain.Identity
+	err := r.db.QueryRowContext(ctx, query, issuer, subject).Scan(
+		&identity.Issuer,
+		&identity.Subject,
+		&identity.UserID,
+		&identity.Email,
+		&identity.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
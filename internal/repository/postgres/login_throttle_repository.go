@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go-todo-service/internal/domain"
+)
+
+// LoginThrottleRepository stores failed sign-in counters in PostgreSQL so
+// every API replica sees the same state.
+type LoginThrottleRepository struct {
+	db *sql.DB
+}
+
+// NewLoginThrottleRepository constructs the repository.
+func NewLoginThrottleRepository(db *sql.DB) *LoginThrottleRepository {
+	return &LoginThrottleRepository{db: db}
+}
+
+// Get fetches the counter for key.
+func (r *LoginThrottleRepository) Get(ctx context.Context, key string) (*domain.LoginThrottle, error) {
+	const query = `
+		SELECT key, failures, last_failure_at, blocked_until
+		FROM login_throttles
+		WHERE key = $1`
+	throttle, err := scanLoginThrottle(r.db.QueryRowContext(ctx, query, key))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return throttle, nil
+}
+
+// RecordFailure upserts and increments the counter in a single statement so
+// concurrent failures are all counted. Stale rows of other keys are pruned on
+// the way.
+func (r *LoginThrottleRepository) RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (*domain.LoginThrottle, error) {
+	const query = `
+		WITH pruned AS (
+			DELETE FROM login_throttles
+			WHERE key <> $1 AND last_failure_at < $3 AND (blocked_until IS NULL OR blocked_until < $2)
+		)
+		INSERT INTO login_throttles (key, failures, last_failure_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE
+		SET failures = CASE WHEN login_throttles.last_failure_at < $3 THEN 1 ELSE login_throttles.failures + 1 END,
+			blocked_until = CASE WHEN login_throttles.last_failure_at < $3 THEN NULL ELSE login_throttles.blocked_until END,
+			last_failure_at = $2
+		RETURNING key, failures, last_failure_at, blocked_until`
+	return scanLoginThrottle(r.db.QueryRowContext(ctx, query, key, at, at.Add(-window)))
+}
+
+// Block sets the time until which attempts for key are refused.
+func (r *LoginThrottleRepository) Block(ctx context.Context, key string, until time.Time) error {
+	const query = `
+		UPDATE login_throttles
+		SET blocked_until = $1
+		WHERE key = $2`
+	result, err := r.db.ExecContext(ctx, query, until, key)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// Reset deletes the counter for key.
+func (r *LoginThrottleRepository) Reset(ctx context.Context, key string) error {
+	const query = `
+		DELETE FROM login_throttles
+		WHERE key = $1`
+	_, err := r.db.ExecContext(ctx, query, key)
+	return err
+}
+
+// ListBlocked returns currently blocked counters, longest block first.
+func (r *LoginThrottleRepository) ListBlocked(ctx context.Context, at time.Time) ([]domain.LoginThrottle, error) {
+	const query = `
+		SELECT key, failures, last_failure_at, blocked_until
+		FROM login_throttles
+		WHERE blocked_until > $1
+		ORDER BY blocked_until DESC, key`
+	rows, err := r.db.QueryContext(ctx, query, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var throttles []domain.LoginThrottle
+	for rows.Next() {
+		throttle, err := scanLoginThrottle(rows)
+		if err != nil {
+			return nil, err
+		}
+		throttles = append(throttles, *throttle)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return throttles, nil
+}
+
+func scanLoginThrottle(row rowScanner) (*domain.LoginThrottle, error) {
+	throttle := &domain.LoginThrottle{}
+	var blockedUntil sql.NullTime
+	if err := row.Scan(&throttle.Key, &throttle.Failures, &throttle.LastFailureAt, &blockedUntil); err != nil {
+		return nil, err
+	}
+	throttle.BlockedUntil = nullTimePtr(blockedUntil)
+	return throttle, nil
+}
@@ -0,0 +1,171 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go-todo-service/internal/domain"
+)
+
+// MFARepository stores TOTP enrollments and recovery codes in PostgreSQL.
+type MFARepository struct {
+	db *sql.DB
+}
+
+// NewMFARepository constructs the repository.
+func NewMFARepository(db *sql.DB) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+// GetByUser fetches the user's enrollment, confirmed or not.
+func (r *MFARepository) GetByUser(ctx context.Context, userID string) (*domain.MFAEnrollment, error) {
+	const query = `
+		SELECT user_id, secret, last_used_step, confirmed_at, created_at
+		FROM user_mfa
+		WHERE user_id = $1`
+	enrollment := &domain.MFAEnrollment{}
+	var confirmedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&enrollment.UserID,
+		&enrollment.Secret,
+		&enrollment.LastUsedStep,
+		&confirmedAt,
+		&enrollment.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	enrollment.ConfirmedAt = nullTimePtr(confirmedAt)
+	return enrollment, nil
+}
+
+// SavePending upserts an unconfirmed enrollment. The conflict clause never
+// overwrites a confirmed row, which is reported as domain.ErrConflict.
+func (r *MFARepository) SavePending(ctx context.Context, enrollment *domain.MFAEnrollment) error {
+	const query = `
+		INSERT INTO user_mfa (user_id, secret, last_used_step, created_at)
+		VALUES ($1, $2, 0, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = 0, created_at = EXCLUDED.created_at
+		WHERE user_mfa.confirmed_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, enrollment.UserID, enrollment.Secret, enrollment.CreatedAt)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrConflict
+	}
+	return nil
+}
+
+// Confirm enables the enrollment and stores fresh recovery codes in one transaction.
+func (r *MFARepository) Confirm(ctx context.Context, userID string, step int64, at time.Time, codes []domain.RecoveryCode) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	const confirm = `
+		UPDATE user_mfa
+		SET confirmed_at = $1, last_used_step = $2
+		WHERE user_id = $3 AND confirmed_at IS NULL AND last_used_step < $2`
+	result, err := tx.ExecContext(ctx, confirm, at, step, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrConflict
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	const insert = `
+		INSERT INTO mfa_recovery_codes (id, user_id, code_hash, created_at)
+		VALUES ($1, $2, $3, $4)`
+	for _, code := range codes {
+		if _, err := tx.ExecContext(ctx, insert, code.ID, code.UserID, code.CodeHash, code.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UseStep advances last_used_step only forwards so each code is accepted once.
+func (r *MFARepository) UseStep(ctx context.Context, userID string, step int64) error {
+	const query = `
+		UPDATE user_mfa
+		SET last_used_step = $1
+		WHERE user_id = $2 AND confirmed_at IS NOT NULL AND last_used_step < $1`
+	result, err := r.db.ExecContext(ctx, query, step, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrConflict
+	}
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used.
+func (r *MFARepository) UseRecoveryCode(ctx context.Context, userID, codeHash string, at time.Time) error {
+	const query = `
+		UPDATE mfa_recovery_codes
+		SET used_at = $1
+		WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, at, userID, codeHash)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes the enrollment and all recovery codes of the user.
+func (r *MFARepository) Delete(ctx context.Context, userID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM user_mfa WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrNotFound
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go-todo-service/internal/domain"
+)
+
+const projectColumns = `id, user_id, name, description, color, archived, sort_order, inbox, created_at, updated_at`
+
+// ProjectRepository stores projects in PostgreSQL.
+type ProjectRepository struct {
+	db *sql.DB
+}
+
+// NewProjectRepository constructs the repository.
+func NewProjectRepository(db *sql.DB) *ProjectRepository {
+	return &ProjectRepository{db: db}
+}
+
+// Create inserts a project row.
+func (r *ProjectRepository) Create(ctx context.Context, project *domain.Project) error {
+	return insertProject(ctx, r.db, project)
+}
+
+func insertProject(ctx context.Context, db execer, project *domain.Project) error {
+	const query = `
+		INSERT INTO projects (id, user_id, name, description, color, archived, sort_order, inbox, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err := db.ExecContext(ctx, query,
+		project.ID,
+		project.UserID,
+		project.Name,
+		project.Description,
+		project.Color,
+		project.Archived,
+		project.SortOrder,
+		project.Inbox,
+		project.CreatedAt,
+		project.UpdatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// GetByID fetches a project by identifier.
+func (r *ProjectRepository) GetByID(ctx context.Context, id string) (*domain.Project, error) {
+	const query = `
+		SELECT ` + projectColumns + `
+		FROM projects
+		WHERE id = $1`
+	return r.getProject(ctx, query, id)
+}
+
+// GetInbox fetches the user's inbox project.
+func (r *ProjectRepository) GetInbox(ctx context.Context, userID string) (*domain.Project, error) {
+	const query = `
+		SELECT ` + projectColumns + `
+		FROM projects
+		WHERE user_id = $1 AND inbox`
+	return r.getProject(ctx, query, userID)
+}
+
+// ListByUser returns the user's projects in display order.
+func (r *ProjectRepository) ListByUser(ctx context.Context, userID string, includeArchived bool) ([]domain.Project, error) {
+	const query = `
+		SELECT ` + projectColumns + `
+		FROM projects
+		WHERE user_id = $1 AND ($2 OR NOT archived)
+		ORDER BY sort_order, created_at, id`
+	rows, err := r.db.QueryContext(ctx, query, userID, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []domain.Project
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, *project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// Update mutates an existing project row.
+func (r *ProjectRepository) Update(ctx context.Context, project *domain.Project) error {
+	const query = `
+		UPDATE projects
+		SET name = $1, description = $2, color = $3, archived = $4, sort_order = $5, updated_at = $6
+		WHERE id = $7`
+	result, err := r.db.ExecContext(ctx, query,
+		project.Name,
+		project.Description,
+		project.Color,
+		project.Archived,
+		project.SortOrder,
+		project.UpdatedAt,
+		project.ID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a project together with its tasks, or after moving them.
+func (r *ProjectRepository) Delete(ctx context.Context, id, moveTasksTo string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if moveTasksTo != "" {
+		_, err = tx.ExecContext(ctx, `UPDATE tasks SET project_id = $1 WHERE project_id = $2`, moveTasksTo, id)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM tasks WHERE project_id = $1`, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM projects WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrNotFound
+	}
+	return tx.Commit()
+}
+
+func (r *ProjectRepository) getProject(ctx context.Context, query string, args ...any) (*domain.Project, error) {
+	project, err := scanProject(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return project, nil
+}
+
+func scanProject(row rowScanner) (*domain.Project, error) {
+	project := &domain.Project{}
+	if err := row.Scan(
+		&project.ID,
+		&project.UserID,
+		&project.Name,
+		&project.Description,
+		&project.Color,
+		&project.Archived,
+		&project.SortOrder,
+		&project.Inbox,
+		&project.CreatedAt,
+		&project.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// RevokedTokenRepository keeps the access-token denylist in PostgreSQL.
+type RevokedTokenRepository struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// NewRevokedTokenRepository constructs the repository.
+func NewRevokedTokenRepository(db *sql.DB) *RevokedTokenRepository {
+	return &RevokedTokenRepository{db: db, now: time.Now}
+}
+
+// Revoke denylists a token id and prunes entries whose tokens have expired.
+func (r *RevokedTokenRepository) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	const query = `
+		WITH pruned AS (
+			DELETE FROM revoked_tokens WHERE expires_at < $3
+		)
+		INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING`
+	_, err := r.db.ExecContext(ctx, query, tokenID, expiresAt, r.now().UTC())
+	return err
+}
+
+// Claim denylists a token id unless it already is and reports whether this
+// call inserted it. Concurrent claims of one id are serialised on its
+// primary key, so only one of them inserts the row.
+func (r *RevokedTokenRepository) Claim(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	const query = `
+		WITH pruned AS (
+			DELETE FROM revoked_tokens WHERE expires_at < $3
+		)
+		INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING`
+	result, err := r.db.ExecContext(ctx, query, tokenID, expiresAt, r.now().UTC())
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// IsRevoked reports whether the token id is on the denylist.
+func (r *RevokedTokenRepository) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	const query = `
+		SELECT EXISTS (
+			SELECT 1 FROM revoked_tokens WHERE jti = $1
+		)`
+	var revoked bool
+	if err := r.db.QueryRowContext(ctx, query, tokenID).Scan(&revoked); err != nil {
+		return false, err
+	}
+	return revoked, nil
+}
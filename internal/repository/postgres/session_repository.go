@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go-todo-service/internal/domain"
+)
+
+const sessionColumns = `id, user_id, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at`
+
+// SessionRepository stores login sessions in PostgreSQL.
+type SessionRepository struct {
+	db *sql.DB
+}
+
+// NewSessionRepository constructs the repository.
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create inserts a session row.
+func (r *SessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	const query = `
+		INSERT INTO sessions (id, user_id, user_agent, ip, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.db.ExecContext(ctx, query,
+		session.ID,
+		session.UserID,
+		session.UserAgent,
+		session.IP,
+		session.CreatedAt,
+		session.LastSeenAt,
+		session.ExpiresAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// GetByID fetches a session, including revoked and expired ones.
+func (r *SessionRepository) GetByID(ctx context.Context, id string) (*domain.Session, error) {
+	const query = `
+		SELECT ` + sessionColumns + `
+		FROM sessions
+		WHERE id = $1`
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return session, nil
+}
+
+// ListActiveByUser returns the user's live sessions, most recently seen first.
+func (r *SessionRepository) ListActiveByUser(ctx context.Context, userID string, now time.Time) ([]domain.Session, error) {
+	const query = `
+		SELECT ` + sessionColumns + `
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC, id`
+	rows, err := r.db.QueryContext(ctx, query, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []domain.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Touch records activity on an active session.
+func (r *SessionRepository) Touch(ctx context.Context, id string, at time.Time) error {
+	const query = `
+		UPDATE sessions
+		SET last_seen_at = $1
+		WHERE id = $2 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, at, id)
+	return err
+}
+
+// Renew records activity and moves the expiry of an active session.
+func (r *SessionRepository) Renew(ctx context.Context, id string, at, expiresAt time.Time) error {
+	const query = `
+		UPDATE sessions
+		SET last_seen_at = $1, expires_at = $2
+		WHERE id = $3 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, at, expiresAt, id)
+	return err
+}
+
+// Revoke ends one active session of the user.
+func (r *SessionRepository) Revoke(ctx context.Context, userID, id string, at time.Time) error {
+	const query = `
+		UPDATE sessions
+		SET revoked_at = $1
+		WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, at, id, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// RevokeByUser ends every active session of the user.
+func (r *SessionRepository) RevokeByUser(ctx context.Context, userID string, at time.Time) error {
+	const query = `
+		UPDATE sessions
+		SET revoked_at = $1
+		WHERE user_id = $2 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, at, userID)
+	return err
+}
+
+// RevokeOthers ends the user's active sessions except one.
+func (r *SessionRepository) RevokeOthers(ctx context.Context, userID, keepID string, at time.Time) error {
+	const query = `
+		UPDATE sessions
+		SET revoked_at = $1
+		WHERE user_id = $2 AND id <> $3 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, at, userID, keepID)
+	return err
+}
+
+func scanSession(row rowScanner) (*domain.Session, error) {
+	session := &domain.Session{}
+	var revokedAt sql.NullTime
+	if err := row.Scan(
+		&session.ID, &session.UserID, &session.UserAgent, &session.IP,
+		&session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &revokedAt,
+	); err != nil {
+		return nil, err
+	}
+	session.RevokedAt = nullTimePtr(revokedAt)
+	return session, nil
+}
@@ -0,0 +1,220 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go-todo-service/internal/domain"
+)
+
+const tagColumns = `id, user_id, name, color, created_at, updated_at`
+
+// TagRepository stores tags and task assignments in PostgreSQL.
+type TagRepository struct {
+	db *sql.DB
+}
+
+// NewTagRepository constructs the repository.
+func NewTagRepository(db *sql.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// Create inserts a tag row.
+func (r *TagRepository) Create(ctx context.Context, tag *domain.Tag) error {
+	return insertTag(ctx, r.db, tag)
+}
+
+func insertTag(ctx context.Context, db execer, tag *domain.Tag) error {
+	const query = `
+		INSERT INTO tags (id, user_id, name, color, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := db.ExecContext(ctx, query,
+		tag.ID,
+		tag.UserID,
+		tag.Name,
+		tag.Color,
+		tag.CreatedAt,
+		tag.UpdatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// GetByID fetches a tag by identifier.
+func (r *TagRepository) GetByID(ctx context.Context, id string) (*domain.Tag, error) {
+	const query = `
+		SELECT ` + tagColumns + `
+		FROM tags
+		WHERE id = $1`
+	tag, err := scanTag(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return tag, nil
+}
+
+// ListByUser returns the user's tags ordered by name.
+func (r *TagRepository) ListByUser(ctx context.Context, userID string) ([]domain.Tag, error) {
+	const query = `
+		SELECT ` + tagColumns + `
+		FROM tags
+		WHERE user_id = $1
+		ORDER BY LOWER(name), id`
+	return r.queryTags(ctx, query, userID)
+}
+
+// GetByNames returns the user's tags matching any of names, ignoring case.
+func (r *TagRepository) GetByNames(ctx context.Context, userID string, names []string) ([]domain.Tag, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	args := []any{userID}
+	placeholders := make([]string, len(names))
+	for i, name := range names {
+		args = append(args, strings.ToLower(name))
+		placeholders[i] = fmt.Sprintf("$%d", len(args))
+	}
+	query := `
+		SELECT ` + tagColumns + `
+		FROM tags
+		WHERE user_id = $1 AND LOWER(name) IN (` + strings.Join(placeholders, ", ") + `)
+		ORDER BY LOWER(name), id`
+	return r.queryTags(ctx, query, args...)
+}
+
+// Update mutates an existing tag row.
+func (r *TagRepository) Update(ctx context.Context, tag *domain.Tag) error {
+	const query = `
+		UPDATE tags
+		SET name = $1, color = $2, updated_at = $3
+		WHERE id = $4`
+	result, err := r.db.ExecContext(ctx, query, tag.Name, tag.Color, tag.UpdatedAt, tag.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrConflict
+		}
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a tag row; its task assignments cascade.
+func (r *TagRepository) Delete(ctx context.Context, id string) error {
+	const query = `
+		DELETE FROM tags
+		WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// SetTaskTags replaces a task's tags in one transaction.
+func (r *TagRepository) SetTaskTags(ctx context.Context, taskID string, tagIDs []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM task_tags WHERE task_id = $1`, taskID); err != nil {
+		return err
+	}
+	const insert = `
+		INSERT INTO task_tags (task_id, tag_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`
+	for _, tagID := range tagIDs {
+		if _, err := tx.ExecContext(ctx, insert, taskID, tagID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListByTasks returns the tags of each task ordered by name.
+func (r *TagRepository) ListByTasks(ctx context.Context, taskIDs []string) (map[string][]domain.Tag, error) {
+	out := make(map[string][]domain.Tag)
+	if len(taskIDs) == 0 {
+		return out, nil
+	}
+	query := `
+		SELECT tt.task_id, t.id, t.user_id, t.name, t.color, t.created_at, t.updated_at
+		FROM task_tags tt
+		JOIN tags t ON t.id = tt.tag_id
+		WHERE tt.task_id = ANY($1)
+		ORDER BY tt.task_id, LOWER(t.name), t.id`
+	rows, err := r.db.QueryContext(ctx, query, taskIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskID string
+		var tag domain.Tag
+		if err := rows.Scan(&taskID, &tag.ID, &tag.UserID, &tag.Name, &tag.Color, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out[taskID] = append(out[taskID], tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *TagRepository) queryTags(ctx context.Context, query string, args ...any) ([]domain.Tag, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []domain.Tag
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, *tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func scanTag(row rowScanner) (*domain.Tag, error) {
+	tag := &domain.Tag{}
+	if err := row.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.Color, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
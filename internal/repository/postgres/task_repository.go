@@ -4,10 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 
 	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository"
 )
 
+const taskColumns = `id, user_id, project_id, parent_id, title, description, status, priority, due_at, due_all_day, recurrence, recurrence_basis, created_at, updated_at`
+
 // TaskRepository persists tasks in PostgreSQL.
 type TaskRepository struct {
 	db *sql.DB
@@ -20,58 +25,217 @@ func NewTaskRepository(db *sql.DB) *TaskRepository {
 
 // Create inserts a task row.
 func (r *TaskRepository) Create(ctx context.Context, task *domain.Task) error {
+	if task.ParentID == "" {
+		return insertTask(ctx, r.db, task)
+	}
+	return r.inHierarchyTx(ctx, task, func(tx *sql.Tx) error {
+		return insertTask(ctx, tx, task)
+	})
+}
+
+// inHierarchyTx runs write in a transaction after re-checking that task may
+// sit under its parent. The user's row is locked first so that hierarchy
+// changes of one user run one after the other: each sees the moves committed
+// before it, and two moves cannot both pass on a snapshot without the other.
+func (r *TaskRepository) inHierarchyTx(ctx context.Context, task *domain.Task, write func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// NO KEY UPDATE does not conflict with the key share locks taken by
+	// foreign keys, so inserts referencing the user are not held up.
+	var userID string
+	err = tx.QueryRowContext(ctx, `SELECT id FROM users WHERE id = $1 FOR NO KEY UPDATE`, task.UserID).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrNotFound
+		}
+		return err
+	}
+	if err := checkPlacement(ctx, tx, task.ID, task.ParentID); err != nil {
+		return err
+	}
+	if err := write(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// checkPlacement reports domain.ErrConcurrentUpdate when putting the task
+// id under parentID would nest it under itself or make the hierarchy deeper
+// than domain.MaxTaskDepth levels, given the rows as they are now.
+func checkPlacement(ctx context.Context, tx *sql.Tx, id, parentID string) error {
 	const query = `
-		INSERT INTO tasks (id, user_id, title, description, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := r.db.ExecContext(ctx, query,
+		WITH RECURSIVE ancestors (id, parent_id) AS (
+			SELECT id, parent_id FROM tasks WHERE id = $2
+			UNION ALL
+			SELECT t.id, t.parent_id
+			FROM tasks t
+			JOIN ancestors a ON t.id = a.parent_id
+		) CYCLE id SET is_cycle USING path,
+		descendants (id, depth) AS (
+			SELECT id, 1 FROM tasks WHERE id = $1
+			UNION ALL
+			SELECT t.id, d.depth + 1
+			FROM tasks t
+			JOIN descendants d ON t.parent_id = d.id
+		) CYCLE id SET is_cycle USING path
+		SELECT
+			(SELECT COUNT(*) FROM ancestors WHERE NOT is_cycle),
+			(SELECT COALESCE(bool_or(id = $1 OR is_cycle), false) FROM ancestors),
+			(SELECT COALESCE(MAX(depth), 1) FROM descendants WHERE NOT is_cycle)`
+	var levels, height int
+	var cycle bool
+	if err := tx.QueryRowContext(ctx, query, id, parentID).Scan(&levels, &cycle, &height); err != nil {
+		return err
+	}
+	// The parent and its ancestors sit above the task's subtree.
+	if cycle || levels+height > domain.MaxTaskDepth {
+		return domain.ErrConcurrentUpdate
+	}
+	return nil
+}
+
+func insertTask(ctx context.Context, db execer, task *domain.Task) error {
+	const query = `
+		INSERT INTO tasks (id, user_id, project_id, parent_id, title, description, status, priority, due_at, due_all_day, recurrence, recurrence_basis, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+	_, err := db.ExecContext(ctx, query,
 		task.ID,
 		task.UserID,
+		nullString(task.ProjectID),
+		nullString(task.ParentID),
 		task.Title,
 		task.Description,
 		task.Status,
+		priorityRank(task.Priority),
+		task.DueAt,
+		task.DueAllDay,
+		task.Recurrence,
+		recurrenceBasis(task.RecurrenceBasis),
 		task.CreatedAt,
 		task.UpdatedAt,
 	)
 	return err
 }
 
-// ListByUser returns tasks for a given user ordered by creation time.
-func (r *TaskRepository) ListByUser(ctx context.Context, userID string) ([]domain.Task, error) {
-	const query = `
-		SELECT id, user_id, title, description, status, created_at, updated_at
-		FROM tasks
-		WHERE user_id = $1
-		ORDER BY created_at DESC`
+// ListByUser returns a page of tasks for a given user using keyset pagination.
+func (r *TaskRepository) ListByUser(ctx context.Context, userID string, opts repository.TaskListOptions) ([]domain.Task, error) {
+	conditions := []string{"user_id = $1"}
+	args := []any{userID}
+	addArg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
-	if err != nil {
-		return nil, err
+	filter := opts.Filter
+	if filter.ProjectID != "" {
+		conditions = append(conditions, "project_id = "+addArg(filter.ProjectID))
+	}
+	if filter.ParentID != "" {
+		conditions = append(conditions, "parent_id = "+addArg(filter.ParentID))
+	}
+	if filter.RootsOnly {
+		conditions = append(conditions, "parent_id IS NULL")
+	}
+	if filter.Status != nil {
+		conditions = append(conditions, "status = "+addArg(*filter.Status))
+	}
+	if len(filter.Priorities) > 0 {
+		placeholders := make([]string, len(filter.Priorities))
+		for i, priority := range filter.Priorities {
+			placeholders[i] = addArg(priorityRank(priority))
+		}
+		conditions = append(conditions, "priority IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if filter.Query != "" {
+		pattern := addArg("%" + escapeLike(filter.Query) + "%")
+		conditions = append(conditions, fmt.Sprintf("(title ILIKE %s OR description ILIKE %s)", pattern, pattern))
+	}
+	if filter.DueBefore != nil {
+		conditions = append(conditions, "due_at < "+addArg(*filter.DueBefore))
+	}
+	if filter.DueAfter != nil {
+		conditions = append(conditions, "due_at >= "+addArg(*filter.DueAfter))
+	}
+	if filter.Overdue != nil {
+		conditions = append(conditions, fmt.Sprintf(
+			"status = 'pending' AND due_at IS NOT NULL AND ((NOT due_all_day AND due_at < %s) OR (due_all_day AND due_at < %s))",
+			addArg(filter.Overdue.Now),
+			addArg(filter.Overdue.Today),
+		))
+	}
+	if filter.DueWithin != nil {
+		conditions = append(conditions, fmt.Sprintf(
+			"due_at IS NOT NULL AND ((NOT due_all_day AND due_at >= %s AND due_at < %s) OR (due_all_day AND due_at >= %s AND due_at < %s))",
+			addArg(filter.DueWithin.Start),
+			addArg(filter.DueWithin.End),
+			addArg(filter.DueWithin.StartDate),
+			addArg(filter.DueWithin.EndDate),
+		))
+	}
+	if len(filter.Tags) > 0 {
+		placeholders := make([]string, len(filter.Tags))
+		for i, name := range filter.Tags {
+			placeholders[i] = addArg(name)
+		}
+		subquery := `id IN (
+			SELECT tt.task_id
+			FROM task_tags tt
+			JOIN tags t ON t.id = tt.tag_id
+			WHERE t.user_id = $1 AND LOWER(t.name) IN (` + strings.Join(placeholders, ", ") + `)`
+		if filter.MatchAllTags {
+			subquery += `
+			GROUP BY tt.task_id
+			HAVING COUNT(DISTINCT t.id) = ` + addArg(len(filter.Tags))
+		}
+		conditions = append(conditions, subquery+")")
 	}
-	defer rows.Close()
 
-	var tasks []domain.Task
-	for rows.Next() {
-		var task domain.Task
-		if err := rows.Scan(&task.ID, &task.UserID, &task.Title, &task.Description, &task.Status, &task.CreatedAt, &task.UpdatedAt); err != nil {
-			return nil, err
+	var orderBy string
+	if opts.Sort.Field == repository.TaskSortPriority {
+		direction, comparator := "ASC", ">"
+		if opts.Sort.Descending {
+			direction, comparator = "DESC", "<"
+		}
+		if opts.After != nil {
+			conditions = append(conditions, priorityAfter(opts.After, comparator, addArg))
 		}
-		tasks = append(tasks, task)
+		orderBy = "priority " + direction + ", due_at ASC NULLS LAST, id ASC"
+	} else {
+		column, cursorValue := sortColumn(opts.Sort.Field, opts.After)
+		direction, comparator := "ASC", ">"
+		if opts.Sort.Descending {
+			direction, comparator = "DESC", "<"
+		}
+		if opts.After != nil {
+			conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s, %s)", column, comparator, addArg(cursorValue), addArg(opts.After.ID)))
+		}
+		orderBy = column + ` ` + direction + `, id ` + direction
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY ` + orderBy
+	if opts.Limit > 0 {
+		query += `
+		LIMIT ` + addArg(opts.Limit)
 	}
-	return tasks, nil
+	return r.queryTasks(ctx, query, args...)
 }
 
 // GetByID fetches a task by identifier.
 func (r *TaskRepository) GetByID(ctx context.Context, id string) (*domain.Task, error) {
 	const query = `
-		SELECT id, user_id, title, description, status, created_at, updated_at
+		SELECT ` + taskColumns + `
 		FROM tasks
 		WHERE id = $1`
-	row := r.db.QueryRowContext(ctx, query, id)
-	task := &domain.Task{}
-	if err := row.Scan(&task.ID, &task.UserID, &task.Title, &task.Description, &task.Status, &task.CreatedAt, &task.UpdatedAt); err != nil {
+	task, err := scanTask(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, domain.ErrNotFound
 		}
@@ -80,16 +244,72 @@ func (r *TaskRepository) GetByID(ctx context.Context, id string) (*domain.Task,
 	return task, nil
 }
 
-// Update mutates an existing task row.
+// Update mutates an existing task row. Subtasks are re-checked even when the
+// parent is unchanged, since a move committed after the task was read may
+// otherwise be undone.
 func (r *TaskRepository) Update(ctx context.Context, task *domain.Task) error {
+	if task.ParentID == "" {
+		return updateTask(ctx, r.db, task)
+	}
+	return r.inHierarchyTx(ctx, task, func(tx *sql.Tx) error {
+		return updateTask(ctx, tx, task)
+	})
+}
+
+// CompleteOccurrence saves a completed recurring task and inserts its next
+// occurrence, carrying the tags over, in one transaction.
+func (r *TaskRepository) CompleteOccurrence(ctx context.Context, done, next *domain.Task) error {
+	write := func(tx *sql.Tx) error {
+		if err := updateTask(ctx, tx, done); err != nil {
+			return err
+		}
+		if err := insertTask(ctx, tx, next); err != nil {
+			return err
+		}
+		const copyTags = `
+			INSERT INTO task_tags (task_id, tag_id)
+			SELECT $1, tag_id
+			FROM task_tags
+			WHERE task_id = $2`
+		if _, err := tx.ExecContext(ctx, copyTags, next.ID, done.ID); err != nil {
+			return err
+		}
+		return nil
+	}
+	// The next occurrence shares the parent of the completed task, whose
+	// check covers it.
+	if done.ParentID != "" {
+		return r.inHierarchyTx(ctx, done, write)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := write(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func updateTask(ctx context.Context, db execer, task *domain.Task) error {
 	const query = `
 		UPDATE tasks
-		SET title = $1, description = $2, status = $3, updated_at = $4
-		WHERE id = $5`
-	result, err := r.db.ExecContext(ctx, query,
+		SET project_id = $1, parent_id = $2, title = $3, description = $4, status = $5, priority = $6, due_at = $7, due_all_day = $8, recurrence = $9, recurrence_basis = $10, updated_at = $11
+		WHERE id = $12`
+	result, err := db.ExecContext(ctx, query,
+		nullString(task.ProjectID),
+		nullString(task.ParentID),
 		task.Title,
 		task.Description,
 		task.Status,
+		priorityRank(task.Priority),
+		task.DueAt,
+		task.DueAllDay,
+		task.Recurrence,
+		recurrenceBasis(task.RecurrenceBasis),
 		task.UpdatedAt,
 		task.ID,
 	)
@@ -124,3 +344,173 @@ func (r *TaskRepository) Delete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+// ListAncestors walks up the parent chain of a task with a recursive query.
+// The CYCLE clause stops the walk should the chain ever loop.
+func (r *TaskRepository) ListAncestors(ctx context.Context, id string) ([]domain.Task, error) {
+	const query = `
+		WITH RECURSIVE ancestors (id) AS (
+			SELECT parent_id FROM tasks WHERE id = $1 AND parent_id IS NOT NULL
+			UNION ALL
+			SELECT t.parent_id
+			FROM tasks t
+			JOIN ancestors a ON t.id = a.id
+			WHERE t.parent_id IS NOT NULL
+		) CYCLE id SET is_cycle USING path
+		SELECT ` + taskColumns + `
+		FROM tasks
+		WHERE id IN (SELECT id FROM ancestors)`
+	return r.queryTasks(ctx, query, id)
+}
+
+// ListDescendants collects the subtrees below the given tasks with a
+// recursive query.
+func (r *TaskRepository) ListDescendants(ctx context.Context, ids []string) ([]domain.Task, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	// One array parameter rather than a placeholder per id: an import can
+	// pass more ids than Postgres allows bind parameters.
+	query := `
+		WITH RECURSIVE descendants (id) AS (
+			SELECT id FROM tasks WHERE parent_id = ANY($1)
+			UNION ALL
+			SELECT t.id
+			FROM tasks t
+			JOIN descendants d ON t.parent_id = d.id
+		) CYCLE id SET is_cycle USING path
+		SELECT ` + taskColumns + `
+		FROM tasks
+		WHERE id IN (SELECT id FROM descendants)
+		ORDER BY created_at, id`
+	return r.queryTasks(ctx, query, ids)
+}
+
+// CountSubtasks counts the direct subtasks of each task.
+func (r *TaskRepository) CountSubtasks(ctx context.Context, ids []string) (map[string]domain.SubtaskProgress, error) {
+	out := make(map[string]domain.SubtaskProgress)
+	if len(ids) == 0 {
+		return out, nil
+	}
+	query := `
+		SELECT parent_id, COUNT(*), COUNT(*) FILTER (WHERE status = 'done')
+		FROM tasks
+		WHERE parent_id = ANY($1)
+		GROUP BY parent_id`
+	rows, err := r.db.QueryContext(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var parentID string
+		var progress domain.SubtaskProgress
+		if err := rows.Scan(&parentID, &progress.Total, &progress.Done); err != nil {
+			return nil, err
+		}
+		out[parentID] = progress
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *TaskRepository) queryTasks(ctx context.Context, query string, args ...any) ([]domain.Task, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []domain.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// sortColumn maps a sort field to its column and extracts the matching cursor value.
+func sortColumn(field repository.TaskSortField, cursor *repository.TaskCursor) (string, any) {
+	var value any
+	switch field {
+	case repository.TaskSortUpdatedAt:
+		if cursor != nil {
+			value = cursor.UpdatedAt
+		}
+		return "updated_at", value
+	case repository.TaskSortTitle:
+		if cursor != nil {
+			value = cursor.Title
+		}
+		return "title", value
+	default:
+		if cursor != nil {
+			value = cursor.CreatedAt
+		}
+		return "created_at", value
+	}
+}
+
+// priorityAfter selects the tasks following the cursor in priority order.
+// Within a priority tasks run by due date, soonest first with undated tasks
+// last, then by id, so the comparison is spelled out instead of using a row
+// comparison.
+func priorityAfter(cursor *repository.TaskCursor, comparator string, addArg func(any) string) string {
+	priority := addArg(priorityRank(cursor.Priority))
+	id := addArg(cursor.ID)
+	if cursor.DueAt == nil {
+		return fmt.Sprintf("(priority %s %s OR (priority = %s AND due_at IS NULL AND id > %s))", comparator, priority, priority, id)
+	}
+	due := addArg(*cursor.DueAt)
+	return fmt.Sprintf(
+		"(priority %s %s OR (priority = %s AND (due_at IS NULL OR due_at > %s OR (due_at = %s AND id > %s))))",
+		comparator, priority, priority, due, due, id,
+	)
+}
+
+// priorityRank maps a priority to its stored rank. The zero value is stored
+// as none.
+func priorityRank(priority domain.TaskPriority) int {
+	if rank := priority.Rank(); rank > 0 {
+		return rank
+	}
+	return 0
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(row rowScanner) (*domain.Task, error) {
+	task := &domain.Task{}
+	var priority int
+	var basis string
+	var projectID, parentID sql.NullString
+	var dueAt sql.NullTime
+	if err := row.Scan(&task.ID, &task.UserID, &projectID, &parentID, &task.Title, &task.Description, &task.Status, &priority, &dueAt, &task.DueAllDay, &task.Recurrence, &basis, &task.CreatedAt, &task.UpdatedAt); err != nil {
+		return nil, err
+	}
+	task.Priority = domain.TaskPriorityFromRank(priority)
+	task.ProjectID = projectID.String
+	task.ParentID = parentID.String
+	task.DueAt = nullTimePtr(dueAt)
+	task.RecurrenceBasis = domain.RecurrenceBasis(basis)
+	return task, nil
+}
+
+// recurrenceBasis stores an unset basis as the column default.
+func recurrenceBasis(basis domain.RecurrenceBasis) string {
+	if basis == "" {
+		return string(domain.RecurFromDue)
+	}
+	return string(basis)
+}
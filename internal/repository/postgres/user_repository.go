@@ -4,9 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository"
 )
 
 // UserRepository stores and retrieves users from PostgreSQL.
@@ -22,12 +25,17 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 // Create persists a new user row.
 func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	const query = `
-		INSERT INTO users (id, email, password_hash, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)`
+		INSERT INTO users (id, email, password_hash, display_name, time_zone, locale, role, email_verified_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
 	_, err := r.db.ExecContext(ctx, query,
 		user.ID,
 		user.Email,
 		user.PasswordHash,
+		user.DisplayName,
+		user.TimeZone,
+		user.Locale,
+		user.EffectiveRole(),
+		user.EmailVerifiedAt,
 		user.CreatedAt,
 		user.UpdatedAt,
 	)
@@ -40,36 +48,188 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	return nil
 }
 
+const userColumns = `id, email, password_hash, display_name, time_zone, locale, role, disabled_at, disabled_reason, disabled_until, password_reset_required, email_verified_at, created_at, updated_at`
+
 // GetByEmail fetches a user via email.
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	const query = `
-		SELECT id, email, password_hash, created_at, updated_at
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE email = $1`
-	row := r.db.QueryRowContext(ctx, query, strings.ToLower(email))
-	user := &domain.User{}
-	if err := row.Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, domain.ErrNotFound
-		}
-		return nil, err
-	}
-	return user, nil
+	return scanUser(r.db.QueryRowContext(ctx, query, strings.ToLower(email)))
 }
 
 // GetByID fetches a user via id.
 func (r *UserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
 	const query = `
-		SELECT id, email, password_hash, created_at, updated_at
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE id = $1`
-	row := r.db.QueryRowContext(ctx, query, id)
+	return scanUser(r.db.QueryRowContext(ctx, query, id))
+}
+
+// UpdatePassword replaces the stored password hash and clears any forced reset.
+func (r *UserRepository) UpdatePassword(ctx context.Context, id, passwordHash string, at time.Time) error {
+	const query = `
+		UPDATE users
+		SET password_hash = $1, password_reset_required = FALSE, updated_at = $2
+		WHERE id = $3`
+	result, err := r.db.ExecContext(ctx, query, passwordHash, at, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// MarkEmailVerified records when the user confirmed their email. An earlier
+// verification timestamp is kept.
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id string, at time.Time) error {
+	const query = `
+		UPDATE users
+		SET email_verified_at = COALESCE(email_verified_at, $1), updated_at = $1
+		WHERE id = $2`
+	result, err := r.db.ExecContext(ctx, query, at, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// Update persists the mutable account fields.
+func (r *UserRepository) Update(ctx context.Context, user *domain.User, unmodifiedSince time.Time) error {
+	const query = `
+		UPDATE users
+		SET email = $1, email_verified_at = $2, display_name = $3, time_zone = $4, locale = $5,
+			role = $6, disabled_at = $7, disabled_reason = $8, disabled_until = $9,
+			password_reset_required = $10, updated_at = $11
+		WHERE id = $12 AND updated_at = $13`
+	result, err := r.db.ExecContext(ctx, query,
+		strings.ToLower(user.Email),
+		user.EmailVerifiedAt,
+		user.DisplayName,
+		user.TimeZone,
+		user.Locale,
+		user.EffectiveRole(),
+		user.DisabledAt,
+		user.DisabledReason,
+		user.DisabledUntil,
+		user.PasswordResetRequired,
+		user.UpdatedAt,
+		user.ID,
+		unmodifiedSince,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrConflict
+		}
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		if _, err := r.GetByID(ctx, user.ID); err != nil {
+			return err
+		}
+		return domain.ErrConcurrentUpdate
+	}
+	return nil
+}
+
+// Delete removes the user row; foreign keys cascade to dependent rows.
+func (r *UserRepository) Delete(ctx context.Context, id string) error {
+	const query = `
+		DELETE FROM users
+		WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// List returns a page of users ordered by email.
+func (r *UserRepository) List(ctx context.Context, opts repository.UserListOptions) ([]domain.User, error) {
+	conditions := []string{"TRUE"}
+	var args []any
+	addArg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if opts.Query != "" {
+		pattern := addArg("%" + escapeLike(opts.Query) + "%")
+		conditions = append(conditions, fmt.Sprintf("(email ILIKE %s OR display_name ILIKE %s)", pattern, pattern))
+	}
+	if opts.AfterEmail != "" {
+		conditions = append(conditions, "email > "+addArg(opts.AfterEmail))
+	}
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY email`
+	if opts.Limit > 0 {
+		query += `
+		LIMIT ` + addArg(opts.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func scanUser(row rowScanner) (*domain.User, error) {
 	user := &domain.User{}
-	if err := row.Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt); err != nil {
+	var verifiedAt, disabledAt, disabledUntil sql.NullTime
+	if err := row.Scan(
+		&user.ID, &user.Email, &user.PasswordHash, &user.DisplayName, &user.TimeZone, &user.Locale,
+		&user.Role, &disabledAt, &user.DisabledReason, &disabledUntil, &user.PasswordResetRequired, &verifiedAt, &user.CreatedAt, &user.UpdatedAt,
+	); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, domain.ErrNotFound
 		}
 		return nil, err
 	}
+	user.DisabledAt = nullTimePtr(disabledAt)
+	user.DisabledUntil = nullTimePtr(disabledUntil)
+	user.EmailVerifiedAt = nullTimePtr(verifiedAt)
 	return user, nil
 }
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go-todo-service/internal/domain"
+)
+
+// UserTokenRepository stores hashed single-use user tokens in PostgreSQL.
+type UserTokenRepository struct {
+	db *sql.DB
+}
+
+// NewUserTokenRepository constructs the repository.
+func NewUserTokenRepository(db *sql.DB) *UserTokenRepository {
+	return &UserTokenRepository{db: db}
+}
+
+// Create inserts a token row.
+func (r *UserTokenRepository) Create(ctx context.Context, token *domain.UserToken) error {
+	const query = `
+		INSERT INTO user_tokens (id, user_id, purpose, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := r.db.ExecContext(ctx, query,
+		token.ID,
+		token.UserID,
+		token.Purpose,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// GetActive fetches a token that could still be consumed at the given time.
+func (r *UserTokenRepository) GetActive(ctx context.Context, tokenHash string, purpose domain.TokenPurpose, at time.Time) (*domain.UserToken, error) {
+	const query = `
+		SELECT id, user_id, purpose, token_hash, expires_at, created_at, consumed_at
+		FROM user_tokens
+		WHERE token_hash = $1 AND purpose = $2 AND consumed_at IS NULL AND expires_at > $3`
+	return scanUserToken(r.db.QueryRowContext(ctx, query, tokenHash, purpose, at))
+}
+
+// Consume marks a valid token as used in a single statement so concurrent
+// requests cannot both redeem it.
+func (r *UserTokenRepository) Consume(ctx context.Context, tokenHash string, purpose domain.TokenPurpose, at time.Time) (*domain.UserToken, error) {
+	const query = `
+		UPDATE user_tokens
+		SET consumed_at = $1
+		WHERE token_hash = $2 AND purpose = $3 AND consumed_at IS NULL AND expires_at > $1
+		RETURNING id, user_id, purpose, token_hash, expires_at, created_at, consumed_at`
+	return scanUserToken(r.db.QueryRowContext(ctx, query, at, tokenHash, purpose))
+}
+
+// InvalidateByUser consumes every outstanding token of the purpose for the user.
+func (r *UserTokenRepository) InvalidateByUser(ctx context.Context, userID string, purpose domain.TokenPurpose, at time.Time) error {
+	const query = `
+		UPDATE user_tokens
+		SET consumed_at = $1
+		WHERE user_id = $2 AND purpose = $3 AND consumed_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, at, userID, purpose)
+	return err
+}
+
+// LastIssuedAt returns the creation time of the user's newest token of the purpose.
+func (r *UserTokenRepository) LastIssuedAt(ctx context.Context, userID string, purpose domain.TokenPurpose) (time.Time, error) {
+	const query = `
+		SELECT MAX(created_at)
+		FROM user_tokens
+		WHERE user_id = $1 AND purpose = $2`
+	var last sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query, userID, purpose).Scan(&last); err != nil {
+		return time.Time{}, err
+	}
+	if !last.Valid {
+		return time.Time{}, domain.ErrNotFound
+	}
+	return last.Time, nil
+}
+
+func scanUserToken(row rowScanner) (*domain.UserToken, error) {
+	token := &domain.UserToken{}
+	var consumedAt sql.NullTime
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Purpose,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&consumedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	token.ConsumedAt = nullTimePtr(consumedAt)
+	return token, nil
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"go-todo-service/internal/domain"
+)
+
+// ProjectRepository defines persistence operations for projects.
+type ProjectRepository interface {
+	// Create inserts a project. Creating a second inbox for a user returns
+	// domain.ErrConflict.
+	Create(ctx context.Context, project *domain.Project) error
+	GetByID(ctx context.Context, id string) (*domain.Project, error)
+	// GetInbox returns the user's inbox project.
+	GetInbox(ctx context.Context, userID string) (*domain.Project, error)
+	// ListByUser returns the user's projects by sort order, then creation
+	// time. Archived projects are skipped unless includeArchived is set.
+	ListByUser(ctx context.Context, userID string, includeArchived bool) ([]domain.Project, error)
+	Update(ctx context.Context, project *domain.Project) error
+	// Delete removes a project in one transaction together with its tasks,
+	// or after moving its tasks to the project moveTasksTo when that is set.
+	Delete(ctx context.Context, id, moveTasksTo string) error
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-todo-service/internal/domain"
+)
+
+// RefreshTokenRepository defines persistence operations for refresh tokens.
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *domain.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error)
+	// MarkRotated flags an active token as used. It returns domain.ErrConflict
+	// when the token was already rotated or revoked.
+	MarkRotated(ctx context.Context, id string, at time.Time) error
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
+	RevokeByUser(ctx context.Context, userID string, at time.Time) error
+	// RevokeOtherFamilies revokes the user's active tokens outside keepFamilyID.
+	RevokeOtherFamilies(ctx context.Context, userID, keepFamilyID string, at time.Time) error
+}
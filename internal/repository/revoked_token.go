@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// RevokedTokenRepository records access tokens invalidated before their expiry.
+type RevokedTokenRepository interface {
+	// Revoke denylists the token id until expiresAt, after which it may be forgotten.
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+	// Claim denylists the token id like Revoke in one atomic step and
+	// reports whether this call added it, so that of several requests
+	// spending the same single-use token only one gets true.
+	Claim(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error)
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-todo-service/internal/domain"
+)
+
+// SessionRepository defines persistence operations for login sessions.
+type SessionRepository interface {
+	Create(ctx context.Context, session *domain.Session) error
+	GetByID(ctx context.Context, id string) (*domain.Session, error)
+	// ListActiveByUser returns sessions neither revoked nor expired at now,
+	// most recently seen first.
+	ListActiveByUser(ctx context.Context, userID string, now time.Time) ([]domain.Session, error)
+	// Touch records activity on an active session.
+	Touch(ctx context.Context, id string, at time.Time) error
+	// Renew records activity and moves the expiry of an active session.
+	Renew(ctx context.Context, id string, at, expiresAt time.Time) error
+	// Revoke ends an active session of the user. It returns domain.ErrNotFound
+	// when no such session exists.
+	Revoke(ctx context.Context, userID, id string, at time.Time) error
+	RevokeByUser(ctx context.Context, userID string, at time.Time) error
+	// RevokeOthers revokes the user's active sessions except keepID.
+	RevokeOthers(ctx context.Context, userID, keepID string, at time.Time) error
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+
+	"go-todo-service/internal/domain"
+)
+
+// TagRepository defines persistence operations for tags and their
+// assignment to tasks.
+type TagRepository interface {
+	// Create inserts a tag. It returns domain.ErrConflict when the user
+	// already has a tag of that name, ignoring case.
+	Create(ctx context.Context, tag *domain.Tag) error
+	GetByID(ctx context.Context, id string) (*domain.Tag, error)
+	// ListByUser returns the user's tags ordered by name.
+	ListByUser(ctx context.Context, userID string) ([]domain.Tag, error)
+	// GetByNames returns the user's tags whose names match, ignoring case.
+	// Unknown names are skipped.
+	GetByNames(ctx context.Context, userID string, names []string) ([]domain.Tag, error)
+	// Update renames or recolours a tag, returning domain.ErrConflict like Create.
+	Update(ctx context.Context, tag *domain.Tag) error
+	// Delete removes a tag and detaches it from every task.
+	Delete(ctx context.Context, id string) error
+	// SetTaskTags replaces the tags attached to a task.
+	SetTaskTags(ctx context.Context, taskID string, tagIDs []string) error
+	// ListByTasks returns the tags of each task ordered by name, keyed by task id.
+	ListByTasks(ctx context.Context, taskIDs []string) (map[string][]domain.Tag, error)
+}
@@ -2,15 +2,122 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"go-todo-service/internal/domain"
 )
 
 // TaskRepository defines persistence operations for Task entities.
+//
+// Create, Update and CompleteOccurrence re-check the placement of a subtask
+// in the same transaction as the write and return
+// domain.ErrConcurrentUpdate when a concurrent change has since made it
+// nest the task under itself or deeper than domain.MaxTaskDepth levels.
 type TaskRepository interface {
 	Create(ctx context.Context, task *domain.Task) error
-	ListByUser(ctx context.Context, userID string) ([]domain.Task, error)
+	ListByUser(ctx context.Context, userID string, opts TaskListOptions) ([]domain.Task, error)
 	GetByID(ctx context.Context, id string) (*domain.Task, error)
 	Update(ctx context.Context, task *domain.Task) error
+	// CompleteOccurrence saves done, a recurring task just completed, and
+	// creates next, its following occurrence with the same tags, in one
+	// transaction.
+	CompleteOccurrence(ctx context.Context, done, next *domain.Task) error
+	// Delete removes a task together with all of its subtasks.
 	Delete(ctx context.Context, id string) error
+	// ListAncestors returns the chain of parents above a task, in no
+	// particular order.
+	ListAncestors(ctx context.Context, id string) ([]domain.Task, error)
+	// ListDescendants returns every task below the given tasks, oldest first.
+	ListDescendants(ctx context.Context, ids []string) ([]domain.Task, error)
+	// CountSubtasks summarises the direct subtasks of each task, keyed by
+	// task id. Tasks without subtasks are omitted.
+	CountSubtasks(ctx context.Context, ids []string) (map[string]domain.SubtaskProgress, error)
+}
+
+// TaskListOptions controls filtering, ordering and keyset pagination for ListByUser.
+type TaskListOptions struct {
+	Filter TaskFilter
+	Sort   TaskSort
+	// After, when set, returns only tasks positioned after the cursor in Sort order.
+	After *TaskCursor
+	// Limit caps the number of rows returned; zero means no limit.
+	Limit int
+}
+
+// TaskFilter narrows the tasks returned by ListByUser. Zero values apply no restriction.
+type TaskFilter struct {
+	// ProjectID keeps tasks filed under the project.
+	ProjectID string
+	// ParentID keeps the direct subtasks of a task; RootsOnly keeps tasks
+	// that are not subtasks.
+	ParentID  string
+	RootsOnly bool
+	Status    *domain.TaskStatus
+	// Priorities keeps tasks with any of the listed priorities.
+	Priorities []domain.TaskPriority
+	// Query matches case-insensitively against title and description.
+	Query string
+	// DueBefore keeps tasks due strictly before the instant.
+	DueBefore *time.Time
+	// DueAfter keeps tasks due at or after the instant.
+	DueAfter *time.Time
+	// Overdue keeps pending tasks whose due date has passed.
+	Overdue *OverdueCutoff
+	// DueWithin keeps tasks due inside a span of calendar days.
+	DueWithin *DueWindow
+	// Tags keeps tasks carrying any of the named tags, or all of them when
+	// MatchAllTags is set. Names are lower case and distinct.
+	Tags         []string
+	MatchAllTags bool
+}
+
+// OverdueCutoff holds the reference points used to decide whether a task is overdue.
+type OverdueCutoff struct {
+	// Now is compared against timed tasks.
+	Now time.Time
+	// Today is midnight UTC of the user's current calendar date and is compared against all-day tasks.
+	Today time.Time
+}
+
+// DueWindow spans whole calendar days in the user's time zone. Timed tasks
+// are matched by instant and all-day tasks by date, so both kinds land on the
+// day the user sees them.
+type DueWindow struct {
+	// Start and End bound timed tasks as [Start, End).
+	Start time.Time
+	End   time.Time
+	// StartDate and EndDate bound all-day tasks as [StartDate, EndDate),
+	// expressed as midnight UTC like stored all-day due dates.
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// TaskSortField names a column tasks can be ordered by.
+type TaskSortField string
+
+const (
+	TaskSortCreatedAt TaskSortField = "created_at"
+	TaskSortUpdatedAt TaskSortField = "updated_at"
+	TaskSortTitle     TaskSortField = "title"
+	// TaskSortPriority orders by priority, then by due date with the soonest
+	// first and undated tasks last whatever the direction.
+	TaskSortPriority TaskSortField = "priority"
+)
+
+// TaskSort describes the ordering of a task listing. Ties are broken by task id
+// in the same direction so the ordering is total.
+type TaskSort struct {
+	Field      TaskSortField
+	Descending bool
+}
+
+// TaskCursor identifies the last task of a previous page. Only the value of the
+// active sort field and the id are consulted.
+type TaskCursor struct {
+	ID        string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Title     string
+	Priority  domain.TaskPriority
+	DueAt     *time.Time
 }
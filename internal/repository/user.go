@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"go-todo-service/internal/domain"
 )
@@ -11,4 +12,27 @@ type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) error
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
 	GetByID(ctx context.Context, id string) (*domain.User, error)
+	// UpdatePassword replaces the hash and clears PasswordResetRequired.
+	UpdatePassword(ctx context.Context, id, passwordHash string, at time.Time) error
+	MarkEmailVerified(ctx context.Context, id string, at time.Time) error
+	// Update persists the email, verification state, profile fields, role and
+	// access state, provided the stored UpdatedAt still equals
+	// unmodifiedSince, the value the caller read. It returns
+	// domain.ErrConcurrentUpdate when the user changed in between and
+	// domain.ErrConflict if the email belongs to another user.
+	Update(ctx context.Context, user *domain.User, unmodifiedSince time.Time) error
+	// Delete removes the user; their tasks and tokens are deleted with them.
+	Delete(ctx context.Context, id string) error
+	// List returns users ordered by email.
+	List(ctx context.Context, opts UserListOptions) ([]domain.User, error)
+}
+
+// UserListOptions filters and paginates List.
+type UserListOptions struct {
+	// Query matches case-insensitively against email and display name.
+	Query string
+	// AfterEmail, when set, returns only users whose email sorts after it.
+	AfterEmail string
+	// Limit caps the number of rows returned; zero means no limit.
+	Limit int
 }
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-todo-service/internal/domain"
+)
+
+// UserTokenRepository defines persistence operations for single-use user tokens.
+type UserTokenRepository interface {
+	Create(ctx context.Context, token *domain.UserToken) error
+	// GetActive returns an unexpired, unconsumed token without using it, or
+	// domain.ErrNotFound.
+	GetActive(ctx context.Context, tokenHash string, purpose domain.TokenPurpose, at time.Time) (*domain.UserToken, error)
+	// Consume atomically marks an unexpired, unconsumed token as used and
+	// returns it, or returns domain.ErrNotFound.
+	Consume(ctx context.Context, tokenHash string, purpose domain.TokenPurpose, at time.Time) (*domain.UserToken, error)
+	// InvalidateByUser consumes every outstanding token of the purpose for the user.
+	InvalidateByUser(ctx context.Context, userID string, purpose domain.TokenPurpose, at time.Time) error
+	// LastIssuedAt returns when the newest token of the purpose was created
+	// for the user, or domain.ErrNotFound if none exists.
+	LastIssuedAt(ctx context.Context, userID string, purpose domain.TokenPurpose) (time.Time, error)
+}
@@ -0,0 +1,304 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/pkg/jwt"
+)
+
+const (
+	// maxDisplayNameLength bounds display names in characters.
+	maxDisplayNameLength = 100
+	// ReauthWindow is how recently an account without a password must have
+	// signed in, in the session making the request, to confirm account
+	// changes.
+	ReauthWindow = 10 * time.Minute
+)
+
+var (
+	// ErrIncorrectPassword indicates the current password given to confirm an account change is wrong.
+	ErrIncorrectPassword = errors.New("current password is incorrect")
+	// ErrReauthenticationRequired indicates an account change by an account
+	// without a password from a session that did not sign in recently.
+	ErrReauthenticationRequired = errors.New("sign in again to confirm this change")
+	// ErrInvalidDisplayName indicates a display name that is too long or contains control characters.
+	ErrInvalidDisplayName = errors.New("display name must be at most 100 characters without control characters")
+	// ErrInvalidTimeZone indicates a time zone that is not a known IANA zone name.
+	ErrInvalidTimeZone = errors.New("time zone must be an IANA zone name such as Europe/Berlin")
+	// ErrInvalidLocale indicates a locale that is not a language tag such as en or pt-BR.
+	ErrInvalidLocale = errors.New("locale must be a language tag such as en or pt-BR")
+	// ErrEmailUnchanged indicates a change request for the address already on the account.
+	ErrEmailUnchanged = errors.New("new email matches the current email")
+)
+
+// Account is a user together with security settings shown on their profile.
+type Account struct {
+	*domain.User
+	MFAEnabled bool
+}
+
+// ProfileUpdate carries optional profile changes; nil fields are kept.
+type ProfileUpdate struct {
+	DisplayName *string
+	TimeZone    *string
+	Locale      *string
+}
+
+// GetAccount returns the user's profile.
+func (s *Service) GetAccount(ctx context.Context, userID string) (*Account, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	mfaEnabled, err := s.mfaRequired(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	user.PasswordHash = ""
+	return &Account{User: user, MFAEnabled: mfaEnabled}, nil
+}
+
+// UpdateProfile applies profile changes and returns the updated account.
+func (s *Service) UpdateProfile(ctx context.Context, userID string, update ProfileUpdate) (*Account, error) {
+	var name, zone, locale string
+	if update.DisplayName != nil {
+		name = strings.TrimSpace(*update.DisplayName)
+		if utf8.RuneCountInString(name) > maxDisplayNameLength || strings.ContainsFunc(name, isControl) {
+			return nil, ErrInvalidDisplayName
+		}
+	}
+	if update.TimeZone != nil {
+		var err error
+		if zone, err = parseTimeZone(*update.TimeZone); err != nil {
+			return nil, err
+		}
+	}
+	if update.Locale != nil {
+		var err error
+		if locale, err = parseLocale(*update.Locale); err != nil {
+			return nil, err
+		}
+	}
+	_, err := s.updateUser(ctx, userID, func(user *domain.User) {
+		if update.DisplayName != nil {
+			user.DisplayName = name
+		}
+		if update.TimeZone != nil {
+			user.TimeZone = zone
+		}
+		if update.Locale != nil {
+			user.Locale = locale
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAccount(ctx, userID)
+}
+
+// ChangePassword replaces the password after confirmIdentity and ends every
+// other session. The session identified by claims stays signed in. Accounts
+// without a password set their first one this way.
+func (s *Service) ChangePassword(ctx context.Context, claims *jwt.Claims, currentPassword, newPassword string) error {
+	user, err := s.confirmIdentity(ctx, claims, currentPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.policy.Check(newPassword, user.Email); err != nil {
+		return err
+	}
+	hashed, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	if err := s.users.UpdatePassword(ctx, user.ID, hashed, now); err != nil {
+		return err
+	}
+	if s.userTokens != nil {
+		if err := s.userTokens.InvalidateByUser(ctx, user.ID, domain.TokenPurposePasswordReset, now); err != nil {
+			return err
+		}
+	}
+	if claims.SessionID == "" {
+		return s.revokeSessions(ctx, user.ID)
+	}
+	return s.revokeOtherSessions(ctx, user.ID, claims.SessionID)
+}
+
+// ChangeEmail moves the account to a new address after confirmIdentity.
+// The new address starts unverified and receives a verification link.
+func (s *Service) ChangeEmail(ctx context.Context, claims *jwt.Claims, currentPassword, newEmail string) (*Account, error) {
+	newEmail = strings.TrimSpace(strings.ToLower(newEmail))
+	if newEmail == "" || !strings.Contains(newEmail, "@") {
+		return nil, ErrInvalidEmail
+	}
+	user, err := s.confirmIdentity(ctx, claims, currentPassword)
+	if err != nil {
+		return nil, err
+	}
+	userID := user.ID
+	if newEmail == user.Email {
+		return nil, ErrEmailUnchanged
+	}
+
+	now := s.now().UTC()
+	read := user.UpdatedAt
+	user.Email = newEmail
+	user.EmailVerifiedAt = nil
+	user.UpdatedAt = now
+	// A concurrent write may have changed what the password confirmed, so
+	// it is reported rather than retried.
+	if err := s.users.Update(ctx, user, read); err != nil {
+		return nil, err
+	}
+	if s.userTokens != nil {
+		if err := s.userTokens.InvalidateByUser(ctx, userID, domain.TokenPurposeEmailVerification, now); err != nil {
+			return nil, err
+		}
+		if err := s.userTokens.InvalidateByUser(ctx, userID, domain.TokenPurposePasswordReset, now); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.SendVerificationEmail(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.GetAccount(ctx, userID)
+}
+
+// DeleteAccount permanently removes the user and everything they own after
+// confirmIdentity. The presented access token is revoked as well.
+func (s *Service) DeleteAccount(ctx context.Context, claims *jwt.Claims, currentPassword string) error {
+	user, err := s.confirmIdentity(ctx, claims, currentPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.users.Delete(ctx, user.ID); err != nil {
+		return err
+	}
+	s.status.forget(user.ID)
+	if s.revokedTokens != nil && claims.ID != "" {
+		return s.revokedTokens.Revoke(ctx, claims.ID, time.Unix(claims.ExpiresAt, 0).UTC())
+	}
+	return nil
+}
+
+// confirmIdentity loads the user and checks that they are making the
+// request: through their current password or, for accounts without one such
+// as those created through single sign-on, by the session of claims having
+// signed in within ReauthWindow. Such users sign in again, through their
+// provider or a magic link, to confirm a change.
+func (s *Service) confirmIdentity(ctx context.Context, claims *jwt.Claims, plainPassword string) (*domain.User, error) {
+	user, err := s.users.GetByID(ctx, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if user.PasswordHash == "" {
+		if err := s.checkRecentSignIn(ctx, user.ID, claims.SessionID); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+	if plainPassword == "" {
+		return nil, ErrIncorrectPassword
+	}
+	ok, err := s.checkPassword(user, plainPassword)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrIncorrectPassword
+	}
+	return user, nil
+}
+
+// checkRecentSignIn returns ErrReauthenticationRequired unless the session
+// is the user's, still active and started within ReauthWindow.
+func (s *Service) checkRecentSignIn(ctx context.Context, userID, sessionID string) error {
+	if s.sessions == nil || sessionID == "" {
+		return ErrReauthenticationRequired
+	}
+	session, err := s.sessions.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrReauthenticationRequired
+		}
+		return err
+	}
+	if session.UserID != userID || session.RevokedAt != nil || s.now().Sub(session.CreatedAt) > ReauthWindow {
+		return ErrReauthenticationRequired
+	}
+	return nil
+}
+
+// parseTimeZone accepts IANA zone names known to the host's time zone
+// database. "Local" is refused since it depends on the server.
+func parseTimeZone(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "Local" {
+		return "", ErrInvalidTimeZone
+	}
+	loc, err := time.LoadLocation(value)
+	if err != nil {
+		return "", ErrInvalidTimeZone
+	}
+	return loc.String(), nil
+}
+
+// parseLocale accepts a BCP 47 language tag made of a language, an optional
+// script and an optional region, and returns it in canonical case
+// ("zh-hant-tw" becomes "zh-Hant-TW").
+func parseLocale(value string) (string, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if !isAlpha(parts[0], 2, 3) {
+		return "", ErrInvalidLocale
+	}
+	canonical := []string{strings.ToLower(parts[0])}
+	rest := parts[1:]
+	if len(rest) > 0 && isAlpha(rest[0], 4, 4) {
+		canonical = append(canonical, strings.ToUpper(rest[0][:1])+strings.ToLower(rest[0][1:]))
+		rest = rest[1:]
+	}
+	if len(rest) > 0 && (isAlpha(rest[0], 2, 2) || isDigits(rest[0], 3)) {
+		canonical = append(canonical, strings.ToUpper(rest[0]))
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		return "", ErrInvalidLocale
+	}
+	return strings.Join(canonical, "-"), nil
+}
+
+func isAlpha(s string, minLen, maxLen int) bool {
+	if len(s) < minLen || len(s) > maxLen {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
@@ -0,0 +1,207 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository/memory"
+	authsvc "go-todo-service/internal/service/auth"
+	"go-todo-service/pkg/jwt"
+	"go-todo-service/pkg/password"
+)
+
+func TestChangePasswordRevokesOtherSessions(t *testing.T) {
+	service, _, keys := newLoginFixture(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	current, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	other, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("second login failed: %v", err)
+	}
+	claims, err := jwt.ParseAndValidate(current.AccessToken, keys, now)
+	if err != nil {
+		t.Fatalf("token invalid: %v", err)
+	}
+	if claims.SessionID == "" {
+		t.Fatal("expected sid claim")
+	}
+
+	if err := service.ChangePassword(ctx, claims, "wrong", "new-password"); !errors.Is(err, authsvc.ErrIncorrectPassword) {
+		t.Fatalf("expected ErrIncorrectPassword, got %v", err)
+	}
+	var policyErr *password.PolicyError
+	if err := service.ChangePassword(ctx, claims, "password", "short"); !errors.As(err, &policyErr) {
+		t.Fatalf("expected policy error, got %v", err)
+	}
+
+	if err := service.ChangePassword(ctx, claims, "password", "new-password"); err != nil {
+		t.Fatalf("change password failed: %v", err)
+	}
+	if _, err := service.Login(ctx, "user@example.com", "new-password", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("expected login with new password, got %v", err)
+	}
+	if _, err := service.Refresh(ctx, other.RefreshToken); err != authsvc.ErrInvalidRefreshToken {
+		t.Fatalf("expected other session to be revoked, got %v", err)
+	}
+	if _, err := service.Refresh(ctx, current.RefreshToken); err != nil {
+		t.Fatalf("expected current session to survive, got %v", err)
+	}
+}
+
+func TestPasswordlessAccountConfirmsBySigningInAgain(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := newFakeUserRepo()
+	repo.users["sso@example.com"] = &domain.User{ID: "sso", Email: "sso@example.com"}
+	sessions := newFakeSessionRepo()
+	sessions.sessions["fresh"] = &domain.Session{ID: "fresh", UserID: "sso", CreatedAt: now.Add(-time.Minute)}
+	sessions.sessions["stale"] = &domain.Session{ID: "stale", UserID: "sso", CreatedAt: now.Add(-time.Hour)}
+	service := authsvc.New(repo, newTestKeySet(t), 15*time.Minute)
+	service.WithSessions(sessions)
+	service.WithNow(func() time.Time { return now })
+
+	stale := &jwt.Claims{Subject: "sso", SessionID: "stale"}
+	if err := service.ChangePassword(ctx, stale, "", "new-password"); !errors.Is(err, authsvc.ErrReauthenticationRequired) {
+		t.Fatalf("expected ErrReauthenticationRequired, got %v", err)
+	}
+	if err := service.DeleteAccount(ctx, &jwt.Claims{Subject: "sso"}, ""); !errors.Is(err, authsvc.ErrReauthenticationRequired) {
+		t.Fatalf("expected ErrReauthenticationRequired without a session, got %v", err)
+	}
+
+	fresh := &jwt.Claims{Subject: "sso", SessionID: "fresh"}
+	if err := service.ChangePassword(ctx, fresh, "", "new-password"); err != nil {
+		t.Fatalf("set password failed: %v", err)
+	}
+	if err := service.ChangePassword(ctx, fresh, "", "other-password"); !errors.Is(err, authsvc.ErrIncorrectPassword) {
+		t.Fatalf("expected the new password to be required, got %v", err)
+	}
+}
+
+func TestChangeEmailRequiresReverification(t *testing.T) {
+	ctx := context.Background()
+	verifiedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := newFakeUserRepo()
+	hashed, _ := password.Hash("password")
+	repo.users["user@example.com"] = &domain.User{
+		ID:              "abc",
+		Email:           "user@example.com",
+		PasswordHash:    hashed,
+		EmailVerifiedAt: &verifiedAt,
+	}
+	repo.users["taken@example.com"] = &domain.User{ID: "def", Email: "taken@example.com"}
+	mail := &fakeMailer{}
+	service := authsvc.New(repo, newTestKeySet(t), 15*time.Minute)
+	service.WithUserTokens(newFakeUserTokenRepo())
+	service.WithMailer(mail, "https://app.example.com")
+	claims := &jwt.Claims{Subject: "abc"}
+
+	if _, err := service.ChangeEmail(ctx, claims, "password", "taken@example.com"); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+	if _, err := service.ChangeEmail(ctx, claims, "wrong", "new@example.com"); !errors.Is(err, authsvc.ErrIncorrectPassword) {
+		t.Fatalf("expected ErrIncorrectPassword, got %v", err)
+	}
+
+	account, err := service.ChangeEmail(ctx, claims, "password", " New@Example.com ")
+	if err != nil {
+		t.Fatalf("change email failed: %v", err)
+	}
+	if account.Email != "new@example.com" || account.EmailVerifiedAt != nil {
+		t.Fatalf("expected unverified new address, got %+v", account.User)
+	}
+	if len(mail.sent) != 1 || mail.sent[0].To != "new@example.com" || !strings.Contains(mail.sent[0].Body, "/verify-email?token=") {
+		t.Fatalf("expected verification email to new address, got %+v", mail.sent)
+	}
+	if err := service.VerifyEmail(ctx, tokenFromLink(t, mail.sent[0])); err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if _, err := service.Login(ctx, "new@example.com", "password", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("expected login with new email, got %v", err)
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	service, _, _ := newLoginFixture(t)
+	ctx := context.Background()
+
+	name := "  Jane Doe "
+	account, err := service.UpdateProfile(ctx, "abc", authsvc.ProfileUpdate{DisplayName: &name})
+	if err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if account.DisplayName != "Jane Doe" || account.PasswordHash != "" {
+		t.Fatalf("unexpected account %+v", account.User)
+	}
+
+	long := strings.Repeat("x", 101)
+	if _, err := service.UpdateProfile(ctx, "abc", authsvc.ProfileUpdate{DisplayName: &long}); !errors.Is(err, authsvc.ErrInvalidDisplayName) {
+		t.Fatalf("expected ErrInvalidDisplayName, got %v", err)
+	}
+}
+
+func TestUpdateProfileTimeZoneAndLocale(t *testing.T) {
+	service, _, _ := newLoginFixture(t)
+	ctx := context.Background()
+
+	zone, locale := "America/Sao_Paulo", "pt-br"
+	account, err := service.UpdateProfile(ctx, "abc", authsvc.ProfileUpdate{TimeZone: &zone, Locale: &locale})
+	if err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if account.TimeZone != "America/Sao_Paulo" || account.Locale != "pt-BR" {
+		t.Fatalf("unexpected profile %+v", account.User)
+	}
+
+	for _, bad := range []string{"", "Local", "Mars/Olympus_Mons", "+02:00"} {
+		if _, err := service.UpdateProfile(ctx, "abc", authsvc.ProfileUpdate{TimeZone: &bad}); !errors.Is(err, authsvc.ErrInvalidTimeZone) {
+			t.Fatalf("time zone %q: expected ErrInvalidTimeZone, got %v", bad, err)
+		}
+	}
+	for _, bad := range []string{"", "english", "en_US", "en-US-x-private"} {
+		if _, err := service.UpdateProfile(ctx, "abc", authsvc.ProfileUpdate{Locale: &bad}); !errors.Is(err, authsvc.ErrInvalidLocale) {
+			t.Fatalf("locale %q: expected ErrInvalidLocale, got %v", bad, err)
+		}
+	}
+	tag := "zh-hant-tw"
+	if account, err = service.UpdateProfile(ctx, "abc", authsvc.ProfileUpdate{Locale: &tag}); err != nil || account.Locale != "zh-Hant-TW" {
+		t.Fatalf("expected canonical zh-Hant-TW, got %v (%v)", account, err)
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	service, _, keys := newLoginFixture(t)
+	revoked := memory.NewRevokedTokenRepository()
+	service.WithRevokedTokens(revoked)
+	ctx := context.Background()
+
+	tokens, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	claims, err := jwt.ParseAndValidate(tokens.AccessToken, keys, time.Now())
+	if err != nil {
+		t.Fatalf("token invalid: %v", err)
+	}
+
+	if err := service.DeleteAccount(ctx, claims, "wrong"); !errors.Is(err, authsvc.ErrIncorrectPassword) {
+		t.Fatalf("expected ErrIncorrectPassword, got %v", err)
+	}
+	if err := service.DeleteAccount(ctx, claims, "password"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := service.GetAccount(ctx, "abc"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected account to be gone, got %v", err)
+	}
+	if ok, _ := revoked.IsRevoked(ctx, claims.ID); !ok {
+		t.Fatal("expected access token to be revoked")
+	}
+}
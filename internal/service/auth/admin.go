@@ -0,0 +1,278 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository"
+	"go-todo-service/internal/service/lockout"
+)
+
+const (
+	// DefaultUserPageSize is used when UserListOptions.Limit is zero.
+	DefaultUserPageSize = 50
+	// MaxUserPageSize bounds UserListOptions.Limit.
+	MaxUserPageSize = 100
+	// MaxDisableReasonLength bounds DisableOptions.Reason in characters.
+	MaxDisableReasonLength = 500
+
+	// maxUpdateAttempts bounds how often updateUser retries after a
+	// concurrent write.
+	maxUpdateAttempts = 3
+)
+
+var (
+	// ErrInvalidRole indicates a role outside the supported values.
+	ErrInvalidRole = errors.New("role must be one of user, admin")
+	// ErrCannotModifySelf stops administrators from disabling or demoting
+	// their own account and locking everyone out of /admin.
+	ErrCannotModifySelf = errors.New("administrators cannot disable or demote themselves")
+	// ErrInvalidUserCursor indicates a malformed user listing cursor.
+	ErrInvalidUserCursor = errors.New("invalid cursor")
+	// ErrInvalidUserLimit indicates a page size outside the supported range.
+	ErrInvalidUserLimit = errors.New("limit must be between 1 and 100")
+	// ErrInvalidLockoutKind indicates an unlock request for an unknown kind of key.
+	ErrInvalidLockoutKind = errors.New("kind must be one of account, ip, magic-link, password-reset")
+	// ErrInvalidDisableReason indicates an overlong or malformed reason.
+	ErrInvalidDisableReason = errors.New("reason must be at most 500 characters without control characters")
+	// ErrInvalidSuspension indicates a suspension that ends in the past.
+	ErrInvalidSuspension = errors.New("until must be in the future")
+	// ErrLockoutUnavailable indicates login lockout is not configured.
+	ErrLockoutUnavailable = errors.New("login lockout not configured")
+)
+
+// UserListOptions narrows and paginates ListUsers.
+type UserListOptions struct {
+	// Query matches against email and display name.
+	Query string
+	// Cursor is the NextCursor of a previous page.
+	Cursor string
+	// Limit is the page size; zero selects DefaultUserPageSize.
+	Limit int
+}
+
+// DisableOptions describes why and for how long an account is blocked.
+type DisableOptions struct {
+	// Reason is an administrator's note; it is not shown to the user.
+	Reason string
+	// Until, when set, turns the block into a suspension that lapses at
+	// that instant.
+	Until *time.Time
+}
+
+// UserPage is one page of a user listing, ordered by email.
+type UserPage struct {
+	Users []domain.User
+	// NextCursor is empty on the last page.
+	NextCursor string
+}
+
+// ListUsers returns a page of accounts for administrators. Password hashes
+// are cleared.
+func (s *Service) ListUsers(ctx context.Context, opts UserListOptions) (*UserPage, error) {
+	limit := opts.Limit
+	if limit == 0 {
+		limit = DefaultUserPageSize
+	}
+	if limit < 0 || limit > MaxUserPageSize {
+		return nil, ErrInvalidUserLimit
+	}
+	listOpts := repository.UserListOptions{
+		Query: strings.TrimSpace(opts.Query),
+		// Fetch one extra row to learn whether another page follows.
+		Limit: limit + 1,
+	}
+	if opts.Cursor != "" {
+		after, err := base64.RawURLEncoding.DecodeString(opts.Cursor)
+		if err != nil || len(after) == 0 {
+			return nil, ErrInvalidUserCursor
+		}
+		listOpts.AfterEmail = string(after)
+	}
+
+	users, err := s.users.List(ctx, listOpts)
+	if err != nil {
+		return nil, err
+	}
+	page := &UserPage{Users: users}
+	if len(users) > limit {
+		page.Users = users[:limit]
+		page.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(page.Users[limit-1].Email))
+	}
+	for i := range page.Users {
+		page.Users[i].PasswordHash = ""
+	}
+	return page, nil
+}
+
+// GetUser returns one account for administrators.
+func (s *Service) GetUser(ctx context.Context, userID string) (*domain.User, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	user.PasswordHash = ""
+	return user, nil
+}
+
+// SetUserRole changes a user's role. It applies to access tokens issued from
+// then on; demoting an administrator also ends their sessions, so access
+// tokens that still carry the admin role are refused at once.
+func (s *Service) SetUserRole(ctx context.Context, actorID, userID string, role domain.Role) (*domain.User, error) {
+	if !role.Valid() {
+		return nil, ErrInvalidRole
+	}
+	if actorID == userID && role != domain.RoleAdmin {
+		return nil, ErrCannotModifySelf
+	}
+	var demoted bool
+	user, err := s.updateUser(ctx, userID, func(user *domain.User) {
+		demoted = user.EffectiveRole() == domain.RoleAdmin && role != domain.RoleAdmin
+		user.Role = role
+	})
+	if err != nil {
+		return nil, err
+	}
+	if demoted {
+		if err := s.revokeSessions(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// DisableUser blocks the account from logging in, revokes its refresh
+// tokens and makes CheckAccountActive refuse its access tokens. Disabling an
+// already blocked account replaces the reason and end of the block.
+func (s *Service) DisableUser(ctx context.Context, actorID, userID string, opts DisableOptions) (*domain.User, error) {
+	if actorID == userID {
+		return nil, ErrCannotModifySelf
+	}
+	reason := strings.TrimSpace(opts.Reason)
+	if utf8.RuneCountInString(reason) > MaxDisableReasonLength || strings.ContainsFunc(reason, isControl) {
+		return nil, ErrInvalidDisableReason
+	}
+	now := s.now().UTC()
+	var until *time.Time
+	if opts.Until != nil {
+		if !opts.Until.After(now) {
+			return nil, ErrInvalidSuspension
+		}
+		end := opts.Until.UTC()
+		until = &end
+	}
+
+	user, err := s.updateUser(ctx, userID, func(user *domain.User) {
+		if !user.Disabled(now) {
+			user.DisabledAt = &now
+		}
+		user.DisabledReason = reason
+		user.DisabledUntil = until
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.status.forget(userID)
+	if err := s.revokeSessions(ctx, userID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// EnableUser lifts a block set by DisableUser.
+func (s *Service) EnableUser(ctx context.Context, userID string) (*domain.User, error) {
+	user, err := s.updateUser(ctx, userID, func(user *domain.User) {
+		user.DisabledAt = nil
+		user.DisabledReason = ""
+		user.DisabledUntil = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.status.forget(userID)
+	return user, nil
+}
+
+// ForcePasswordReset refuses further logins, by password, magic link or
+// single sign-on, until the user sets a new password through the reset flow.
+// It signs them out, revokes their personal access tokens and, when mail is
+// configured, emails them a reset link.
+func (s *Service) ForcePasswordReset(ctx context.Context, userID string) (*domain.User, error) {
+	user, err := s.updateUser(ctx, userID, func(user *domain.User) {
+		user.PasswordResetRequired = true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.revokeSessions(ctx, userID); err != nil {
+		return nil, err
+	}
+	if s.accessTokens != nil {
+		if err := s.accessTokens.RevokeByUser(ctx, userID, s.now().UTC()); err != nil {
+			return nil, err
+		}
+	}
+	if s.userTokens != nil && s.mailer != nil {
+		if err := s.sendPasswordReset(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// ListBlockedLogins returns accounts and IPs currently refused by the login
+// lockout.
+func (s *Service) ListBlockedLogins(ctx context.Context) ([]lockout.Entry, error) {
+	if s.lockout == nil {
+		return nil, ErrLockoutUnavailable
+	}
+	return s.lockout.ListBlocked(ctx)
+}
+
+// UnlockLogin clears the failure counter of an account or IP.
+func (s *Service) UnlockLogin(ctx context.Context, kind, subject string) error {
+	if s.lockout == nil {
+		return ErrLockoutUnavailable
+	}
+	switch kind {
+	case lockout.KindAccount:
+		return s.lockout.UnlockAccount(ctx, subject)
+	case lockout.KindIP:
+		return s.lockout.UnlockIP(ctx, subject)
+	case lockout.KindMagicLink:
+		return s.lockout.UnlockMagicLink(ctx, subject)
+	case lockout.KindPasswordReset:
+		return s.lockout.UnlockPasswordReset(ctx, subject)
+	default:
+		return ErrInvalidLockoutKind
+	}
+}
+
+// updateUser loads a user, applies change and saves the result. When
+// another write lands in between, the user is reloaded and change applied
+// again, so concurrent edits to different fields do not undo each other.
+func (s *Service) updateUser(ctx context.Context, userID string, change func(*domain.User)) (*domain.User, error) {
+	for attempt := 1; ; attempt++ {
+		user, err := s.users.GetByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		read := user.UpdatedAt
+		change(user)
+		user.UpdatedAt = s.now().UTC()
+		err = s.users.Update(ctx, user, read)
+		if errors.Is(err, domain.ErrConcurrentUpdate) && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordHash = ""
+		return user, nil
+	}
+}
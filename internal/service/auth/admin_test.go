@@ -0,0 +1,332 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-todo-service/internal/domain"
+	authsvc "go-todo-service/internal/service/auth"
+	"go-todo-service/pkg/jwt"
+	"go-todo-service/pkg/password"
+)
+
+func TestAccessTokenCarriesRole(t *testing.T) {
+	service, _, keys := newLoginFixture(t)
+	ctx := context.Background()
+
+	tokens, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	claims, err := jwt.ParseAndValidate(tokens.AccessToken, keys, time.Now())
+	if err != nil {
+		t.Fatalf("token invalid: %v", err)
+	}
+	if claims.Role != string(domain.RoleUser) {
+		t.Fatalf("expected user role for legacy account, got %q", claims.Role)
+	}
+
+	if _, err := service.SetUserRole(ctx, "admin-id", "abc", domain.RoleAdmin); err != nil {
+		t.Fatalf("set role failed: %v", err)
+	}
+	refreshed, err := service.Refresh(ctx, tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	claims, err = jwt.ParseAndValidate(refreshed.AccessToken, keys, time.Now())
+	if err != nil {
+		t.Fatalf("token invalid: %v", err)
+	}
+	if claims.Role != string(domain.RoleAdmin) {
+		t.Fatalf("expected admin role after refresh, got %q", claims.Role)
+	}
+
+	// Demotion ends the sessions whose tokens still say admin.
+	if _, err := service.SetUserRole(ctx, "admin-id", "abc", domain.RoleUser); err != nil {
+		t.Fatalf("set role failed: %v", err)
+	}
+	if _, err := service.Refresh(ctx, refreshed.RefreshToken); !errors.Is(err, authsvc.ErrInvalidRefreshToken) {
+		t.Fatalf("expected demotion to revoke refresh tokens, got %v", err)
+	}
+
+	if _, err := service.SetUserRole(ctx, "admin-id", "abc", "owner"); !errors.Is(err, authsvc.ErrInvalidRole) {
+		t.Fatalf("expected ErrInvalidRole, got %v", err)
+	}
+	if _, err := service.SetUserRole(ctx, "abc", "abc", domain.RoleUser); !errors.Is(err, authsvc.ErrCannotModifySelf) {
+		t.Fatalf("expected ErrCannotModifySelf, got %v", err)
+	}
+}
+
+func TestDisableUserBlocksLoginAndRefresh(t *testing.T) {
+	service, _, _ := newLoginFixture(t)
+	ctx := context.Background()
+
+	tokens, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if _, err := service.DisableUser(ctx, "abc", "abc", authsvc.DisableOptions{}); !errors.Is(err, authsvc.ErrCannotModifySelf) {
+		t.Fatalf("expected ErrCannotModifySelf, got %v", err)
+	}
+	user, err := service.DisableUser(ctx, "admin-id", "abc", authsvc.DisableOptions{Reason: "spam"})
+	if err != nil {
+		t.Fatalf("disable failed: %v", err)
+	}
+	if user.DisabledAt == nil || user.DisabledReason != "spam" || user.PasswordHash != "" {
+		t.Fatalf("unexpected user %+v", user)
+	}
+
+	if _, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{}); !errors.Is(err, authsvc.ErrAccountDisabled) {
+		t.Fatalf("expected ErrAccountDisabled, got %v", err)
+	}
+	if _, err := service.Login(ctx, "user@example.com", "wrong", authsvc.ClientInfo{}); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("expected wrong password to stay ErrInvalidCredentials, got %v", err)
+	}
+	if _, err := service.Refresh(ctx, tokens.RefreshToken); err != authsvc.ErrInvalidRefreshToken {
+		t.Fatalf("expected refresh token to be revoked, got %v", err)
+	}
+
+	if _, err := service.EnableUser(ctx, "abc"); err != nil {
+		t.Fatalf("enable failed: %v", err)
+	}
+	if _, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("expected login after enabling, got %v", err)
+	}
+}
+
+func TestSuspensionLapses(t *testing.T) {
+	service, _, _ := newLoginFixture(t)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return now })
+	ctx := context.Background()
+
+	past := now.Add(-time.Minute)
+	if _, err := service.DisableUser(ctx, "admin-id", "abc", authsvc.DisableOptions{Until: &past}); !errors.Is(err, authsvc.ErrInvalidSuspension) {
+		t.Fatalf("expected ErrInvalidSuspension, got %v", err)
+	}
+	until := now.Add(time.Hour)
+	if _, err := service.DisableUser(ctx, "admin-id", "abc", authsvc.DisableOptions{Until: &until}); err != nil {
+		t.Fatalf("suspend failed: %v", err)
+	}
+	if _, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{}); !errors.Is(err, authsvc.ErrAccountDisabled) {
+		t.Fatalf("expected ErrAccountDisabled during suspension, got %v", err)
+	}
+
+	now = until
+	if _, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("expected login once the suspension ended, got %v", err)
+	}
+}
+
+func TestCheckAccountActiveCachesStatus(t *testing.T) {
+	repo := newFakeUserRepo()
+	repo.users["user@example.com"] = &domain.User{ID: "abc", Email: "user@example.com"}
+	service := authsvc.New(repo, newTestKeySet(t), 15*time.Minute)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return now })
+	service.WithAccountStatusCache(30 * time.Second)
+	ctx := context.Background()
+
+	if err := service.CheckAccountActive(ctx, "abc"); err != nil {
+		t.Fatalf("expected active account, got %v", err)
+	}
+	// A change made elsewhere, e.g. by another instance, shows once the
+	// cached entry expires.
+	disabledAt := now
+	repo.users["user@example.com"].DisabledAt = &disabledAt
+	if err := service.CheckAccountActive(ctx, "abc"); err != nil {
+		t.Fatalf("expected cached status, got %v", err)
+	}
+	now = now.Add(30 * time.Second)
+	if err := service.CheckAccountActive(ctx, "abc"); !errors.Is(err, authsvc.ErrAccountDisabled) {
+		t.Fatalf("expected ErrAccountDisabled after expiry, got %v", err)
+	}
+
+	// Changes made through the service apply immediately.
+	if _, err := service.EnableUser(ctx, "abc"); err != nil {
+		t.Fatalf("enable failed: %v", err)
+	}
+	if err := service.CheckAccountActive(ctx, "abc"); err != nil {
+		t.Fatalf("expected active account after enabling, got %v", err)
+	}
+	if _, err := service.DisableUser(ctx, "admin-id", "abc", authsvc.DisableOptions{}); err != nil {
+		t.Fatalf("disable failed: %v", err)
+	}
+	if err := service.CheckAccountActive(ctx, "abc"); !errors.Is(err, authsvc.ErrAccountDisabled) {
+		t.Fatalf("expected ErrAccountDisabled after disabling, got %v", err)
+	}
+
+	if err := service.CheckAccountActive(ctx, "missing"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unknown user, got %v", err)
+	}
+}
+
+// racingUserRepo runs interleave once, between a read and the write that
+// follows it, like a request from another instance.
+type racingUserRepo struct {
+	*fakeUserRepo
+	interleave func()
+}
+
+func (r *racingUserRepo) Update(ctx context.Context, user *domain.User, unmodifiedSince time.Time) error {
+	if r.interleave != nil {
+		interleave := r.interleave
+		r.interleave = nil
+		interleave()
+	}
+	return r.fakeUserRepo.Update(ctx, user, unmodifiedSince)
+}
+
+func TestConcurrentUserUpdatesAreKept(t *testing.T) {
+	repo := &racingUserRepo{fakeUserRepo: newFakeUserRepo()}
+	hashed, _ := password.Hash("password")
+	repo.users["user@example.com"] = &domain.User{ID: "abc", Email: "user@example.com", PasswordHash: hashed, Locale: "en"}
+	service := authsvc.New(repo, newTestKeySet(t), 15*time.Minute)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return now })
+	ctx := context.Background()
+
+	repo.interleave = func() {
+		now = now.Add(time.Second)
+		locale := "de"
+		if _, err := service.UpdateProfile(ctx, "abc", authsvc.ProfileUpdate{Locale: &locale}); err != nil {
+			t.Fatalf("update profile failed: %v", err)
+		}
+	}
+	if _, err := service.SetUserRole(ctx, "admin-id", "abc", domain.RoleAdmin); err != nil {
+		t.Fatalf("set role failed: %v", err)
+	}
+	user := repo.users["user@example.com"]
+	if user.Role != domain.RoleAdmin || user.Locale != "de" {
+		t.Fatalf("expected both changes to be kept, got role %q and locale %q", user.Role, user.Locale)
+	}
+
+	repo.interleave = func() {
+		now = now.Add(time.Second)
+		if _, err := service.SetUserRole(ctx, "admin-id", "abc", domain.RoleUser); err != nil {
+			t.Fatalf("set role failed: %v", err)
+		}
+	}
+	if _, err := service.ChangeEmail(ctx, &jwt.Claims{Subject: "abc"}, "password", "new@example.com"); !errors.Is(err, domain.ErrConcurrentUpdate) {
+		t.Fatalf("expected ErrConcurrentUpdate, got %v", err)
+	}
+}
+
+// fakeAccessTokenRepo holds personal access tokens by id.
+type fakeAccessTokenRepo struct {
+	tokens map[string]*domain.PersonalAccessToken
+}
+
+func (r *fakeAccessTokenRepo) Create(ctx context.Context, token *domain.PersonalAccessToken) error {
+	t := *token
+	r.tokens[token.ID] = &t
+	return nil
+}
+
+func (r *fakeAccessTokenRepo) ListByUser(ctx context.Context, userID string) ([]domain.PersonalAccessToken, error) {
+	var out []domain.PersonalAccessToken
+	for _, token := range r.tokens {
+		if token.UserID == userID {
+			out = append(out, *token)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeAccessTokenRepo) GetByHash(ctx context.Context, tokenHash string) (*domain.PersonalAccessToken, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (r *fakeAccessTokenRepo) Revoke(ctx context.Context, userID, id string, at time.Time) error {
+	return domain.ErrNotFound
+}
+
+func (r *fakeAccessTokenRepo) RevokeByUser(ctx context.Context, userID string, at time.Time) error {
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *fakeAccessTokenRepo) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	return nil
+}
+
+func TestForcePasswordReset(t *testing.T) {
+	service, mail, _ := newResetFixture(t)
+	accessTokens := &fakeAccessTokenRepo{tokens: map[string]*domain.PersonalAccessToken{
+		"pat-1": {ID: "pat-1", UserID: "abc"},
+	}}
+	service.WithAccessTokens(accessTokens)
+	ctx := context.Background()
+
+	user, err := service.ForcePasswordReset(ctx, "abc")
+	if err != nil {
+		t.Fatalf("force reset failed: %v", err)
+	}
+	if !user.PasswordResetRequired {
+		t.Fatal("expected reset flag")
+	}
+	if _, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{}); !errors.Is(err, authsvc.ErrPasswordResetRequired) {
+		t.Fatalf("expected ErrPasswordResetRequired, got %v", err)
+	}
+	if accessTokens.tokens["pat-1"].RevokedAt == nil {
+		t.Fatal("expected personal access tokens to be revoked")
+	}
+	if len(mail.sent) != 1 {
+		t.Fatalf("expected reset email, got %d", len(mail.sent))
+	}
+	if err := service.RequestMagicLink(ctx, "user@example.com", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("magic link request failed: %v", err)
+	}
+	if _, err := service.ConsumeMagicLink(ctx, tokenFromLink(t, mail.sent[1]), authsvc.ClientInfo{}); !errors.Is(err, authsvc.ErrPasswordResetRequired) {
+		t.Fatalf("expected magic link sign-in to be refused, got %v", err)
+	}
+	if err := service.ResetPassword(ctx, tokenFromLink(t, mail.sent[0]), "brand-new-password"); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	if _, err := service.Login(ctx, "user@example.com", "brand-new-password", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("expected login after reset, got %v", err)
+	}
+}
+
+func TestListUsersPaginates(t *testing.T) {
+	repo := newFakeUserRepo()
+	for i := 0; i < 5; i++ {
+		email := fmt.Sprintf("user%d@example.com", i)
+		repo.users[email] = &domain.User{ID: fmt.Sprintf("id-%d", i), Email: email, PasswordHash: "hash"}
+	}
+	service := authsvc.New(repo, newTestKeySet(t), 15*time.Minute)
+	ctx := context.Background()
+
+	var seen []string
+	opts := authsvc.UserListOptions{Limit: 2}
+	for {
+		page, err := service.ListUsers(ctx, opts)
+		if err != nil {
+			t.Fatalf("list failed: %v", err)
+		}
+		for _, user := range page.Users {
+			if user.PasswordHash != "" {
+				t.Fatal("expected password hash to be cleared")
+			}
+			seen = append(seen, user.Email)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+	if len(seen) != 5 || seen[0] != "user0@example.com" || seen[4] != "user4@example.com" {
+		t.Fatalf("unexpected listing %v", seen)
+	}
+
+	if _, err := service.ListUsers(ctx, authsvc.UserListOptions{Cursor: "***"}); !errors.Is(err, authsvc.ErrInvalidUserCursor) {
+		t.Fatalf("expected ErrInvalidUserCursor, got %v", err)
+	}
+}
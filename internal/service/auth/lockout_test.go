@@ -0,0 +1,98 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository/memory"
+	authsvc "go-todo-service/internal/service/auth"
+	"go-todo-service/internal/service/lockout"
+)
+
+func TestLoginLockout(t *testing.T) {
+	service, _, _ := newLoginFixture(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return now })
+	policy := lockout.DefaultPolicy()
+	policy.MaxAccountFailures = 3
+	policy.FreeAttempts = 3
+	guard := lockout.New(memory.NewLoginThrottleRepository(), policy)
+	guard.WithNow(func() time.Time { return now })
+	service.WithLockout(guard)
+	ctx := context.Background()
+	client := authsvc.ClientInfo{IP: "10.0.0.1"}
+
+	for i := 0; i < 3; i++ {
+		if _, err := service.Login(ctx, "user@example.com", "wrong", client); err != domain.ErrInvalidCredentials {
+			t.Fatalf("attempt %d: expected ErrInvalidCredentials, got %v", i+1, err)
+		}
+	}
+
+	_, err := service.Login(ctx, "user@example.com", "password", client)
+	var blocked *lockout.BlockedError
+	if !errors.As(err, &blocked) || !blocked.Locked || blocked.RetryAfter != policy.LockoutDuration {
+		t.Fatalf("expected account lockout even with the right password, got %v", err)
+	}
+
+	now = now.Add(policy.LockoutDuration)
+	if _, err := service.Login(ctx, "user@example.com", "password", client); err != nil {
+		t.Fatalf("expected login after lockout expiry, got %v", err)
+	}
+	if _, err := service.Login(ctx, "user@example.com", "wrong", client); err != domain.ErrInvalidCredentials {
+		t.Fatalf("expected success to reset the counter, got %v", err)
+	}
+}
+
+func TestLoginLockoutCountsUnknownEmails(t *testing.T) {
+	service, _, _ := newLoginFixture(t)
+	policy := lockout.DefaultPolicy()
+	policy.MaxAccountFailures = 2
+	service.WithLockout(lockout.New(memory.NewLoginThrottleRepository(), policy))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, _ = service.Login(ctx, "ghost@example.com", "guess", authsvc.ClientInfo{})
+	}
+	_, err := service.Login(ctx, "ghost@example.com", "guess", authsvc.ClientInfo{})
+	var blocked *lockout.BlockedError
+	if !errors.As(err, &blocked) || !blocked.Locked {
+		t.Fatalf("expected unknown email to lock like a real one, got %v", err)
+	}
+}
+
+func TestLoginKeepsCountingWrongMFACodes(t *testing.T) {
+	service, _, _ := newLoginFixture(t)
+	service.WithMFA(newFakeMFARepo(), "Todo")
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return now })
+	revoked := memory.NewRevokedTokenRepository()
+	revoked.WithNow(func() time.Time { return now })
+	service.WithRevokedTokens(revoked)
+	policy := lockout.DefaultPolicy()
+	policy.MaxAccountFailures = 3
+	policy.FreeAttempts = 3
+	guard := lockout.New(memory.NewLoginThrottleRepository(), policy)
+	guard.WithNow(func() time.Time { return now })
+	service.WithLockout(guard)
+	ctx := context.Background()
+	enableMFA(t, service, now)
+
+	for i := 0; i < 3; i++ {
+		challenge, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+		if err != nil {
+			t.Fatalf("attempt %d: login: %v", i+1, err)
+		}
+		if _, err := service.VerifyMFA(ctx, challenge.MFAToken, "000000", authsvc.ClientInfo{}); err != authsvc.ErrInvalidMFACode {
+			t.Fatalf("attempt %d: expected ErrInvalidMFACode, got %v", i+1, err)
+		}
+	}
+
+	_, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+	var blocked *lockout.BlockedError
+	if !errors.As(err, &blocked) || !blocked.Locked {
+		t.Fatalf("expected wrong codes to lock the account despite the right password, got %v", err)
+	}
+}
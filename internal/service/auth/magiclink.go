@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/pkg/mailer"
+	"go-todo-service/pkg/token"
+)
+
+const (
+	// magicLinkTTL bounds how long an emailed sign-in link stays valid.
+	magicLinkTTL = 15 * time.Minute
+	// magicLinkResendInterval is the minimum gap between links sent to one
+	// account; requests inside it are dropped silently.
+	magicLinkResendInterval = time.Minute
+)
+
+var (
+	// ErrInvalidMagicLink indicates an unknown, expired or already used sign-in link.
+	ErrInvalidMagicLink = errors.New("invalid or expired sign-in link")
+	// ErrMagicLinkUnavailable indicates mail or the token store is not configured.
+	ErrMagicLinkUnavailable = errors.New("magic link sign-in not configured")
+)
+
+// RequestMagicLink emails a single-use sign-in link when the address belongs
+// to an account. Unknown addresses are ignored silently so callers cannot
+// probe which emails are registered. Requests are subject to the same
+// lockout as Login and are rate limited per client IP, so a client cannot
+// flood inboxes. The rate limit is counted apart from failed logins, so
+// clients sharing an address are not blocked from signing in.
+func (s *Service) RequestMagicLink(ctx context.Context, email string, client ClientInfo) error {
+	if s.userTokens == nil || s.mailer == nil {
+		return ErrMagicLinkUnavailable
+	}
+	email = strings.TrimSpace(strings.ToLower(email))
+	if email == "" || !strings.Contains(email, "@") {
+		return ErrInvalidEmail
+	}
+	if s.lockout != nil {
+		if err := s.lockout.Check(ctx, email, client.IP); err != nil {
+			return err
+		}
+		if err := s.lockout.RecordMagicLinkRequest(ctx, client.IP); err != nil {
+			return err
+		}
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.Disabled(s.now()) {
+		return nil
+	}
+	last, err := s.userTokens.LastIssuedAt(ctx, user.ID, domain.TokenPurposeMagicLink)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+	if err == nil && s.now().Sub(last) < magicLinkResendInterval {
+		return nil
+	}
+
+	// Only the newest link works.
+	if err := s.userTokens.InvalidateByUser(ctx, user.ID, domain.TokenPurposeMagicLink, s.now().UTC()); err != nil {
+		return err
+	}
+	plain, err := s.issueUserToken(ctx, user.ID, domain.TokenPurposeMagicLink, magicLinkTTL)
+	if err != nil {
+		return err
+	}
+
+	link := s.linkBaseURL + "/magic-link?token=" + url.QueryEscape(plain)
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf("Someone asked to sign in to your account without a password.\n\n"+
+			"Use the link below within %d minutes to sign in. It works once:\n%s\n\n"+
+			"If this was not you, you can ignore this email.\n", int(magicLinkTTL.Minutes()), link),
+	})
+}
+
+// ConsumeMagicLink redeems a sign-in link and returns the same credentials as
+// Login, including an MFA challenge when two-factor authentication is
+// enabled. Redeeming a link proves control of the address, so an unverified
+// email is marked verified. Whoever registered the unverified account may not
+// own the address, so its password is removed and its sessions are ended
+// first; the owner can set a new password through password reset. Invalid
+// links count as failed attempts from the client IP.
+func (s *Service) ConsumeMagicLink(ctx context.Context, linkToken string, client ClientInfo) (*Tokens, error) {
+	if s.userTokens == nil {
+		return nil, ErrMagicLinkUnavailable
+	}
+	if s.lockout != nil {
+		if err := s.lockout.Check(ctx, "", client.IP); err != nil {
+			return nil, err
+		}
+	}
+	linkToken = strings.TrimSpace(linkToken)
+	if linkToken == "" {
+		return nil, s.magicLinkFailed(ctx, client)
+	}
+	now := s.now().UTC()
+	consumed, err := s.userTokens.Consume(ctx, token.Hash(linkToken), domain.TokenPurposeMagicLink, now)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, s.magicLinkFailed(ctx, client)
+		}
+		return nil, err
+	}
+
+	user, err := s.users.GetByID(ctx, consumed.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrInvalidMagicLink
+		}
+		return nil, err
+	}
+	if err := s.checkAccountActive(user); err != nil {
+		return nil, err
+	}
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
+	if user.EmailVerifiedAt == nil {
+		if err := s.claimUnverifiedAccount(ctx, user, now); err != nil {
+			return nil, err
+		}
+	}
+
+	required, err := s.mfaRequired(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if required {
+		// As with Login, VerifyMFA clears the account counter.
+		return s.issueMFAChallenge(user.ID)
+	}
+	if s.lockout != nil {
+		if err := s.lockout.RecordSuccess(ctx, user.Email); err != nil {
+			return nil, err
+		}
+	}
+	return s.issueTokens(ctx, user, "", client)
+}
+
+// claimUnverifiedAccount hands an unverified account to the owner of its
+// address: the password set at registration is removed, every session is
+// ended and the email is marked verified.
+func (s *Service) claimUnverifiedAccount(ctx context.Context, user *domain.User, now time.Time) error {
+	if user.PasswordHash != "" {
+		if err := s.users.UpdatePassword(ctx, user.ID, "", now); err != nil {
+			return err
+		}
+		user.PasswordHash = ""
+	}
+	if err := s.revokeSessions(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := s.users.MarkEmailVerified(ctx, user.ID, now); err != nil {
+		return err
+	}
+	user.EmailVerifiedAt = &now
+	return nil
+}
+
+// magicLinkFailed counts an invalid link against the client IP and returns
+// the error to report.
+func (s *Service) magicLinkFailed(ctx context.Context, client ClientInfo) error {
+	if s.lockout != nil {
+		if err := s.lockout.RecordFailure(ctx, "", client.IP); err != nil {
+			return err
+		}
+	}
+	return ErrInvalidMagicLink
+}
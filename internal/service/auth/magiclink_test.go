@@ -0,0 +1,215 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository/memory"
+	authsvc "go-todo-service/internal/service/auth"
+	"go-todo-service/internal/service/lockout"
+)
+
+func TestMagicLinkFlow(t *testing.T) {
+	service, mail, _ := newResetFixture(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return now })
+	ctx := context.Background()
+
+	if err := service.RequestMagicLink(ctx, "ghost@example.com", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("expected unknown email to be ignored, got %v", err)
+	}
+	if len(mail.sent) != 0 {
+		t.Fatalf("expected no email for unknown address, got %d", len(mail.sent))
+	}
+
+	// Signed in with the password chosen when the unverified account was
+	// registered, possibly by someone else.
+	earlier, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	if err := service.RequestMagicLink(ctx, "User@Example.com", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if len(mail.sent) != 1 || !strings.Contains(mail.sent[0].Body, "https://app.example.com/magic-link?token=") {
+		t.Fatalf("expected one sign-in email, got %+v", mail.sent)
+	}
+	// A second request within the resend interval sends nothing.
+	if err := service.RequestMagicLink(ctx, "user@example.com", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if len(mail.sent) != 1 {
+		t.Fatalf("expected resend to be throttled, got %d emails", len(mail.sent))
+	}
+
+	link := tokenFromLink(t, mail.sent[0])
+	tokens, err := service.ConsumeMagicLink(ctx, link, authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("consume failed: %v", err)
+	}
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatalf("expected access and refresh tokens, got %+v", tokens)
+	}
+	account, err := service.GetAccount(ctx, "abc")
+	if err != nil {
+		t.Fatalf("get account failed: %v", err)
+	}
+	if account.EmailVerifiedAt == nil {
+		t.Fatal("expected the link to verify the email")
+	}
+	if _, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{}); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("expected the registration password to be removed, got %v", err)
+	}
+	if _, err := service.Refresh(ctx, earlier.RefreshToken); !errors.Is(err, authsvc.ErrInvalidRefreshToken) {
+		t.Fatalf("expected earlier sessions to be ended, got %v", err)
+	}
+	if _, err := service.Refresh(ctx, tokens.RefreshToken); err != nil {
+		t.Fatalf("expected the new session to work: %v", err)
+	}
+	if _, err := service.ConsumeMagicLink(ctx, link, authsvc.ClientInfo{}); !errors.Is(err, authsvc.ErrInvalidMagicLink) {
+		t.Fatalf("expected a used link to be refused, got %v", err)
+	}
+}
+
+func TestMagicLinkExpiresAndOnlyNewestWorks(t *testing.T) {
+	service, mail, _ := newResetFixture(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return now })
+	ctx := context.Background()
+
+	if err := service.RequestMagicLink(ctx, "user@example.com", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	if err := service.RequestMagicLink(ctx, "user@example.com", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if len(mail.sent) != 2 {
+		t.Fatalf("expected two emails, got %d", len(mail.sent))
+	}
+	if _, err := service.ConsumeMagicLink(ctx, tokenFromLink(t, mail.sent[0]), authsvc.ClientInfo{}); !errors.Is(err, authsvc.ErrInvalidMagicLink) {
+		t.Fatalf("expected superseded link to be refused, got %v", err)
+	}
+
+	now = now.Add(15 * time.Minute)
+	if _, err := service.ConsumeMagicLink(ctx, tokenFromLink(t, mail.sent[1]), authsvc.ClientInfo{}); !errors.Is(err, authsvc.ErrInvalidMagicLink) {
+		t.Fatalf("expected expired link to be refused, got %v", err)
+	}
+}
+
+func TestMagicLinkSharesLoginLockout(t *testing.T) {
+	service, mail, _ := newResetFixture(t)
+	policy := lockout.DefaultPolicy()
+	policy.MaxAccountFailures = 2
+	policy.MaxIPFailures = 3
+	service.WithLockout(lockout.New(memory.NewLoginThrottleRepository(), policy))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, _ = service.Login(ctx, "user@example.com", "wrong", authsvc.ClientInfo{})
+	}
+	var blocked *lockout.BlockedError
+	if err := service.RequestMagicLink(ctx, "user@example.com", authsvc.ClientInfo{}); !errors.As(err, &blocked) || !blocked.Locked {
+		t.Fatalf("expected locked account to be refused a link, got %v", err)
+	}
+	if len(mail.sent) != 0 {
+		t.Fatalf("expected no email for a locked account, got %d", len(mail.sent))
+	}
+
+	client := authsvc.ClientInfo{IP: "10.0.0.9"}
+	for i := 0; i < 3; i++ {
+		if _, err := service.ConsumeMagicLink(ctx, "guess", client); !errors.Is(err, authsvc.ErrInvalidMagicLink) {
+			t.Fatalf("attempt %d: expected ErrInvalidMagicLink, got %v", i+1, err)
+		}
+	}
+	if _, err := service.ConsumeMagicLink(ctx, "guess", client); !errors.As(err, &blocked) {
+		t.Fatalf("expected guessing IP to be blocked, got %v", err)
+	}
+	if _, err := service.Login(ctx, "user@example.com", "password", client); !errors.As(err, &blocked) {
+		t.Fatalf("expected the IP block to apply to password logins, got %v", err)
+	}
+}
+
+func TestMagicLinkKeepsCountingWrongMFACodes(t *testing.T) {
+	service, mail, _ := newResetFixture(t)
+	service.WithMFA(newFakeMFARepo(), "Todo")
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return now })
+	revoked := memory.NewRevokedTokenRepository()
+	revoked.WithNow(func() time.Time { return now })
+	service.WithRevokedTokens(revoked)
+	policy := lockout.DefaultPolicy()
+	policy.MaxAccountFailures = 3
+	policy.FreeAttempts = 3
+	guard := lockout.New(memory.NewLoginThrottleRepository(), policy)
+	guard.WithNow(func() time.Time { return now })
+	service.WithLockout(guard)
+	ctx := context.Background()
+	enableMFA(t, service, now)
+
+	for i := 0; i < 3; i++ {
+		now = now.Add(time.Minute)
+		if err := service.RequestMagicLink(ctx, "user@example.com", authsvc.ClientInfo{}); err != nil {
+			t.Fatalf("attempt %d: request: %v", i+1, err)
+		}
+		challenge, err := service.ConsumeMagicLink(ctx, tokenFromLink(t, mail.sent[len(mail.sent)-1]), authsvc.ClientInfo{})
+		if err != nil {
+			t.Fatalf("attempt %d: consume: %v", i+1, err)
+		}
+		if _, err := service.VerifyMFA(ctx, challenge.MFAToken, "000000", authsvc.ClientInfo{}); err != authsvc.ErrInvalidMFACode {
+			t.Fatalf("attempt %d: expected ErrInvalidMFACode, got %v", i+1, err)
+		}
+	}
+
+	_, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+	var blocked *lockout.BlockedError
+	if !errors.As(err, &blocked) || !blocked.Locked {
+		t.Fatalf("expected redeemed links not to clear wrong codes, got %v", err)
+	}
+}
+
+func TestMagicLinkRequestsDoNotBlockLogin(t *testing.T) {
+	service, mail, _ := newResetFixture(t)
+	policy := lockout.DefaultPolicy()
+	policy.MaxIPFailures = 3
+	policy.MaxMagicLinkRequests = 5
+	service.WithLockout(lockout.New(memory.NewLoginThrottleRepository(), policy))
+	ctx := context.Background()
+
+	client := authsvc.ClientInfo{IP: "10.0.0.9"}
+	for i := 0; i < 5; i++ {
+		if err := service.RequestMagicLink(ctx, "someone@example.com", client); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	var blocked *lockout.BlockedError
+	if err := service.RequestMagicLink(ctx, "user@example.com", client); !errors.As(err, &blocked) {
+		t.Fatalf("expected requests from the IP to be rate limited, got %v", err)
+	}
+	if len(mail.sent) != 0 {
+		t.Fatalf("expected no email once rate limited, got %d", len(mail.sent))
+	}
+	if _, err := service.Login(ctx, "user@example.com", "password", client); err != nil {
+		t.Fatalf("expected password login from the same IP to work, got %v", err)
+	}
+}
+
+func TestMagicLinkRefusesDisabledAccount(t *testing.T) {
+	service, mail, _ := newResetFixture(t)
+	ctx := context.Background()
+
+	if err := service.RequestMagicLink(ctx, "user@example.com", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if _, err := service.DisableUser(ctx, "admin-id", "abc", authsvc.DisableOptions{}); err != nil {
+		t.Fatalf("disable failed: %v", err)
+	}
+	if _, err := service.ConsumeMagicLink(ctx, tokenFromLink(t, mail.sent[0]), authsvc.ClientInfo{}); !errors.Is(err, authsvc.ErrAccountDisabled) {
+		t.Fatalf("expected ErrAccountDisabled, got %v", err)
+	}
+}
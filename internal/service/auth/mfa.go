@@ -0,0 +1,318 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/pkg/jwt"
+	"go-todo-service/pkg/token"
+	"go-todo-service/pkg/totp"
+	"go-todo-service/pkg/uuid"
+)
+
+const (
+	// mfaChallengeTTL bounds the time between the password step and the code step of a login.
+	mfaChallengeTTL = 5 * time.Minute
+	// mfaChallengePurpose marks challenge JWTs so they cannot be used as access tokens.
+	mfaChallengePurpose = "mfa_challenge"
+	// totpSkew accepts codes from one step either side to tolerate clock drift.
+	totpSkew           = 1
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+var (
+	// ErrMFANotConfigured indicates the MFA or token revocation store is not configured.
+	ErrMFANotConfigured = errors.New("two-factor authentication not configured")
+	// ErrMFAAlreadyEnabled indicates an attempt to enroll while MFA is active.
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	// ErrMFANotEnrolled indicates there is no enrollment to confirm or disable.
+	ErrMFANotEnrolled = errors.New("two-factor authentication not enrolled")
+	// ErrInvalidMFACode indicates a wrong, reused or expired TOTP or recovery code.
+	ErrInvalidMFACode = errors.New("invalid verification code")
+	// ErrInvalidMFAChallenge indicates an unknown, expired or used MFA challenge token.
+	ErrInvalidMFAChallenge = errors.New("invalid or expired mfa challenge")
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// MFASetup carries what an authenticator app needs to start generating codes.
+type MFASetup struct {
+	Secret string
+	// URI is the otpauth:// provisioning URI, usually shown as a QR code.
+	URI string
+}
+
+// BeginMFAEnrollment generates a new TOTP secret for the user. The secret is
+// inactive until confirmed with a first code; calling again replaces an
+// unconfirmed secret.
+func (s *Service) BeginMFAEnrollment(ctx context.Context, userID string) (*MFASetup, error) {
+	if !s.mfaConfigured() {
+		return nil, ErrMFANotConfigured
+	}
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfa.SavePending(ctx, &domain.MFAEnrollment{
+		UserID:    userID,
+		Secret:    secret,
+		CreatedAt: s.now().UTC(),
+	}); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			return nil, ErrMFAAlreadyEnabled
+		}
+		return nil, err
+	}
+	return &MFASetup{
+		Secret: secret,
+		URI:    totp.URI(s.mfaIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmMFAEnrollment activates MFA once the user proves their authenticator
+// works and returns freshly generated recovery codes. The codes are shown
+// only once.
+func (s *Service) ConfirmMFAEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	if !s.mfaConfigured() {
+		return nil, ErrMFANotConfigured
+	}
+	enrollment, err := s.mfa.GetByUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrMFANotEnrolled
+		}
+		return nil, err
+	}
+	if enrollment.Enabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	now := s.now()
+	step, ok := totp.Validate(enrollment.Secret, code, now, totpSkew)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	plain, stored, err := s.generateRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfa.Confirm(ctx, userID, step, now.UTC(), stored); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			return nil, ErrInvalidMFACode
+		}
+		return nil, err
+	}
+	return plain, nil
+}
+
+// DisableMFA turns two-factor authentication off after checking a current
+// TOTP or recovery code.
+func (s *Service) DisableMFA(ctx context.Context, userID, code string) error {
+	if s.mfa == nil {
+		return ErrMFANotConfigured
+	}
+	enrollment, err := s.mfa.GetByUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrMFANotEnrolled
+		}
+		return err
+	}
+	if enrollment.Enabled() {
+		if err := s.checkSecondFactor(ctx, enrollment, code); err != nil {
+			return err
+		}
+	}
+	return s.mfa.Delete(ctx, userID)
+}
+
+// VerifyMFA completes a two-step login by exchanging the challenge token
+// returned from Login and a TOTP or recovery code for regular tokens. Wrong
+// codes count towards the account lockout like wrong passwords.
+//
+// The challenge is spent before the code is checked, whatever the outcome:
+// requests racing with the same challenge cannot all pass, and a wrong code
+// means logging in again.
+func (s *Service) VerifyMFA(ctx context.Context, challenge, code string, client ClientInfo) (*Tokens, error) {
+	if !s.mfaConfigured() {
+		return nil, ErrMFANotConfigured
+	}
+	claims, err := jwt.ParseAndValidate(challenge, s.keys, s.now())
+	if err != nil || claims.Purpose != mfaChallengePurpose {
+		return nil, ErrInvalidMFAChallenge
+	}
+	claimed, err := s.revokedTokens.Claim(ctx, claims.ID, time.Unix(claims.ExpiresAt, 0).UTC())
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	enrollment, err := s.mfa.GetByUser(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrInvalidMFAChallenge
+		}
+		return nil, err
+	}
+	if !enrollment.Enabled() {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	user, err := s.users.GetByID(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrInvalidMFAChallenge
+		}
+		return nil, err
+	}
+	email := user.Email
+	if s.lockout != nil {
+		if err := s.lockout.Check(ctx, email, client.IP); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.checkSecondFactor(ctx, enrollment, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) && s.lockout != nil {
+			if recordErr := s.lockout.RecordFailure(ctx, email, client.IP); recordErr != nil {
+				return nil, recordErr
+			}
+		}
+		return nil, err
+	}
+	if s.lockout != nil {
+		if err := s.lockout.RecordSuccess(ctx, email); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.checkAccountActive(user); err != nil {
+		return nil, err
+	}
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
+	return s.issueTokens(ctx, user, "", client)
+}
+
+// mfaRequired reports whether the user must pass a second factor to log in.
+func (s *Service) mfaRequired(ctx context.Context, userID string) (bool, error) {
+	if s.mfa == nil {
+		return false, nil
+	}
+	enrollment, err := s.mfa.GetByUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if enrollment.Enabled() && s.revokedTokens == nil {
+		// Refuse rather than skip the second factor: without a revocation
+		// store a challenge could not be made single use.
+		return false, ErrMFANotConfigured
+	}
+	return enrollment.Enabled(), nil
+}
+
+// mfaConfigured reports whether both the MFA store and the revocation store
+// that makes login challenges single use are set.
+func (s *Service) mfaConfigured() bool {
+	return s.mfa != nil && s.revokedTokens != nil
+}
+
+// issueMFAChallenge signs the short-lived token handed out in place of
+// access tokens after a correct password.
+func (s *Service) issueMFAChallenge(userID string) (*Tokens, error) {
+	id, err := uuid.NewString()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	expiresAt := now.Add(mfaChallengeTTL)
+	challenge, err := s.keys.Sign(jwt.Claims{
+		ID:        id,
+		Subject:   userID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+		Purpose:   mfaChallengePurpose,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Tokens{MFAToken: challenge, MFAExpiresAt: expiresAt.UTC()}, nil
+}
+
+// checkSecondFactor accepts a six-digit TOTP code, refusing replays of an
+// already used time step, or an unused recovery code.
+func (s *Service) checkSecondFactor(ctx context.Context, enrollment *domain.MFAEnrollment, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(enrollment.Secret, code, s.now(), totpSkew)
+		if !ok || step <= enrollment.LastUsedStep {
+			return ErrInvalidMFACode
+		}
+		if err := s.mfa.UseStep(ctx, enrollment.UserID, step); err != nil {
+			if errors.Is(err, domain.ErrConflict) {
+				return ErrInvalidMFACode
+			}
+			return err
+		}
+		return nil
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) != recoveryCodeLength {
+		return ErrInvalidMFACode
+	}
+	if err := s.mfa.UseRecoveryCode(ctx, enrollment.UserID, token.Hash(normalized), s.now().UTC()); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrInvalidMFACode
+		}
+		return err
+	}
+	return nil
+}
+
+// generateRecoveryCodes returns codes formatted as xxxxx-xxxxx together with
+// their stored digests.
+func (s *Service) generateRecoveryCodes(userID string) ([]string, []domain.RecoveryCode, error) {
+	now := s.now().UTC()
+	plain := make([]string, 0, recoveryCodeCount)
+	stored := make([]domain.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		var b [recoveryCodeLength * 5 / 8]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(recoveryEncoding.EncodeToString(b[:]))
+		id, err := uuid.NewString()
+		if err != nil {
+			return nil, nil, err
+		}
+		plain = append(plain, code[:recoveryCodeLength/2]+"-"+code[recoveryCodeLength/2:])
+		stored = append(stored, domain.RecoveryCode{
+			ID:        id,
+			UserID:    userID,
+			CodeHash:  token.Hash(code),
+			CreatedAt: now,
+		})
+	}
+	return plain, stored, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,315 @@
+package auth_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository/memory"
+	authsvc "go-todo-service/internal/service/auth"
+	"go-todo-service/pkg/totp"
+)
+
+type fakeMFARepo struct {
+	enrollments map[string]*domain.MFAEnrollment
+	codes       map[string][]domain.RecoveryCode
+}
+
+func newFakeMFARepo() *fakeMFARepo {
+	return &fakeMFARepo{
+		enrollments: make(map[string]*domain.MFAEnrollment),
+		codes:       make(map[string][]domain.RecoveryCode),
+	}
+}
+
+func (r *fakeMFARepo) GetByUser(ctx context.Context, userID string) (*domain.MFAEnrollment, error) {
+	if enrollment, ok := r.enrollments[userID]; ok {
+		e := *enrollment
+		return &e, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *fakeMFARepo) SavePending(ctx context.Context, enrollment *domain.MFAEnrollment) error {
+	if existing, ok := r.enrollments[enrollment.UserID]; ok && existing.Enabled() {
+		return domain.ErrConflict
+	}
+	e := *enrollment
+	r.enrollments[enrollment.UserID] = &e
+	return nil
+}
+
+func (r *fakeMFARepo) Confirm(ctx context.Context, userID string, step int64, at time.Time, codes []domain.RecoveryCode) error {
+	enrollment, ok := r.enrollments[userID]
+	if !ok || enrollment.Enabled() || enrollment.LastUsedStep >= step {
+		return domain.ErrConflict
+	}
+	enrollment.ConfirmedAt = &at
+	enrollment.LastUsedStep = step
+	r.codes[userID] = append([]domain.RecoveryCode(nil), codes...)
+	return nil
+}
+
+func (r *fakeMFARepo) UseStep(ctx context.Context, userID string, step int64) error {
+	enrollment, ok := r.enrollments[userID]
+	if !ok || !enrollment.Enabled() || enrollment.LastUsedStep >= step {
+		return domain.ErrConflict
+	}
+	enrollment.LastUsedStep = step
+	return nil
+}
+
+func (r *fakeMFARepo) UseRecoveryCode(ctx context.Context, userID, codeHash string, at time.Time) error {
+	for i := range r.codes[userID] {
+		code := &r.codes[userID][i]
+		if code.CodeHash == codeHash && code.UsedAt == nil {
+			code.UsedAt = &at
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (r *fakeMFARepo) Delete(ctx context.Context, userID string) error {
+	if _, ok := r.enrollments[userID]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(r.enrollments, userID)
+	delete(r.codes, userID)
+	return nil
+}
+
+// enableMFA enrolls the fixture user and returns the secret and recovery codes.
+func enableMFA(t *testing.T, service *authsvc.Service, now time.Time) (string, []string) {
+	t.Helper()
+	setup, err := service.BeginMFAEnrollment(context.Background(), "abc")
+	if err != nil {
+		t.Fatalf("begin enrollment: %v", err)
+	}
+	code, err := totp.Code(setup.Secret, now)
+	if err != nil {
+		t.Fatalf("code: %v", err)
+	}
+	recovery, err := service.ConfirmMFAEnrollment(context.Background(), "abc", code)
+	if err != nil {
+		t.Fatalf("confirm enrollment: %v", err)
+	}
+	return setup.Secret, recovery
+}
+
+func TestMFAEnrollmentAndLogin(t *testing.T) {
+	service, _, _ := newLoginFixture(t)
+	service.WithMFA(newFakeMFARepo(), "Todo")
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return now })
+	revoked := memory.NewRevokedTokenRepository()
+	revoked.WithNow(func() time.Time { return now })
+	service.WithRevokedTokens(revoked)
+	ctx := context.Background()
+
+	setup, err := service.BeginMFAEnrollment(ctx, "abc")
+	if err != nil {
+		t.Fatalf("begin enrollment: %v", err)
+	}
+	if want := totp.URI("Todo", "user@example.com", setup.Secret); setup.URI != want {
+		t.Fatalf("expected uri %s, got %s", want, setup.URI)
+	}
+	if _, err := service.ConfirmMFAEnrollment(ctx, "abc", "000000"); err != authsvc.ErrInvalidMFACode {
+		t.Fatalf("expected ErrInvalidMFACode, got %v", err)
+	}
+	tokens, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil || tokens.AccessToken == "" {
+		t.Fatalf("expected unconfirmed enrollment not to affect login, got %v", err)
+	}
+
+	code, _ := totp.Code(setup.Secret, now)
+	recovery, err := service.ConfirmMFAEnrollment(ctx, "abc", code)
+	if err != nil {
+		t.Fatalf("confirm enrollment: %v", err)
+	}
+	if len(recovery) != 10 {
+		t.Fatalf("expected 10 recovery codes, got %d", len(recovery))
+	}
+	if _, err := service.BeginMFAEnrollment(ctx, "abc"); err != authsvc.ErrMFAAlreadyEnabled {
+		t.Fatalf("expected ErrMFAAlreadyEnabled, got %v", err)
+	}
+
+	challenge, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if challenge.AccessToken != "" || challenge.RefreshToken != "" || challenge.MFAToken == "" {
+		t.Fatalf("expected only an mfa challenge, got %+v", challenge)
+	}
+
+	if _, err := service.VerifyMFA(ctx, challenge.MFAToken, code, authsvc.ClientInfo{}); err != authsvc.ErrInvalidMFACode {
+		t.Fatalf("expected confirmation code to be unusable again, got %v", err)
+	}
+	now = now.Add(totp.Period)
+	code, _ = totp.Code(setup.Secret, now)
+	if _, err := service.VerifyMFA(ctx, challenge.MFAToken, code, authsvc.ClientInfo{}); err != authsvc.ErrInvalidMFAChallenge {
+		t.Fatalf("expected the challenge to be spent by the wrong code, got %v", err)
+	}
+
+	challenge, err = service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	tokens, err = service.VerifyMFA(ctx, challenge.MFAToken, code, authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatalf("expected tokens after mfa, got %+v", tokens)
+	}
+	if _, err := service.VerifyMFA(ctx, challenge.MFAToken, recovery[0], authsvc.ClientInfo{}); err != authsvc.ErrInvalidMFAChallenge {
+		t.Fatalf("expected challenge to be single use, got %v", err)
+	}
+}
+
+// barrierRevokedTokens holds the answer of every lookup until all the
+// expected requests have made theirs, so they race on the same challenge.
+type barrierRevokedTokens struct {
+	*memory.RevokedTokenRepository
+	arrived *sync.WaitGroup
+}
+
+func (r barrierRevokedTokens) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	found, err := r.RevokedTokenRepository.IsRevoked(ctx, tokenID)
+	r.arrived.Done()
+	r.arrived.Wait()
+	return found, err
+}
+
+func (r barrierRevokedTokens) Claim(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	found, err := r.RevokedTokenRepository.Claim(ctx, tokenID, expiresAt)
+	r.arrived.Done()
+	r.arrived.Wait()
+	return found, err
+}
+
+func TestMFAChallengeRedeemedOnceUnderConcurrency(t *testing.T) {
+	service, _, _ := newLoginFixture(t)
+	service.WithMFA(newFakeMFARepo(), "Todo")
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return now })
+	revoked := memory.NewRevokedTokenRepository()
+	revoked.WithNow(func() time.Time { return now })
+	service.WithRevokedTokens(revoked)
+	ctx := context.Background()
+	_, recovery := enableMFA(t, service, now)
+
+	challenge, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	const requests = 8
+	var arrived sync.WaitGroup
+	arrived.Add(requests)
+	service.WithRevokedTokens(barrierRevokedTokens{RevokedTokenRepository: revoked, arrived: &arrived})
+
+	var wg sync.WaitGroup
+	var issued atomic.Int32
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(code string) {
+			defer wg.Done()
+			if _, err := service.VerifyMFA(ctx, challenge.MFAToken, code, authsvc.ClientInfo{}); err == nil {
+				issued.Add(1)
+			}
+		}(recovery[i])
+	}
+	wg.Wait()
+	if n := issued.Load(); n != 1 {
+		t.Fatalf("expected one challenge redemption, got %d", n)
+	}
+}
+
+func TestMFARecoveryCodesAreSingleUse(t *testing.T) {
+	service, _, _ := newLoginFixture(t)
+	service.WithMFA(newFakeMFARepo(), "Todo")
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return now })
+	revoked := memory.NewRevokedTokenRepository()
+	revoked.WithNow(func() time.Time { return now })
+	service.WithRevokedTokens(revoked)
+	ctx := context.Background()
+	_, recovery := enableMFA(t, service, now)
+
+	challenge, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if _, err := service.VerifyMFA(ctx, challenge.MFAToken, " "+recovery[3]+" ", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("expected recovery code to work, got %v", err)
+	}
+
+	challenge, _ = service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+	if _, err := service.VerifyMFA(ctx, challenge.MFAToken, recovery[3], authsvc.ClientInfo{}); err != authsvc.ErrInvalidMFACode {
+		t.Fatalf("expected used recovery code to fail, got %v", err)
+	}
+}
+
+func TestMFAChallengeExpires(t *testing.T) {
+	service, _, _ := newLoginFixture(t)
+	service.WithMFA(newFakeMFARepo(), "Todo")
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return now })
+	revoked := memory.NewRevokedTokenRepository()
+	revoked.WithNow(func() time.Time { return now })
+	service.WithRevokedTokens(revoked)
+	ctx := context.Background()
+	secret, _ := enableMFA(t, service, now)
+
+	challenge, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	now = now.Add(10 * time.Minute)
+	code, _ := totp.Code(secret, now)
+	if _, err := service.VerifyMFA(ctx, challenge.MFAToken, code, authsvc.ClientInfo{}); err != authsvc.ErrInvalidMFAChallenge {
+		t.Fatalf("expected expired challenge, got %v", err)
+	}
+}
+
+func TestDisableMFA(t *testing.T) {
+	service, _, _ := newLoginFixture(t)
+	service.WithMFA(newFakeMFARepo(), "Todo")
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return now })
+	revoked := memory.NewRevokedTokenRepository()
+	revoked.WithNow(func() time.Time { return now })
+	service.WithRevokedTokens(revoked)
+	ctx := context.Background()
+	_, recovery := enableMFA(t, service, now)
+
+	if err := service.DisableMFA(ctx, "abc", "123456"); err != authsvc.ErrInvalidMFACode {
+		t.Fatalf("expected ErrInvalidMFACode, got %v", err)
+	}
+	if err := service.DisableMFA(ctx, "abc", recovery[0]); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+	tokens, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil || tokens.AccessToken == "" {
+		t.Fatalf("expected direct login after disabling mfa, got %+v %v", tokens, err)
+	}
+}
+
+func TestMFARequiresRevocationStore(t *testing.T) {
+	service, _, _ := newLoginFixture(t)
+	mfa := newFakeMFARepo()
+	service.WithMFA(mfa, "Todo")
+	ctx := context.Background()
+
+	if _, err := service.BeginMFAEnrollment(ctx, "abc"); err != authsvc.ErrMFANotConfigured {
+		t.Fatalf("expected ErrMFANotConfigured, got %v", err)
+	}
+
+	mfa.enrollments["abc"] = &domain.MFAEnrollment{UserID: "abc", Secret: "secret", ConfirmedAt: &time.Time{}}
+	if _, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{}); err != authsvc.ErrMFANotConfigured {
+		t.Fatalf("expected login to refuse skipping the second factor, got %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository"
+	"go-todo-service/pkg/jwt"
+	"go-todo-service/pkg/oidc"
+	"go-todo-service/pkg/uuid"
+)
+
+const (
+	// oidcLoginTTL bounds how long a user may take at the identity provider.
+	oidcLoginTTL = 10 * time.Minute
+	// oidcLoginPurpose marks login state JWTs so they cannot be used as access tokens.
+	oidcLoginPurpose = "oidc_login"
+)
+
+var (
+	// ErrOIDCUnavailable indicates single sign-on is not configured.
+	ErrOIDCUnavailable = errors.New("single sign-on not configured")
+	// ErrInvalidOIDCState indicates a callback that does not belong to a
+	// login started by this client, or one that took too long.
+	ErrInvalidOIDCState = errors.New("invalid or expired sign-in state")
+	// ErrOIDCFailed indicates the identity provider refused the code or
+	// returned an ID token that failed validation.
+	ErrOIDCFailed = errors.New("single sign-on failed")
+	// ErrOIDCEmailNotVerified indicates an identity without a verified email
+	// address, which cannot be linked to an account.
+	ErrOIDCEmailNotVerified = errors.New("identity provider did not verify the email address")
+	// ErrOIDCAccountUnverified indicates an identity whose email belongs to
+	// an account that never verified it. Whoever registered it may not own
+	// the address, so the identity is not linked until the account is
+	// verified.
+	ErrOIDCAccountUnverified = errors.New("an unverified account uses this email address; verify it before signing in with single sign-on")
+)
+
+// OIDCLogin is a started single sign-on login.
+type OIDCLogin struct {
+	// URL is the identity provider page the user is sent to.
+	URL string
+	// State must be kept by the client, typically in a cookie, and handed
+	// to CompleteOIDCLogin with the callback parameters.
+	State     string
+	ExpiresAt time.Time
+}
+
+// WithOIDC enables single sign-on through an OpenID provider. Identities
+// records which provider account belongs to which user. stateKey is a server
+// secret keying the derivation of each login's state, nonce and PKCE
+// verifier; every instance behind a load balancer needs the same key.
+func (s *Service) WithOIDC(provider *oidc.Provider, identities repository.IdentityRepository, stateKey []byte) {
+	if provider != nil && identities != nil && len(stateKey) > 0 {
+		s.oidc = provider
+		s.identities = identities
+		s.oidcStateKey = stateKey
+	}
+}
+
+// BeginOIDCLogin starts the authorization code flow with PKCE. The state,
+// nonce and code verifier are all derived from a random seed carried in the
+// returned signed State, so nothing is stored server-side. The derivation is
+// keyed, so the seed alone, which the State does not hide, does not reveal
+// the verifier.
+func (s *Service) BeginOIDCLogin(ctx context.Context) (*OIDCLogin, error) {
+	if s.oidc == nil {
+		return nil, ErrOIDCUnavailable
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	seed := base64.RawURLEncoding.EncodeToString(buf)
+
+	now := s.now()
+	expiresAt := now.Add(oidcLoginTTL)
+	loginState, err := s.keys.Sign(jwt.Claims{
+		ID:        seed,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+		Purpose:   oidcLoginPurpose,
+	})
+	if err != nil {
+		return nil, err
+	}
+	authURL, err := s.oidc.AuthCodeURL(ctx, s.deriveOIDCValue("state", seed), s.deriveOIDCValue("nonce", seed),
+		oidc.S256Challenge(s.deriveOIDCValue("verifier", seed)))
+	if err != nil {
+		return nil, err
+	}
+	return &OIDCLogin{URL: authURL, State: loginState, ExpiresAt: expiresAt.UTC()}, nil
+}
+
+// CompleteOIDCLogin handles the provider's callback. The identity is
+// matched to the user it was linked to before or, failing that, to the
+// account with the same email address once that account has verified it.
+// Unknown addresses get a new account without a password. It returns the same credentials as Login,
+// including an MFA challenge when two-factor authentication is enabled.
+func (s *Service) CompleteOIDCLogin(ctx context.Context, loginState, state, code string, client ClientInfo) (*Tokens, error) {
+	if s.oidc == nil {
+		return nil, ErrOIDCUnavailable
+	}
+	claims, err := jwt.ParseAndValidate(loginState, s.keys, s.now())
+	if err != nil || claims.Purpose != oidcLoginPurpose || claims.ID == "" {
+		return nil, ErrInvalidOIDCState
+	}
+	expected := s.deriveOIDCValue("state", claims.ID)
+	if subtle.ConstantTimeCompare([]byte(state), []byte(expected)) != 1 {
+		return nil, ErrInvalidOIDCState
+	}
+	if strings.TrimSpace(code) == "" {
+		return nil, ErrOIDCFailed
+	}
+
+	idToken, err := s.oidc.Exchange(ctx, code, s.deriveOIDCValue("verifier", claims.ID), s.deriveOIDCValue("nonce", claims.ID))
+	if err != nil {
+		if errors.Is(err, oidc.ErrExchangeFailed) || errors.Is(err, oidc.ErrInvalidIDToken) {
+			return nil, fmt.Errorf("%w: %w", ErrOIDCFailed, err)
+		}
+		return nil, err
+	}
+	user, err := s.userForIdentity(ctx, idToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccountActive(user); err != nil {
+		return nil, err
+	}
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
+
+	required, err := s.mfaRequired(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if required {
+		return s.issueMFAChallenge(user.ID)
+	}
+	return s.issueTokens(ctx, user, "", client)
+}
+
+// userForIdentity returns the user linked to the identity, linking or
+// provisioning one by verified email when it is new. Accounts that have not
+// verified their email are never linked: the address may have been
+// registered by someone else, who would keep access through the password.
+func (s *Service) userForIdentity(ctx context.Context, idToken *oidc.IDToken) (*domain.User, error) {
+	identity, err := s.identities.GetBySubject(ctx, idToken.Issuer, idToken.Subject)
+	if err == nil {
+		return s.users.GetByID(ctx, identity.UserID)
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	email := strings.TrimSpace(strings.ToLower(idToken.Email))
+	if !idToken.EmailVerified || email == "" || !strings.Contains(email, "@") {
+		return nil, ErrOIDCEmailNotVerified
+	}
+	now := s.now().UTC()
+	user, err := s.users.GetByEmail(ctx, email)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		if user, err = s.provisionUser(ctx, email, idToken.Name, now); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case user.EmailVerifiedAt == nil:
+		return nil, ErrOIDCAccountUnverified
+	}
+
+	err = s.identities.Create(ctx, &domain.Identity{
+		Issuer:    idToken.Issuer,
+		Subject:   idToken.Subject,
+		UserID:    user.ID,
+		Email:     email,
+		CreatedAt: now,
+	})
+	if errors.Is(err, domain.ErrConflict) {
+		// A concurrent callback for the same identity linked it first.
+		identity, err := s.identities.GetBySubject(ctx, idToken.Issuer, idToken.Subject)
+		if err != nil {
+			return nil, err
+		}
+		return s.users.GetByID(ctx, identity.UserID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// provisionUser creates a verified account without a password for a new
+// single sign-on user. A password can be added later via password reset.
+func (s *Service) provisionUser(ctx context.Context, email, name string, now time.Time) (*domain.User, error) {
+	id, err := uuid.NewString()
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > maxDisplayNameLength || strings.ContainsFunc(name, isControl) {
+		name = ""
+	}
+	user := &domain.User{
+		ID:              id,
+		Email:           email,
+		DisplayName:     name,
+		TimeZone:        domain.DefaultTimeZone,
+		Locale:          domain.DefaultLocale,
+		Role:            domain.RoleUser,
+		EmailVerifiedAt: &now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := s.users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	s.createInbox(ctx, user.ID)
+	return user, nil
+}
+
+// deriveOIDCValue derives one of the flow's secrets from the login seed
+// with an HMAC keyed by the server's state key.
+func (s *Service) deriveOIDCValue(label, seed string) string {
+	mac := hmac.New(sha256.New, s.oidcStateKey)
+	mac.Write([]byte(label + ":" + seed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,261 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-todo-service/internal/domain"
+	authsvc "go-todo-service/internal/service/auth"
+	"go-todo-service/pkg/jwt"
+	"go-todo-service/pkg/oidc"
+	"go-todo-service/pkg/oidc/oidctest"
+	"go-todo-service/pkg/password"
+)
+
+type fakeIdentityRepo struct {
+	identities map[string]*domain.Identity
+}
+
+func newFakeIdentityRepo() *fakeIdentityRepo {
+	return &fakeIdentityRepo{identities: make(map[string]*domain.Identity)}
+}
+
+func (r *fakeIdentityRepo) Create(ctx context.Context, identity *domain.Identity) error {
+	key := identity.Issuer + "|" + identity.Subject
+	if _, exists := r.identities[key]; exists {
+		return domain.ErrConflict
+	}
+	i := *identity
+	r.identities[key] = &i
+	return nil
+}
+
+func (r *fakeIdentityRepo) GetBySubject(ctx context.Context, issuer, subject string) (*domain.Identity, error) {
+	if identity, ok := r.identities[issuer+"|"+subject]; ok {
+		i := *identity
+		return &i, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+type oidcFixture struct {
+	service    *authsvc.Service
+	users      *fakeUserRepo
+	identities *fakeIdentityRepo
+	idp        *oidctest.Server
+	keys       *jwt.KeySet
+}
+
+func newOIDCFixture(t *testing.T) *oidcFixture {
+	t.Helper()
+	users := newFakeUserRepo()
+	hashed, _ := password.Hash("password")
+	verifiedAt := time.Now().Add(-time.Hour)
+	users.users["user@example.com"] = &domain.User{ID: "abc", Email: "user@example.com", PasswordHash: hashed, EmailVerifiedAt: &verifiedAt}
+
+	idp := oidctest.NewServer(t, "todo-app", "secret")
+	provider, err := oidc.NewProvider(idp.Config("https://app.example.com/auth/oidc/callback"))
+	if err != nil {
+		t.Fatalf("provider: %v", err)
+	}
+	identities := newFakeIdentityRepo()
+	keys := newTestKeySet(t)
+	service := authsvc.New(users, keys, 15*time.Minute)
+	service.WithRefreshTokens(newFakeRefreshTokenRepo(), 24*time.Hour)
+	service.WithOIDC(provider, identities, []byte("oidc-state-key-oidc-state-key-01"))
+	return &oidcFixture{service: service, users: users, identities: identities, idp: idp, keys: keys}
+}
+
+// login runs the whole flow for id and returns the access token's subject.
+func (f *oidcFixture) login(t *testing.T, id oidctest.Identity) (string, error) {
+	t.Helper()
+	ctx := context.Background()
+	login, err := f.service.BeginOIDCLogin(ctx)
+	if err != nil {
+		t.Fatalf("begin login: %v", err)
+	}
+	code, state := f.idp.Authorize(t, login.URL, id)
+	tokens, err := f.service.CompleteOIDCLogin(ctx, login.State, state, code, authsvc.ClientInfo{})
+	if err != nil {
+		return "", err
+	}
+	claims, err := jwt.ParseAndValidate(tokens.AccessToken, f.keys, time.Now())
+	if err != nil {
+		t.Fatalf("parse access token: %v", err)
+	}
+	return claims.Subject, nil
+}
+
+func TestOIDCLoginLinksExistingUserByVerifiedEmail(t *testing.T) {
+	f := newOIDCFixture(t)
+
+	subject, err := f.login(t, oidctest.Identity{Subject: "idp-1", Email: "User@Example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if subject != "abc" {
+		t.Fatalf("expected existing user to be signed in, got %q", subject)
+	}
+	if _, err := f.identities.GetBySubject(context.Background(), f.idp.Issuer, "idp-1"); err != nil {
+		t.Fatalf("expected identity to be linked: %v", err)
+	}
+
+	// Once linked, the subject decides even if the provider's email changes.
+	subject, err = f.login(t, oidctest.Identity{Subject: "idp-1", Email: "renamed@example.com"})
+	if err != nil || subject != "abc" {
+		t.Fatalf("expected linked identity to sign in as abc, got %q, %v", subject, err)
+	}
+}
+
+func TestOIDCLoginRefusesUnverifiedAccount(t *testing.T) {
+	f := newOIDCFixture(t)
+	// Someone registered the address with a password but never verified it.
+	f.users.users["user@example.com"].EmailVerifiedAt = nil
+
+	_, err := f.login(t, oidctest.Identity{Subject: "idp-1", Email: "user@example.com", EmailVerified: true})
+	if !errors.Is(err, authsvc.ErrOIDCAccountUnverified) {
+		t.Fatalf("expected ErrOIDCAccountUnverified, got %v", err)
+	}
+	if _, err := f.identities.GetBySubject(context.Background(), f.idp.Issuer, "idp-1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected the identity to stay unlinked, got %v", err)
+	}
+	if f.users.users["user@example.com"].EmailVerifiedAt != nil {
+		t.Fatal("expected the account to stay unverified")
+	}
+}
+
+func TestOIDCLoginProvisionsNewUser(t *testing.T) {
+	f := newOIDCFixture(t)
+	ctx := context.Background()
+
+	subject, err := f.login(t, oidctest.Identity{Subject: "idp-2", Email: "new@example.com", EmailVerified: true, Name: "New User"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	created, ok := f.users.users["new@example.com"]
+	if !ok || created.ID != subject {
+		t.Fatalf("expected a new account for the identity, got %+v", created)
+	}
+	if created.EmailVerifiedAt == nil || created.DisplayName != "New User" || created.PasswordHash != "" {
+		t.Fatalf("unexpected provisioned account %+v", created)
+	}
+	if _, err := f.service.Login(ctx, "new@example.com", "", authsvc.ClientInfo{}); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("expected password login to fail without a password, got %v", err)
+	}
+	if _, err := f.service.Login(ctx, "new@example.com", "anything", authsvc.ClientInfo{}); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("expected password login to fail without a password, got %v", err)
+	}
+}
+
+func TestOIDCLoginRequiresVerifiedEmail(t *testing.T) {
+	f := newOIDCFixture(t)
+
+	_, err := f.login(t, oidctest.Identity{Subject: "idp-3", Email: "user@example.com"})
+	if !errors.Is(err, authsvc.ErrOIDCEmailNotVerified) {
+		t.Fatalf("expected ErrOIDCEmailNotVerified, got %v", err)
+	}
+	if len(f.identities.identities) != 0 {
+		t.Fatal("expected nothing to be linked")
+	}
+}
+
+func TestOIDCLoginRejectsForeignState(t *testing.T) {
+	f := newOIDCFixture(t)
+	ctx := context.Background()
+	id := oidctest.Identity{Subject: "idp-1", Email: "user@example.com", EmailVerified: true}
+
+	first, err := f.service.BeginOIDCLogin(ctx)
+	if err != nil {
+		t.Fatalf("begin login: %v", err)
+	}
+	second, err := f.service.BeginOIDCLogin(ctx)
+	if err != nil {
+		t.Fatalf("begin login: %v", err)
+	}
+	code, state := f.idp.Authorize(t, first.URL, id)
+
+	if _, err := f.service.CompleteOIDCLogin(ctx, second.State, state, code, authsvc.ClientInfo{}); !errors.Is(err, authsvc.ErrInvalidOIDCState) {
+		t.Fatalf("expected state of another login to be refused, got %v", err)
+	}
+	if _, err := f.service.CompleteOIDCLogin(ctx, "garbage", state, code, authsvc.ClientInfo{}); !errors.Is(err, authsvc.ErrInvalidOIDCState) {
+		t.Fatalf("expected malformed login state to be refused, got %v", err)
+	}
+	if _, err := f.service.CompleteOIDCLogin(ctx, first.State, state, code, authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("expected matching state to succeed: %v", err)
+	}
+	if _, err := f.service.CompleteOIDCLogin(ctx, first.State, state, code, authsvc.ClientInfo{}); !errors.Is(err, authsvc.ErrOIDCFailed) {
+		t.Fatalf("expected a used code to be refused, got %v", err)
+	}
+}
+
+func TestOIDCLoginStateNeedsServerKey(t *testing.T) {
+	f := newOIDCFixture(t)
+	ctx := context.Background()
+
+	login, err := f.service.BeginOIDCLogin(ctx)
+	if err != nil {
+		t.Fatalf("begin login: %v", err)
+	}
+	code, state := f.idp.Authorize(t, login.URL, oidctest.Identity{Subject: "idp-1", Email: "user@example.com", EmailVerified: true})
+
+	// Same signing keys, different state key: the seed in the signed state
+	// is not enough to derive the expected state and verifier.
+	provider, err := oidc.NewProvider(f.idp.Config("https://app.example.com/auth/oidc/callback"))
+	if err != nil {
+		t.Fatalf("provider: %v", err)
+	}
+	other := authsvc.New(f.users, f.keys, 15*time.Minute)
+	other.WithOIDC(provider, f.identities, []byte("another-state-key-another-state-"))
+	if _, err := other.CompleteOIDCLogin(ctx, login.State, state, code, authsvc.ClientInfo{}); !errors.Is(err, authsvc.ErrInvalidOIDCState) {
+		t.Fatalf("expected state derived with another key to be refused, got %v", err)
+	}
+}
+
+func TestOIDCLoginStateExpires(t *testing.T) {
+	f := newOIDCFixture(t)
+	ctx := context.Background()
+	now := time.Now()
+	f.service.WithNow(func() time.Time { return now })
+
+	login, err := f.service.BeginOIDCLogin(ctx)
+	if err != nil {
+		t.Fatalf("begin login: %v", err)
+	}
+	code, state := f.idp.Authorize(t, login.URL, oidctest.Identity{Subject: "idp-1", Email: "user@example.com", EmailVerified: true})
+	now = now.Add(11 * time.Minute)
+	if _, err := f.service.CompleteOIDCLogin(ctx, login.State, state, code, authsvc.ClientInfo{}); !errors.Is(err, authsvc.ErrInvalidOIDCState) {
+		t.Fatalf("expected expired login state to be refused, got %v", err)
+	}
+}
+
+func TestOIDCLoginRefusesDisabledAccount(t *testing.T) {
+	f := newOIDCFixture(t)
+	disabledAt := time.Now().Add(-time.Hour)
+	f.users.users["user@example.com"].DisabledAt = &disabledAt
+
+	_, err := f.login(t, oidctest.Identity{Subject: "idp-1", Email: "user@example.com", EmailVerified: true})
+	if !errors.Is(err, authsvc.ErrAccountDisabled) {
+		t.Fatalf("expected ErrAccountDisabled, got %v", err)
+	}
+}
+
+func TestOIDCLoginRefusesPendingPasswordReset(t *testing.T) {
+	f := newOIDCFixture(t)
+	if _, err := f.service.ForcePasswordReset(context.Background(), "abc"); err != nil {
+		t.Fatalf("force reset failed: %v", err)
+	}
+
+	_, err := f.login(t, oidctest.Identity{Subject: "idp-1", Email: "user@example.com", EmailVerified: true})
+	if !errors.Is(err, authsvc.ErrPasswordResetRequired) {
+		t.Fatalf("expected ErrPasswordResetRequired, got %v", err)
+	}
+}
+
+func TestOIDCLoginUnavailable(t *testing.T) {
+	service, _, _ := newLoginFixture(t)
+	if _, err := service.BeginOIDCLogin(context.Background()); !errors.Is(err, authsvc.ErrOIDCUnavailable) {
+		t.Fatalf("expected ErrOIDCUnavailable, got %v", err)
+	}
+}
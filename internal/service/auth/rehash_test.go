@@ -0,0 +1,53 @@
+package auth_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"go-todo-service/internal/domain"
+	authsvc "go-todo-service/internal/service/auth"
+	"go-todo-service/pkg/password"
+)
+
+func TestLoginUpgradesLegacyHash(t *testing.T) {
+	repo := newFakeUserRepo()
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt: %v", err)
+	}
+	repo.users["user@example.com"] = &domain.User{
+		ID:           "abc",
+		Email:        "user@example.com",
+		PasswordHash: string(legacy),
+	}
+	service := authsvc.New(repo, newTestKeySet(t), 15*time.Minute)
+	ctx := context.Background()
+
+	if _, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("login with bcrypt hash failed: %v", err)
+	}
+	upgraded := repo.users["user@example.com"].PasswordHash
+	if !strings.HasPrefix(upgraded, "$argon2id$") {
+		t.Fatalf("expected hash upgraded to argon2id, got %s", upgraded)
+	}
+
+	stronger, err := password.NewHasher(password.Params{Memory: 32 * 1024, Iterations: 2, Parallelism: 1})
+	if err != nil {
+		t.Fatalf("hasher: %v", err)
+	}
+	service.WithPasswordHasher(stronger)
+	if _, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if hash := repo.users["user@example.com"].PasswordHash; hash == upgraded || !strings.Contains(hash, "m=32768") {
+		t.Fatalf("expected rehash with new parameters, got %s", hash)
+	}
+
+	if _, err := service.Login(ctx, "user@example.com", "wrong", authsvc.ClientInfo{}); err != domain.ErrInvalidCredentials {
+		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/pkg/mailer"
+	"go-todo-service/pkg/token"
+	"go-todo-service/pkg/uuid"
+)
+
+const (
+	// passwordResetTTL bounds how long an emailed reset link stays valid.
+	passwordResetTTL = time.Hour
+	// passwordResetResendInterval is the minimum gap between reset links sent
+	// to one account.
+	passwordResetResendInterval = time.Minute
+)
+
+// RequestPasswordReset emails a reset link when the address belongs to an
+// account. Unknown addresses, and requests within
+// passwordResetResendInterval of the last link, are ignored silently so
+// callers cannot probe which emails are registered. With lockout configured,
+// requests count against the client IP and a *lockout.BlockedError is
+// returned once it has made too many.
+func (s *Service) RequestPasswordReset(ctx context.Context, email string, client ClientInfo) error {
+	if s.userTokens == nil || s.mailer == nil {
+		return errors.New("password reset not configured")
+	}
+	email = strings.TrimSpace(strings.ToLower(email))
+	if email == "" {
+		return nil
+	}
+	if s.lockout != nil {
+		if err := s.lockout.RecordPasswordResetRequest(ctx, client.IP); err != nil {
+			return err
+		}
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	last, err := s.userTokens.LastIssuedAt(ctx, user.ID, domain.TokenPurposePasswordReset)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+	if err == nil && s.now().Sub(last) < passwordResetResendInterval {
+		return nil
+	}
+	return s.sendPasswordReset(ctx, user)
+}
+
+// sendPasswordReset issues a reset token for the user and emails the link.
+func (s *Service) sendPasswordReset(ctx context.Context, user *domain.User) error {
+	plain, err := s.issueUserToken(ctx, user.ID, domain.TokenPurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	link := s.linkBaseURL + "/reset-password?token=" + url.QueryEscape(plain)
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password for your account.\n\n"+
+			"Use the link below within %d minutes to choose a new password:\n%s\n\n"+
+			"If this was not you, you can ignore this email.\n", int(passwordResetTTL.Minutes()), link),
+	})
+}
+
+// ResetPassword redeems a reset token, sets the new password and signs the
+// user out everywhere by revoking their refresh tokens.
+func (s *Service) ResetPassword(ctx context.Context, resetToken, newPassword string) error {
+	if s.userTokens == nil {
+		return ErrInvalidResetToken
+	}
+	resetToken = strings.TrimSpace(resetToken)
+	if resetToken == "" {
+		return ErrInvalidResetToken
+	}
+	now := s.now().UTC()
+	tokenHash := token.Hash(resetToken)
+	// Validate before consuming so a rejected password does not burn the token.
+	pending, err := s.userTokens.GetActive(ctx, tokenHash, domain.TokenPurposePasswordReset, now)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+	user, err := s.users.GetByID(ctx, pending.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.policy.Check(newPassword, user.Email); err != nil {
+		return err
+	}
+
+	consumed, err := s.userTokens.Consume(ctx, tokenHash, domain.TokenPurposePasswordReset, now)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	hashed, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.users.UpdatePassword(ctx, consumed.UserID, hashed, now); err != nil {
+		return err
+	}
+	if err := s.userTokens.InvalidateByUser(ctx, consumed.UserID, domain.TokenPurposePasswordReset, now); err != nil {
+		return err
+	}
+	return s.revokeSessions(ctx, consumed.UserID)
+}
+
+// issueUserToken stores a new single-use token and returns its plaintext.
+func (s *Service) issueUserToken(ctx context.Context, userID string, purpose domain.TokenPurpose, ttl time.Duration) (string, error) {
+	plain, err := token.Generate()
+	if err != nil {
+		return "", err
+	}
+	id, err := uuid.NewString()
+	if err != nil {
+		return "", err
+	}
+	now := s.now().UTC()
+	if err := s.userTokens.Create(ctx, &domain.UserToken{
+		ID:        id,
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: token.Hash(plain),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}); err != nil {
+		return "", err
+	}
+	return plain, nil
+}
@@ -0,0 +1,226 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository/memory"
+	authsvc "go-todo-service/internal/service/auth"
+	"go-todo-service/internal/service/lockout"
+	"go-todo-service/pkg/mailer"
+)
+
+type fakeUserTokenRepo struct {
+	tokens map[string]*domain.UserToken
+}
+
+func newFakeUserTokenRepo() *fakeUserTokenRepo {
+	return &fakeUserTokenRepo{
+		tokens: make(map[string]*domain.UserToken),
+	}
+}
+
+func (r *fakeUserTokenRepo) Create(ctx context.Context, token *domain.UserToken) error {
+	t := *token
+	r.tokens[token.ID] = &t
+	return nil
+}
+
+func (r *fakeUserTokenRepo) GetActive(ctx context.Context, tokenHash string, purpose domain.TokenPurpose, at time.Time) (*domain.UserToken, error) {
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash && token.Purpose == purpose && token.ConsumedAt == nil && at.Before(token.ExpiresAt) {
+			t := *token
+			return &t, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *fakeUserTokenRepo) Consume(ctx context.Context, tokenHash string, purpose domain.TokenPurpose, at time.Time) (*domain.UserToken, error) {
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash && token.Purpose == purpose && token.ConsumedAt == nil && at.Before(token.ExpiresAt) {
+			token.ConsumedAt = &at
+			t := *token
+			return &t, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *fakeUserTokenRepo) InvalidateByUser(ctx context.Context, userID string, purpose domain.TokenPurpose, at time.Time) error {
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.Purpose == purpose && token.ConsumedAt == nil {
+			token.ConsumedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *fakeUserTokenRepo) LastIssuedAt(ctx context.Context, userID string, purpose domain.TokenPurpose) (time.Time, error) {
+	var last time.Time
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.Purpose == purpose && token.CreatedAt.After(last) {
+			last = token.CreatedAt
+		}
+	}
+	if last.IsZero() {
+		return time.Time{}, domain.ErrNotFound
+	}
+	return last, nil
+}
+
+type fakeMailer struct {
+	sent []mailer.Message
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// tokenFromLink extracts the token query parameter from the last link in the message body.
+func tokenFromLink(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+	for _, field := range strings.Fields(msg.Body) {
+		if parsed, err := url.Parse(field); err == nil && parsed.Query().Get("token") != "" {
+			return parsed.Query().Get("token")
+		}
+	}
+	t.Fatalf("no token link in message: %q", msg.Body)
+	return ""
+}
+
+func newResetFixture(t *testing.T) (*authsvc.Service, *fakeMailer, *fakeUserTokenRepo) {
+	t.Helper()
+	service, _, _ := newLoginFixture(t)
+	mail := &fakeMailer{}
+	userTokens := newFakeUserTokenRepo()
+	service.WithUserTokens(userTokens)
+	service.WithMailer(mail, "https://app.example.com/")
+	return service, mail, userTokens
+}
+
+func TestPasswordResetFlow(t *testing.T) {
+	service, mail, userTokens := newResetFixture(t)
+	ctx := context.Background()
+
+	tokens, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	if err := service.RequestPasswordReset(ctx, "User@Example.com", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("request reset failed: %v", err)
+	}
+	if len(mail.sent) != 1 || mail.sent[0].To != "user@example.com" {
+		t.Fatalf("expected one reset email, got %+v", mail.sent)
+	}
+	if !strings.Contains(mail.sent[0].Body, "https://app.example.com/reset-password?token=") {
+		t.Fatalf("expected reset link in body, got %q", mail.sent[0].Body)
+	}
+	resetToken := tokenFromLink(t, mail.sent[0])
+	for _, stored := range userTokens.tokens {
+		if stored.TokenHash == resetToken {
+			t.Fatal("reset token must not be stored in plaintext")
+		}
+	}
+
+	if err := service.ResetPassword(ctx, resetToken, "new-password"); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	if _, err := service.Login(ctx, "user@example.com", "new-password", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("expected login with new password, got %v", err)
+	}
+	if _, err := service.Refresh(ctx, tokens.RefreshToken); err != authsvc.ErrInvalidRefreshToken {
+		t.Fatalf("expected sessions to be revoked, got %v", err)
+	}
+	if err := service.ResetPassword(ctx, resetToken, "another-password"); err != authsvc.ErrInvalidResetToken {
+		t.Fatalf("expected reset token to be single use, got %v", err)
+	}
+}
+
+func TestPasswordResetUnknownEmailIsSilent(t *testing.T) {
+	service, mail, _ := newResetFixture(t)
+
+	if err := service.RequestPasswordReset(context.Background(), "nobody@example.com", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("expected no error for unknown email, got %v", err)
+	}
+	if len(mail.sent) != 0 {
+		t.Fatalf("expected no email, got %d", len(mail.sent))
+	}
+}
+
+func TestPasswordResetThrottled(t *testing.T) {
+	service, mail, _ := newResetFixture(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return now })
+	policy := lockout.DefaultPolicy()
+	policy.MaxPasswordResetRequests = 3
+	guard := lockout.New(memory.NewLoginThrottleRepository(), policy)
+	guard.WithNow(func() time.Time { return now })
+	service.WithLockout(guard)
+	ctx := context.Background()
+	client := authsvc.ClientInfo{IP: "10.0.0.9"}
+
+	for i := 0; i < 2; i++ {
+		if err := service.RequestPasswordReset(ctx, "user@example.com", client); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	if len(mail.sent) != 1 {
+		t.Fatalf("expected a second request within the interval to be dropped, got %d emails", len(mail.sent))
+	}
+
+	now = now.Add(time.Minute)
+	if err := service.RequestPasswordReset(ctx, "nobody@example.com", client); err != nil {
+		t.Fatalf("request 3: %v", err)
+	}
+	var blocked *lockout.BlockedError
+	if err := service.RequestPasswordReset(ctx, "user@example.com", client); !errors.As(err, &blocked) {
+		t.Fatalf("expected requests from the IP to be rate limited, got %v", err)
+	}
+	if err := service.RequestPasswordReset(ctx, "user@example.com", authsvc.ClientInfo{IP: "10.0.0.10"}); err != nil {
+		t.Fatalf("expected another IP to be unaffected, got %v", err)
+	}
+	if len(mail.sent) != 2 {
+		t.Fatalf("expected a new link after the interval, got %d emails", len(mail.sent))
+	}
+}
+
+func TestPasswordResetExpires(t *testing.T) {
+	service, mail, _ := newResetFixture(t)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return start })
+
+	if err := service.RequestPasswordReset(context.Background(), "user@example.com", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("request reset failed: %v", err)
+	}
+	resetToken := tokenFromLink(t, mail.sent[0])
+
+	service.WithNow(func() time.Time { return start.Add(2 * time.Hour) })
+	if err := service.ResetPassword(context.Background(), resetToken, "new-password"); err != authsvc.ErrInvalidResetToken {
+		t.Fatalf("expected ErrInvalidResetToken, got %v", err)
+	}
+}
+
+func TestPasswordResetPolicyKeepsToken(t *testing.T) {
+	service, mail, _ := newResetFixture(t)
+	ctx := context.Background()
+
+	if err := service.RequestPasswordReset(ctx, "user@example.com", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("request reset failed: %v", err)
+	}
+	resetToken := tokenFromLink(t, mail.sent[0])
+
+	if err := service.ResetPassword(ctx, resetToken, "my-user-password"); !errors.Is(err, authsvc.ErrWeakPassword) {
+		t.Fatalf("expected password containing the email to be rejected, got %v", err)
+	}
+	if err := service.ResetPassword(ctx, resetToken, "new-password"); err != nil {
+		t.Fatalf("expected token to survive a rejected password, got %v", err)
+	}
+}
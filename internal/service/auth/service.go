@@ -8,7 +8,11 @@ import (
 
 	"go-todo-service/internal/domain"
 	"go-todo-service/internal/repository"
+	"go-todo-service/internal/service/lockout"
+	projectsvc "go-todo-service/internal/service/project"
 	"go-todo-service/pkg/jwt"
+	"go-todo-service/pkg/mailer"
+	"go-todo-service/pkg/oidc"
 	"go-todo-service/pkg/password"
 	"go-todo-service/pkg/uuid"
 )
@@ -16,25 +20,84 @@ import (
 var (
 	// ErrInvalidEmail indicates a malformed or empty email address.
 	ErrInvalidEmail = errors.New("invalid email")
-	// ErrWeakPassword indicates insufficient password length.
-	ErrWeakPassword = errors.New("password must be at least 6 characters")
+	// ErrWeakPassword matches every *password.PolicyError, which lists the
+	// rules a rejected password failed.
+	ErrWeakPassword = password.ErrPolicyViolation
+	// ErrInvalidRefreshToken indicates an unknown, expired or revoked refresh token.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused indicates an already rotated refresh token was presented again.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	// ErrTokenNotRevocable indicates an access token without a jti claim.
+	ErrTokenNotRevocable = errors.New("token cannot be revoked")
+	// ErrRevocationUnavailable indicates no revocation store is configured.
+	ErrRevocationUnavailable = errors.New("token revocation not configured")
+	// ErrInvalidResetToken indicates an unknown, expired or already used password reset token.
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+	// ErrAccountDisabled indicates an account blocked by an administrator.
+	ErrAccountDisabled = errors.New("account disabled")
+	// ErrPasswordResetRequired indicates an administrator requires the user to
+	// choose a new password through the reset flow before logging in.
+	ErrPasswordResetRequired = errors.New("password reset required")
 )
 
 // Service provides authentication use-cases.
 type Service struct {
-	users     repository.UserRepository
-	jwtSecret string
-	tokenTTL  time.Duration
-	now       func() time.Time
+	users         repository.UserRepository
+	refreshTokens repository.RefreshTokenRepository
+	revokedTokens repository.RevokedTokenRepository
+	userTokens    repository.UserTokenRepository
+	mailer        mailer.Mailer
+	linkBaseURL   string
+	verification  VerificationPolicy
+	mfa           repository.MFARepository
+	mfaIssuer     string
+	lockout       *lockout.Service
+	projects      *projectsvc.Service
+	sessions      repository.SessionRepository
+	accessTokens  repository.PersonalAccessTokenRepository
+	oidc          *oidc.Provider
+	identities    repository.IdentityRepository
+	oidcStateKey  []byte
+	status        *ttlCache[statusEntry]
+	sessionStatus *ttlCache[sessionEntry]
+	hasher        *password.Hasher
+	policy        password.Policy
+	keys          *jwt.KeySet
+	tokenTTL      time.Duration
+	refreshTTL    time.Duration
+	now           func() time.Time
+}
+
+// Tokens is the credential set issued on login and refresh.
+type Tokens struct {
+	AccessToken     string
+	AccessExpiresAt time.Time
+	// RefreshToken is empty when refresh tokens are not configured.
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+	// MFAToken is returned by Login instead of the tokens above when the
+	// account has two-factor authentication enabled. It must be exchanged
+	// via VerifyMFA together with a code.
+	MFAToken     string
+	MFAExpiresAt time.Time
+}
+
+// ClientInfo describes the client behind an authentication request.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
 }
 
 // New constructs a Service instance.
-func New(users repository.UserRepository, jwtSecret string, tokenTTL time.Duration) *Service {
+func New(users repository.UserRepository, keys *jwt.KeySet, tokenTTL time.Duration) *Service {
 	return &Service{
-		users:     users,
-		jwtSecret: jwtSecret,
-		tokenTTL:  tokenTTL,
-		now:       time.Now,
+		users:        users,
+		keys:         keys,
+		tokenTTL:     tokenTTL,
+		verification: VerificationOptional,
+		hasher:       password.DefaultHasher(),
+		policy:       password.DefaultPolicy(),
+		now:          time.Now,
 	}
 }
 
@@ -45,14 +108,90 @@ func (s *Service) WithNow(fn func() time.Time) {
 	}
 }
 
+// WithRefreshTokens enables refresh tokens stored in the repository with the given lifetime.
+func (s *Service) WithRefreshTokens(store repository.RefreshTokenRepository, ttl time.Duration) {
+	if store != nil && ttl > 0 {
+		s.refreshTokens = store
+		s.refreshTTL = ttl
+	}
+}
+
+// WithRevokedTokens enables access-token revocation on logout.
+func (s *Service) WithRevokedTokens(store repository.RevokedTokenRepository) {
+	if store != nil {
+		s.revokedTokens = store
+	}
+}
+
+// WithAccessTokens lets administrators revoke a user's personal access
+// tokens along with their sessions.
+func (s *Service) WithAccessTokens(store repository.PersonalAccessTokenRepository) {
+	if store != nil {
+		s.accessTokens = store
+	}
+}
+
+// WithUserTokens enables flows built on emailed single-use tokens.
+func (s *Service) WithUserTokens(store repository.UserTokenRepository) {
+	if store != nil {
+		s.userTokens = store
+	}
+}
+
+// WithMailer configures outgoing email. Links in messages are built from
+// linkBaseURL, typically the address of the web client.
+func (s *Service) WithMailer(m mailer.Mailer, linkBaseURL string) {
+	if m != nil {
+		s.mailer = m
+		s.linkBaseURL = strings.TrimRight(linkBaseURL, "/")
+	}
+}
+
+// WithMFA enables TOTP two-factor authentication. issuer names the service
+// in authenticator apps. MFA also needs WithRevokedTokens, which makes login
+// challenges single use; without it enrollment and verification report
+// ErrMFANotConfigured.
+func (s *Service) WithMFA(store repository.MFARepository, issuer string) {
+	if store != nil {
+		s.mfa = store
+		s.mfaIssuer = issuer
+	}
+}
+
+// WithPasswordHasher overrides the hashing parameters for new and upgraded hashes.
+func (s *Service) WithPasswordHasher(h *password.Hasher) {
+	if h != nil {
+		s.hasher = h
+	}
+}
+
+// WithPasswordPolicy sets the rules new passwords must satisfy.
+func (s *Service) WithPasswordPolicy(policy password.Policy) {
+	s.policy = policy
+}
+
+// WithProjects creates each new user's inbox project at signup.
+func (s *Service) WithProjects(projects *projectsvc.Service) {
+	if projects != nil {
+		s.projects = projects
+	}
+}
+
+// WithLockout enables failed-attempt tracking on Login and VerifyMFA.
+func (s *Service) WithLockout(l *lockout.Service) {
+	if l != nil {
+		s.lockout = l
+	}
+}
+
 // Signup registers a new user.
 func (s *Service) Signup(ctx context.Context, email, plainPassword string) (*domain.User, error) {
 	email = strings.TrimSpace(strings.ToLower(email))
 	if email == "" || !strings.Contains(email, "@") {
 		return nil, ErrInvalidEmail
 	}
-	if len(plainPassword) < 6 {
-		return nil, ErrWeakPassword
+	if err := s.policy.Check(plainPassword, email); err != nil {
+		return nil, err
 	}
 
 	if existing, err := s.users.GetByEmail(ctx, email); err == nil && existing != nil {
@@ -61,7 +200,7 @@ func (s *Service) Signup(ctx context.Context, email, plainPassword string) (*dom
 		return nil, err
 	}
 
-	hashed, err := password.Hash(plainPassword)
+	hashed, err := s.hasher.Hash(plainPassword)
 	if err != nil {
 		return nil, err
 	}
@@ -76,6 +215,9 @@ func (s *Service) Signup(ctx context.Context, email, plainPassword string) (*dom
 		ID:           id,
 		Email:        email,
 		PasswordHash: hashed,
+		TimeZone:     domain.DefaultTimeZone,
+		Locale:       domain.DefaultLocale,
+		Role:         domain.RoleUser,
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}
@@ -83,42 +225,127 @@ func (s *Service) Signup(ctx context.Context, email, plainPassword string) (*dom
 	if err := s.users.Create(ctx, user); err != nil {
 		return nil, err
 	}
+	s.createInbox(ctx, user.ID)
 
 	return &domain.User{
-		ID:        user.ID,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:              user.ID,
+		Email:           user.Email,
+		TimeZone:        user.TimeZone,
+		Locale:          user.Locale,
+		Role:            user.Role,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
 	}, nil
 }
 
-// Login verifies credentials and returns a signed JWT.
-func (s *Service) Login(ctx context.Context, email, plainPassword string) (string, error) {
+// Login verifies credentials and returns a signed JWT together with a refresh
+// token, or only an MFA challenge token when two-factor authentication is
+// enabled. With lockout configured, refused attempts return a
+// *lockout.BlockedError.
+func (s *Service) Login(ctx context.Context, email, plainPassword string, client ClientInfo) (*Tokens, error) {
 	email = strings.TrimSpace(strings.ToLower(email))
 	if email == "" || plainPassword == "" {
-		return "", domain.ErrInvalidCredentials
+		return nil, domain.ErrInvalidCredentials
+	}
+	if s.lockout != nil {
+		if err := s.lockout.Check(ctx, email, client.IP); err != nil {
+			return nil, err
+		}
 	}
 
 	user, err := s.users.GetByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
-			return "", domain.ErrInvalidCredentials
+			return nil, s.loginFailed(ctx, email, client)
 		}
-		return "", err
+		return nil, err
 	}
 
-	ok, err := password.Compare(user.PasswordHash, plainPassword)
+	ok, err := s.checkPassword(user, plainPassword)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if !ok {
-		return "", domain.ErrInvalidCredentials
+		return nil, s.loginFailed(ctx, email, client)
+	}
+	// Checked only after the password so the state is not revealed to
+	// someone guessing.
+	if err := s.checkAccountActive(user); err != nil {
+		return nil, err
+	}
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
+	if s.hasher.NeedsRehash(user.PasswordHash) {
+		if err := s.rehashPassword(ctx, user.ID, plainPassword); err != nil {
+			return nil, err
+		}
+	}
+	required, err := s.mfaRequired(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	// With a second factor the account counter is cleared by VerifyMFA, so
+	// wrong codes keep counting however often the password is entered.
+	if !required && s.lockout != nil {
+		if err := s.lockout.RecordSuccess(ctx, email); err != nil {
+			return nil, err
+		}
 	}
+	if s.verification == VerificationRequiredForLogin && user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
+	if required {
+		return s.issueMFAChallenge(user.ID)
+	}
+
+	return s.issueTokens(ctx, user, "", client)
+}
+
+// checkAccountActive refuses accounts an administrator has disabled or
+// suspended.
+func (s *Service) checkAccountActive(user *domain.User) error {
+	if user.Disabled(s.now()) {
+		return ErrAccountDisabled
+	}
+	return nil
+}
+
+// checkPassword compares a password with the stored hash. Accounts created
+// through single sign-on have no password and never match.
+func (s *Service) checkPassword(user *domain.User, plainPassword string) (bool, error) {
+	if user.PasswordHash == "" {
+		return false, nil
+	}
+	return s.hasher.Compare(user.PasswordHash, plainPassword)
+}
 
-	token, err := jwt.GenerateToken(user.ID, s.jwtSecret, s.tokenTTL, s.now())
+// rehashPassword upgrades a stored hash to the current algorithm and
+// parameters while the plaintext is at hand.
+func (s *Service) rehashPassword(ctx context.Context, userID, plainPassword string) error {
+	hashed, err := s.hasher.Hash(plainPassword)
 	if err != nil {
-		return "", err
+		return err
+	}
+	return s.users.UpdatePassword(ctx, userID, hashed, s.now().UTC())
+}
+
+// loginFailed counts a failed attempt and returns the error to report.
+// Unknown emails are counted too so lockouts do not reveal which exist.
+func (s *Service) loginFailed(ctx context.Context, email string, client ClientInfo) error {
+	if s.lockout != nil {
+		if err := s.lockout.RecordFailure(ctx, email, client.IP); err != nil {
+			return err
+		}
 	}
+	return domain.ErrInvalidCredentials
+}
 
-	return token, nil
+// createInbox sets up a new user's inbox project. The inbox is also created
+// on first use, so a failure here does not fail the signup.
+func (s *Service) createInbox(ctx context.Context, userID string) {
+	if s.projects != nil {
+		_, _ = s.projects.Inbox(ctx, userID)
+	}
 }
@@ -2,11 +2,17 @@ package auth_test
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository"
+	"go-todo-service/internal/repository/memory"
 	authsvc "go-todo-service/internal/service/auth"
+	"go-todo-service/pkg/jwt"
 	"go-todo-service/pkg/password"
 )
 
@@ -47,9 +53,169 @@ func (r *fakeUserRepo) GetByID(ctx context.Context, id string) (*domain.User, er
 	return nil, domain.ErrNotFound
 }
 
+func (r *fakeUserRepo) UpdatePassword(ctx context.Context, id, passwordHash string, at time.Time) error {
+	for _, user := range r.users {
+		if user.ID == id {
+			user.PasswordHash = passwordHash
+			user.PasswordResetRequired = false
+			user.UpdatedAt = at
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (r *fakeUserRepo) MarkEmailVerified(ctx context.Context, id string, at time.Time) error {
+	for _, user := range r.users {
+		if user.ID == id {
+			if user.EmailVerifiedAt == nil {
+				user.EmailVerifiedAt = &at
+			}
+			user.UpdatedAt = at
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (r *fakeUserRepo) Update(ctx context.Context, user *domain.User, unmodifiedSince time.Time) error {
+	for email, existing := range r.users {
+		if existing.ID != user.ID {
+			continue
+		}
+		if !existing.UpdatedAt.Equal(unmodifiedSince) {
+			return domain.ErrConcurrentUpdate
+		}
+		if other, taken := r.users[user.Email]; taken && other.ID != user.ID {
+			return domain.ErrConflict
+		}
+		delete(r.users, email)
+		u := *user
+		u.PasswordHash = existing.PasswordHash
+		r.users[user.Email] = &u
+		return nil
+	}
+	return domain.ErrNotFound
+}
+
+func (r *fakeUserRepo) List(ctx context.Context, opts repository.UserListOptions) ([]domain.User, error) {
+	var out []domain.User
+	for _, user := range r.users {
+		if user.Email > opts.AfterEmail && strings.Contains(user.Email+" "+user.DisplayName, opts.Query) {
+			out = append(out, *user)
+		}
+	}
+	slices.SortFunc(out, func(a, b domain.User) int { return strings.Compare(a.Email, b.Email) })
+	if opts.Limit > 0 && len(out) > opts.Limit {
+		out = out[:opts.Limit]
+	}
+	return out, nil
+}
+
+func (r *fakeUserRepo) Delete(ctx context.Context, id string) error {
+	for email, user := range r.users {
+		if user.ID == id {
+			delete(r.users, email)
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+type fakeRefreshTokenRepo struct {
+	tokens map[string]*domain.RefreshToken
+}
+
+func newFakeRefreshTokenRepo() *fakeRefreshTokenRepo {
+	return &fakeRefreshTokenRepo{
+		tokens: make(map[string]*domain.RefreshToken),
+	}
+}
+
+func (r *fakeRefreshTokenRepo) Create(ctx context.Context, token *domain.RefreshToken) error {
+	t := *token
+	r.tokens[token.ID] = &t
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) GetByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			t := *token
+			return &t, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *fakeRefreshTokenRepo) MarkRotated(ctx context.Context, id string, at time.Time) error {
+	token, ok := r.tokens[id]
+	if !ok || token.RotatedAt != nil || token.RevokedAt != nil {
+		return domain.ErrConflict
+	}
+	token.RotatedAt = &at
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	for _, token := range r.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) RevokeByUser(ctx context.Context, userID string, at time.Time) error {
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) RevokeOtherFamilies(ctx context.Context, userID, keepFamilyID string, at time.Time) error {
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.FamilyID != keepFamilyID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func newTestKeySet(t *testing.T) *jwt.KeySet {
+	t.Helper()
+	key, err := jwt.NewHMACKey("test", []byte("secret"))
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	keys, err := jwt.NewKeySet("test", key)
+	if err != nil {
+		t.Fatalf("key set: %v", err)
+	}
+	return keys
+}
+
+func newLoginFixture(t *testing.T) (*authsvc.Service, *fakeRefreshTokenRepo, *jwt.KeySet) {
+	t.Helper()
+	repo := newFakeUserRepo()
+	hashed, _ := password.Hash("password")
+	repo.users["user@example.com"] = &domain.User{
+		ID:           "abc",
+		Email:        "user@example.com",
+		PasswordHash: hashed,
+	}
+	refreshTokens := newFakeRefreshTokenRepo()
+	keys := newTestKeySet(t)
+	service := authsvc.New(repo, keys, 15*time.Minute)
+	service.WithRefreshTokens(refreshTokens, 24*time.Hour)
+	return service, refreshTokens, keys
+}
+
 func TestSignupSuccess(t *testing.T) {
 	repo := newFakeUserRepo()
-	service := authsvc.New(repo, "secret", 15*time.Minute)
+	service := authsvc.New(repo, newTestKeySet(t), 15*time.Minute)
 	fixed := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
 	service.WithNow(func() time.Time { return fixed })
 
@@ -67,7 +233,7 @@ func TestSignupSuccess(t *testing.T) {
 
 func TestSignupDuplicateEmail(t *testing.T) {
 	repo := newFakeUserRepo()
-	service := authsvc.New(repo, "secret", 15*time.Minute)
+	service := authsvc.New(repo, newTestKeySet(t), 15*time.Minute)
 	service.WithNow(func() time.Time { return time.Now() })
 
 	_, err := service.Signup(context.Background(), "dup@example.com", "password")
@@ -92,14 +258,14 @@ func TestLogin(t *testing.T) {
 		PasswordHash: hashed,
 	}
 
-	service := authsvc.New(repo, "secret", 15*time.Minute)
+	service := authsvc.New(repo, newTestKeySet(t), 15*time.Minute)
 	service.WithNow(func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) })
 
-	token, err := service.Login(context.Background(), "user@example.com", "password")
+	tokens, err := service.Login(context.Background(), "user@example.com", "password", authsvc.ClientInfo{})
 	if err != nil {
 		t.Fatalf("expected successful login, got %v", err)
 	}
-	if token == "" {
+	if tokens.AccessToken == "" {
 		t.Fatal("expected token")
 	}
 }
@@ -113,8 +279,8 @@ func TestLoginInvalidPassword(t *testing.T) {
 		PasswordHash: hashed,
 	}
 
-	service := authsvc.New(repo, "secret", 15*time.Minute)
-	_, err := service.Login(context.Background(), "user@example.com", "wrong")
+	service := authsvc.New(repo, newTestKeySet(t), 15*time.Minute)
+	_, err := service.Login(context.Background(), "user@example.com", "wrong", authsvc.ClientInfo{})
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -122,3 +288,111 @@ func TestLoginInvalidPassword(t *testing.T) {
 		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
 	}
 }
+
+func TestRefreshRotatesToken(t *testing.T) {
+	service, refreshTokens, _ := newLoginFixture(t)
+
+	tokens, err := service.Login(context.Background(), "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if tokens.RefreshToken == "" {
+		t.Fatal("expected refresh token")
+	}
+	for _, stored := range refreshTokens.tokens {
+		if stored.TokenHash == tokens.RefreshToken {
+			t.Fatal("refresh token must not be stored in plaintext")
+		}
+	}
+
+	rotated, err := service.Refresh(context.Background(), tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if rotated.AccessToken == "" || rotated.RefreshToken == "" || rotated.RefreshToken == tokens.RefreshToken {
+		t.Fatalf("expected new token pair, got %+v", rotated)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	service, _, _ := newLoginFixture(t)
+
+	tokens, err := service.Login(context.Background(), "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	rotated, err := service.Refresh(context.Background(), tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+
+	if _, err := service.Refresh(context.Background(), tokens.RefreshToken); err != authsvc.ErrRefreshTokenReused {
+		t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+	}
+	if _, err := service.Refresh(context.Background(), rotated.RefreshToken); err != authsvc.ErrInvalidRefreshToken {
+		t.Fatalf("expected successor to be revoked, got %v", err)
+	}
+}
+
+func TestRefreshExpired(t *testing.T) {
+	service, _, _ := newLoginFixture(t)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return start })
+
+	tokens, err := service.Login(context.Background(), "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	service.WithNow(func() time.Time { return start.Add(25 * time.Hour) })
+	if _, err := service.Refresh(context.Background(), tokens.RefreshToken); err != authsvc.ErrInvalidRefreshToken {
+		t.Fatalf("expected ErrInvalidRefreshToken, got %v", err)
+	}
+}
+
+func TestLogoutRevokesAccessAndRefreshTokens(t *testing.T) {
+	service, _, keys := newLoginFixture(t)
+	revoked := memory.NewRevokedTokenRepository()
+	service.WithRevokedTokens(revoked)
+	now := time.Now()
+
+	tokens, err := service.Login(context.Background(), "user@example.com", "password", authsvc.ClientInfo{})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	claims, err := jwt.ParseAndValidate(tokens.AccessToken, keys, now)
+	if err != nil {
+		t.Fatalf("token invalid: %v", err)
+	}
+	if claims.ID == "" {
+		t.Fatal("expected jti claim")
+	}
+
+	if err := service.Logout(context.Background(), claims, tokens.RefreshToken); err != nil {
+		t.Fatalf("logout failed: %v", err)
+	}
+	if ok, _ := revoked.IsRevoked(context.Background(), claims.ID); !ok {
+		t.Fatal("expected access token to be revoked")
+	}
+	if _, err := service.Refresh(context.Background(), tokens.RefreshToken); err != authsvc.ErrInvalidRefreshToken {
+		t.Fatalf("expected refresh token to be revoked, got %v", err)
+	}
+}
+
+func TestSignupPasswordPolicy(t *testing.T) {
+	service := authsvc.New(newFakeUserRepo(), newTestKeySet(t), 15*time.Minute)
+	service.WithPasswordPolicy(password.Policy{MinLength: 12, MaxLength: 64})
+
+	_, err := service.Signup(context.Background(), "jane.doe@example.com", "jane.doe-1")
+	var policyErr *password.PolicyError
+	if !errors.As(err, &policyErr) || !errors.Is(err, authsvc.ErrWeakPassword) {
+		t.Fatalf("expected policy error, got %v", err)
+	}
+	if len(policyErr.Violations) != 2 || policyErr.Violations[0].Code != password.ViolationTooShort || policyErr.Violations[1].Code != password.ViolationContainsEmail {
+		t.Fatalf("unexpected violations %+v", policyErr.Violations)
+	}
+
+	if _, err := service.Signup(context.Background(), "jane.doe@example.com", "a much longer passphrase"); err != nil {
+		t.Fatalf("expected signup to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository"
+	"go-todo-service/pkg/uuid"
+)
+
+const (
+	// lastSeenResolution bounds how often session activity is written.
+	lastSeenResolution = time.Minute
+	// maxUserAgentLength bounds the stored user agent in bytes.
+	maxUserAgentLength = 512
+)
+
+var (
+	// ErrSessionRevoked indicates an access token whose session has ended.
+	ErrSessionRevoked = errors.New("session revoked")
+	// ErrSessionsUnavailable indicates no session store is configured.
+	ErrSessionsUnavailable = errors.New("sessions not configured")
+)
+
+// WithSessions records a session per login so users can list and end them.
+func (s *Service) WithSessions(store repository.SessionRepository) {
+	if store != nil {
+		s.sessions = store
+	}
+}
+
+// ListSessions returns the user's active sessions, most recently seen first.
+func (s *Service) ListSessions(ctx context.Context, userID string) ([]domain.Session, error) {
+	if s.sessions == nil {
+		return nil, ErrSessionsUnavailable
+	}
+	return s.sessions.ListActiveByUser(ctx, userID, s.now().UTC())
+}
+
+// RevokeSession ends one of the user's sessions. Its refresh tokens stop
+// working at once and its access tokens are refused by CheckSession.
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	if s.sessions == nil {
+		return ErrSessionsUnavailable
+	}
+	if !uuid.Valid(sessionID) {
+		return domain.ErrNotFound
+	}
+	return s.endSession(ctx, userID, sessionID)
+}
+
+type sessionEntry struct {
+	userID  string
+	revoked bool
+}
+
+// CheckSession reports whether access tokens carrying sessionID may still be
+// used by userID. It returns ErrSessionRevoked once the session has ended.
+// Tokens without a session, and all tokens while sessions are not
+// configured, pass.
+func (s *Service) CheckSession(ctx context.Context, userID, sessionID string) error {
+	if s.sessions == nil || sessionID == "" {
+		return nil
+	}
+	now := s.now()
+	entry, ok := s.sessionStatus.get(sessionID, now)
+	if !ok {
+		session, err := s.sessions.GetByID(ctx, sessionID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			entry = sessionEntry{revoked: true}
+		case err != nil:
+			return err
+		default:
+			entry = sessionEntry{userID: session.UserID, revoked: session.RevokedAt != nil}
+			if !entry.revoked && now.Sub(session.LastSeenAt) >= lastSeenResolution {
+				if err := s.sessions.Touch(ctx, sessionID, now.UTC()); err != nil {
+					return err
+				}
+			}
+		}
+		s.sessionStatus.put(sessionID, entry, now)
+	}
+
+	if entry.revoked || entry.userID != userID {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// recordSession stores a new session or renews an existing one after tokens
+// have been issued for it.
+func (s *Service) recordSession(ctx context.Context, userID, sessionID string, isNew bool, client ClientInfo, expiresAt time.Time) error {
+	if s.sessions == nil {
+		return nil
+	}
+	now := s.now().UTC()
+	if !isNew {
+		return s.sessions.Renew(ctx, sessionID, now, expiresAt)
+	}
+	userAgent := client.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	return s.sessions.Create(ctx, &domain.Session{
+		ID:         sessionID,
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  expiresAt,
+	})
+}
+
+// endSession revokes a session together with its refresh token family. It
+// returns domain.ErrNotFound when the session is unknown or already ended;
+// the family is revoked regardless.
+func (s *Service) endSession(ctx context.Context, userID, sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+	now := s.now().UTC()
+	var result error
+	if s.sessions != nil {
+		result = s.sessions.Revoke(ctx, userID, sessionID, now)
+		if result != nil && !errors.Is(result, domain.ErrNotFound) {
+			return result
+		}
+		s.sessionStatus.forget(sessionID)
+	}
+	if s.refreshTokens != nil {
+		if err := s.refreshTokens.RevokeFamily(ctx, sessionID, now); err != nil {
+			return err
+		}
+	}
+	return result
+}
+
+// revokeSessions ends every session of the user.
+func (s *Service) revokeSessions(ctx context.Context, userID string) error {
+	now := s.now().UTC()
+	if s.refreshTokens != nil {
+		if err := s.refreshTokens.RevokeByUser(ctx, userID, now); err != nil {
+			return err
+		}
+	}
+	if s.sessions == nil {
+		return nil
+	}
+	if err := s.sessions.RevokeByUser(ctx, userID, now); err != nil {
+		return err
+	}
+	s.forgetSessions(userID)
+	return nil
+}
+
+// revokeOtherSessions ends every session of the user except keepID.
+func (s *Service) revokeOtherSessions(ctx context.Context, userID, keepID string) error {
+	now := s.now().UTC()
+	if s.refreshTokens != nil {
+		if err := s.refreshTokens.RevokeOtherFamilies(ctx, userID, keepID, now); err != nil {
+			return err
+		}
+	}
+	if s.sessions == nil {
+		return nil
+	}
+	if err := s.sessions.RevokeOthers(ctx, userID, keepID, now); err != nil {
+		return err
+	}
+	s.forgetSessions(userID)
+	return nil
+}
+
+// forgetSessions drops cached session state of the user.
+func (s *Service) forgetSessions(userID string) {
+	s.sessionStatus.forgetFunc(func(entry sessionEntry) bool {
+		return entry.userID == userID
+	})
+}
@@ -0,0 +1,201 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-todo-service/internal/domain"
+	authsvc "go-todo-service/internal/service/auth"
+	"go-todo-service/pkg/jwt"
+)
+
+type fakeSessionRepo struct {
+	sessions map[string]*domain.Session
+}
+
+func newFakeSessionRepo() *fakeSessionRepo {
+	return &fakeSessionRepo{sessions: make(map[string]*domain.Session)}
+}
+
+func (r *fakeSessionRepo) Create(ctx context.Context, session *domain.Session) error {
+	if _, exists := r.sessions[session.ID]; exists {
+		return domain.ErrConflict
+	}
+	s := *session
+	r.sessions[session.ID] = &s
+	return nil
+}
+
+func (r *fakeSessionRepo) GetByID(ctx context.Context, id string) (*domain.Session, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	s := *session
+	return &s, nil
+}
+
+func (r *fakeSessionRepo) ListActiveByUser(ctx context.Context, userID string, now time.Time) ([]domain.Session, error) {
+	var sessions []domain.Session
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			sessions = append(sessions, *session)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *fakeSessionRepo) Touch(ctx context.Context, id string, at time.Time) error {
+	if session, ok := r.sessions[id]; ok && session.RevokedAt == nil {
+		session.LastSeenAt = at
+	}
+	return nil
+}
+
+func (r *fakeSessionRepo) Renew(ctx context.Context, id string, at, expiresAt time.Time) error {
+	if session, ok := r.sessions[id]; ok && session.RevokedAt == nil {
+		session.LastSeenAt = at
+		session.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+func (r *fakeSessionRepo) Revoke(ctx context.Context, userID, id string, at time.Time) error {
+	session, ok := r.sessions[id]
+	if !ok || session.UserID != userID || session.RevokedAt != nil {
+		return domain.ErrNotFound
+	}
+	session.RevokedAt = &at
+	return nil
+}
+
+func (r *fakeSessionRepo) RevokeByUser(ctx context.Context, userID string, at time.Time) error {
+	return r.RevokeOthers(ctx, userID, "", at)
+}
+
+func (r *fakeSessionRepo) RevokeOthers(ctx context.Context, userID, keepID string, at time.Time) error {
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.ID != keepID && session.RevokedAt == nil {
+			session.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func newSessionFixture(t *testing.T) (*authsvc.Service, *fakeSessionRepo, *jwt.KeySet) {
+	t.Helper()
+	service, _, keys := newLoginFixture(t)
+	sessions := newFakeSessionRepo()
+	service.WithSessions(sessions)
+	service.WithAccountStatusCache(time.Minute)
+	return service, sessions, keys
+}
+
+func loginSession(t *testing.T, service *authsvc.Service, keys *jwt.KeySet, client authsvc.ClientInfo) (*authsvc.Tokens, *jwt.Claims) {
+	t.Helper()
+	tokens, err := service.Login(context.Background(), "user@example.com", "password", client)
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	claims, err := jwt.ParseAndValidate(tokens.AccessToken, keys, time.Now())
+	if err != nil {
+		t.Fatalf("token invalid: %v", err)
+	}
+	return tokens, claims
+}
+
+func TestLoginRecordsSession(t *testing.T) {
+	service, sessions, keys := newSessionFixture(t)
+	ctx := context.Background()
+
+	tokens, claims := loginSession(t, service, keys, authsvc.ClientInfo{IP: "203.0.113.7", UserAgent: "phone"})
+	session, ok := sessions.sessions[claims.SessionID]
+	if !ok {
+		t.Fatalf("expected session %q to be recorded", claims.SessionID)
+	}
+	if session.UserID != "abc" || session.IP != "203.0.113.7" || session.UserAgent != "phone" {
+		t.Fatalf("unexpected session %+v", session)
+	}
+	if !session.ExpiresAt.Equal(tokens.RefreshExpiresAt) {
+		t.Fatalf("expected session to expire with the refresh token, got %v", session.ExpiresAt)
+	}
+
+	// Refreshing keeps the session.
+	refreshed, err := service.Refresh(ctx, tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	refreshedClaims, err := jwt.ParseAndValidate(refreshed.AccessToken, keys, time.Now())
+	if err != nil {
+		t.Fatalf("token invalid: %v", err)
+	}
+	if refreshedClaims.SessionID != claims.SessionID {
+		t.Fatalf("expected refresh to keep session %q, got %q", claims.SessionID, refreshedClaims.SessionID)
+	}
+
+	listed, err := service.ListSessions(ctx, "abc")
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(listed) != 1 || listed[0].ID != claims.SessionID {
+		t.Fatalf("unexpected sessions %+v", listed)
+	}
+}
+
+func TestRevokeSessionStopsItsTokens(t *testing.T) {
+	service, _, keys := newSessionFixture(t)
+	ctx := context.Background()
+
+	lostTokens, lost := loginSession(t, service, keys, authsvc.ClientInfo{UserAgent: "lost phone"})
+	_, current := loginSession(t, service, keys, authsvc.ClientInfo{UserAgent: "laptop"})
+	if err := service.CheckSession(ctx, "abc", lost.SessionID); err != nil {
+		t.Fatalf("expected active session, got %v", err)
+	}
+
+	if err := service.RevokeSession(ctx, "other-user", lost.SessionID); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for another user's session, got %v", err)
+	}
+	if err := service.RevokeSession(ctx, "abc", lost.SessionID); err != nil {
+		t.Fatalf("revoke failed: %v", err)
+	}
+	if err := service.CheckSession(ctx, "abc", lost.SessionID); !errors.Is(err, authsvc.ErrSessionRevoked) {
+		t.Fatalf("expected ErrSessionRevoked, got %v", err)
+	}
+	if _, err := service.Refresh(ctx, lostTokens.RefreshToken); err != authsvc.ErrInvalidRefreshToken {
+		t.Fatalf("expected refresh token of revoked session to fail, got %v", err)
+	}
+	if err := service.RevokeSession(ctx, "abc", lost.SessionID); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for an ended session, got %v", err)
+	}
+
+	if err := service.CheckSession(ctx, "abc", current.SessionID); err != nil {
+		t.Fatalf("expected other session to stay active, got %v", err)
+	}
+	if err := service.CheckSession(ctx, "other-user", current.SessionID); !errors.Is(err, authsvc.ErrSessionRevoked) {
+		t.Fatalf("expected session of another user to be refused, got %v", err)
+	}
+}
+
+func TestChangePasswordEndsOtherSessions(t *testing.T) {
+	service, _, keys := newSessionFixture(t)
+	ctx := context.Background()
+
+	_, other := loginSession(t, service, keys, authsvc.ClientInfo{})
+	_, current := loginSession(t, service, keys, authsvc.ClientInfo{})
+	// Cache the other session as active before it is ended.
+	if err := service.CheckSession(ctx, "abc", other.SessionID); err != nil {
+		t.Fatalf("expected active session, got %v", err)
+	}
+
+	if err := service.ChangePassword(ctx, current, "password", "a-new-password"); err != nil {
+		t.Fatalf("change password failed: %v", err)
+	}
+	if err := service.CheckSession(ctx, "abc", other.SessionID); !errors.Is(err, authsvc.ErrSessionRevoked) {
+		t.Fatalf("expected other session to end, got %v", err)
+	}
+	if err := service.CheckSession(ctx, "abc", current.SessionID); err != nil {
+		t.Fatalf("expected current session to stay active, got %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go-todo-service/internal/domain"
+)
+
+// maxCacheEntries bounds each status cache. When one fills up expired
+// entries are evicted, and if that is not enough the cache starts over.
+const maxCacheEntries = 10000
+
+// WithAccountStatusCache lets CheckAccountActive and CheckSession reuse a
+// user's disabled state and a session's revocation for ttl instead of loading
+// them on every call. Changes made through this service take effect
+// immediately on this instance and within ttl on others.
+func (s *Service) WithAccountStatusCache(ttl time.Duration) {
+	if ttl > 0 {
+		s.status = newTTLCache[statusEntry](ttl)
+		s.sessionStatus = newTTLCache[sessionEntry](ttl)
+	}
+}
+
+type statusEntry struct {
+	missing       bool
+	disabledAt    *time.Time
+	disabledUntil *time.Time
+}
+
+// CheckAccountActive reports whether tokens issued to userID may still be
+// used. It returns ErrAccountDisabled for blocked accounts and
+// domain.ErrNotFound for deleted ones.
+func (s *Service) CheckAccountActive(ctx context.Context, userID string) error {
+	now := s.now()
+	entry, ok := s.status.get(userID, now)
+	if !ok {
+		user, err := s.users.GetByID(ctx, userID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			entry = statusEntry{missing: true}
+		case err != nil:
+			return err
+		default:
+			entry = statusEntry{disabledAt: user.DisabledAt, disabledUntil: user.DisabledUntil}
+		}
+		s.status.put(userID, entry, now)
+	}
+
+	if entry.missing {
+		return domain.ErrNotFound
+	}
+	// Evaluated on every call so a suspension lapses on time even while
+	// the entry is cached.
+	user := domain.User{DisabledAt: entry.disabledAt, DisabledUntil: entry.disabledUntil}
+	return s.checkAccountActive(&user)
+}
+
+// ttlCache holds values for a fixed time. A nil cache stores nothing.
+type ttlCache[V any] struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cached[V]
+}
+
+type cached[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+func newTTLCache[V any](ttl time.Duration) *ttlCache[V] {
+	return &ttlCache[V]{ttl: ttl, entries: make(map[string]cached[V])}
+}
+
+func (c *ttlCache[V]) get(key string, now time.Time) (V, bool) {
+	var zero V
+	if c == nil {
+		return zero, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		return zero, false
+	}
+	return entry.value, true
+}
+
+func (c *ttlCache[V]) put(key string, value V, now time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxCacheEntries {
+		for k, existing := range c.entries {
+			if !now.Before(existing.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxCacheEntries {
+			c.entries = make(map[string]cached[V])
+		}
+	}
+	c.entries[key] = cached[V]{value: value, expiresAt: now.Add(c.ttl)}
+}
+
+func (c *ttlCache[V]) forget(key string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// forgetFunc drops every entry whose value matches.
+func (c *ttlCache[V]) forgetFunc(match func(V) bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, entry := range c.entries {
+		if match(entry.value) {
+			delete(c.entries, k)
+		}
+	}
+}
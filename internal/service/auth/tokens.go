@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/pkg/jwt"
+	"go-todo-service/pkg/token"
+	"go-todo-service/pkg/uuid"
+)
+
+// Logout revokes the access token described by claims until it expires and
+// ends its session. When a refresh token belonging to the same user is
+// supplied, its family is revoked too so the session cannot be resumed.
+func (s *Service) Logout(ctx context.Context, claims *jwt.Claims, refreshToken string) error {
+	if s.revokedTokens == nil {
+		return ErrRevocationUnavailable
+	}
+	if claims == nil || claims.ID == "" {
+		return ErrTokenNotRevocable
+	}
+	if err := s.revokedTokens.Revoke(ctx, claims.ID, time.Unix(claims.ExpiresAt, 0).UTC()); err != nil {
+		return err
+	}
+	if err := s.endSession(ctx, claims.Subject, claims.SessionID); err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+
+	refreshToken = strings.TrimSpace(refreshToken)
+	if s.refreshTokens == nil || refreshToken == "" {
+		return nil
+	}
+	stored, err := s.refreshTokens.GetByHash(ctx, token.Hash(refreshToken))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if stored.UserID != claims.Subject {
+		return nil
+	}
+	return s.refreshTokens.RevokeFamily(ctx, stored.FamilyID, s.now().UTC())
+}
+
+// Refresh exchanges a refresh token for a new access token and a rotated
+// refresh token. Presenting a token that was already rotated is treated as
+// theft: the whole family is revoked and ErrRefreshTokenReused is returned.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	refreshToken = strings.TrimSpace(refreshToken)
+	if s.refreshTokens == nil || refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	stored, err := s.refreshTokens.GetByHash(ctx, token.Hash(refreshToken))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	if stored.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if stored.RotatedAt != nil {
+		return nil, s.revokeReusedFamily(ctx, stored)
+	}
+	if !now.Before(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if err := s.refreshTokens.MarkRotated(ctx, stored.ID, now); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			// A concurrent request rotated the token first.
+			return nil, s.revokeReusedFamily(ctx, stored)
+		}
+		return nil, err
+	}
+
+	user, err := s.users.GetByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if err := s.checkAccountActive(user); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, user, stored.FamilyID, ClientInfo{})
+}
+
+func (s *Service) revokeReusedFamily(ctx context.Context, stored *domain.RefreshToken) error {
+	if err := s.endSession(ctx, stored.UserID, stored.FamilyID); err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// issueTokens signs an access token and, when enabled, a refresh token. An
+// empty familyID starts a new family and, when sessions are enabled, records
+// a session for client; otherwise the existing session is renewed.
+func (s *Service) issueTokens(ctx context.Context, user *domain.User, familyID string, client ClientInfo) (*Tokens, error) {
+	now := s.now()
+	newSession := familyID == ""
+	if newSession && (s.refreshTokens != nil || s.sessions != nil) {
+		var err error
+		if familyID, err = uuid.NewString(); err != nil {
+			return nil, err
+		}
+	}
+	var plain, id string
+	if s.refreshTokens != nil {
+		var err error
+		if plain, err = token.Generate(); err != nil {
+			return nil, err
+		}
+		if id, err = uuid.NewString(); err != nil {
+			return nil, err
+		}
+	}
+
+	jti, err := uuid.NewString()
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := s.keys.Sign(jwt.Claims{
+		ID:        jti,
+		Subject:   user.ID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.tokenTTL).Unix(),
+		SessionID: familyID,
+		Role:      string(user.EffectiveRole()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	tokens := &Tokens{
+		AccessToken:     accessToken,
+		AccessExpiresAt: now.Add(s.tokenTTL).UTC(),
+	}
+	if s.refreshTokens == nil {
+		if err := s.recordSession(ctx, user.ID, familyID, newSession, client, tokens.AccessExpiresAt); err != nil {
+			return nil, err
+		}
+		return tokens, nil
+	}
+
+	refresh := &domain.RefreshToken{
+		ID:        id,
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: token.Hash(plain),
+		ExpiresAt: now.Add(s.refreshTTL).UTC(),
+		CreatedAt: now.UTC(),
+	}
+	if err := s.refreshTokens.Create(ctx, refresh); err != nil {
+		return nil, err
+	}
+
+	if err := s.recordSession(ctx, user.ID, familyID, newSession, client, refresh.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	tokens.RefreshToken = plain
+	tokens.RefreshExpiresAt = refresh.ExpiresAt
+	return tokens, nil
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/pkg/mailer"
+	"go-todo-service/pkg/token"
+)
+
+const (
+	// emailVerificationTTL bounds how long an emailed verification link stays valid.
+	emailVerificationTTL = 24 * time.Hour
+	// verificationResendInterval is the minimum gap between verification emails to one account.
+	verificationResendInterval = time.Minute
+)
+
+// VerificationPolicy controls what accounts with an unverified email may do.
+type VerificationPolicy string
+
+const (
+	// VerificationOptional lets unverified accounts use the API without restriction.
+	VerificationOptional VerificationPolicy = "off"
+	// VerificationRequiredForLogin refuses to log in unverified accounts.
+	VerificationRequiredForLogin VerificationPolicy = "login"
+	// VerificationRequiredForWrites lets unverified accounts log in and read
+	// but refuses task writes.
+	VerificationRequiredForWrites VerificationPolicy = "writes"
+)
+
+var (
+	// ErrEmailNotVerified indicates the policy requires a verified email for the action.
+	ErrEmailNotVerified = errors.New("email not verified")
+	// ErrInvalidVerificationToken indicates an unknown, expired or already used verification token.
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+)
+
+// WithVerificationPolicy sets what unverified accounts may do.
+func (s *Service) WithVerificationPolicy(policy VerificationPolicy) {
+	if policy != "" {
+		s.verification = policy
+	}
+}
+
+// SendVerificationEmail emails a verification link to the user. It does
+// nothing when the email is already verified or mail is not configured.
+func (s *Service) SendVerificationEmail(ctx context.Context, userID string) error {
+	if s.userTokens == nil || s.mailer == nil {
+		return nil
+	}
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.sendVerification(ctx, user)
+}
+
+// ResendVerificationEmail sends a fresh verification link to the address,
+// at most once per verificationResendInterval. Unknown or already verified
+// addresses and requests within the interval are ignored silently, so the
+// outcome does not reveal whether an unverified account exists.
+func (s *Service) ResendVerificationEmail(ctx context.Context, email string) error {
+	if s.userTokens == nil || s.mailer == nil {
+		return errors.New("email verification not configured")
+	}
+	email = strings.TrimSpace(strings.ToLower(email))
+	if email == "" {
+		return nil
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	last, err := s.userTokens.LastIssuedAt(ctx, user.ID, domain.TokenPurposeEmailVerification)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+	if err == nil && s.now().Sub(last) < verificationResendInterval {
+		return nil
+	}
+
+	return s.sendVerification(ctx, user)
+}
+
+// VerifyEmail redeems a verification token and marks the email as verified.
+func (s *Service) VerifyEmail(ctx context.Context, verificationToken string) error {
+	if s.userTokens == nil || verificationToken == "" {
+		return ErrInvalidVerificationToken
+	}
+
+	now := s.now().UTC()
+	consumed, err := s.userTokens.Consume(ctx, token.Hash(verificationToken), domain.TokenPurposeEmailVerification, now)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrInvalidVerificationToken
+		}
+		return err
+	}
+
+	if err := s.users.MarkEmailVerified(ctx, consumed.UserID, now); err != nil {
+		return err
+	}
+	return s.userTokens.InvalidateByUser(ctx, consumed.UserID, domain.TokenPurposeEmailVerification, now)
+}
+
+// CheckWriteAllowed reports ErrEmailNotVerified when the policy restricts
+// writes and the user has not verified their email.
+func (s *Service) CheckWriteAllowed(ctx context.Context, userID string) error {
+	if s.verification != VerificationRequiredForWrites {
+		return nil
+	}
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt == nil {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+func (s *Service) sendVerification(ctx context.Context, user *domain.User) error {
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	plain, err := s.issueUserToken(ctx, user.ID, domain.TokenPurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := s.linkBaseURL + "/verify-email?token=" + url.QueryEscape(plain)
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Welcome! Please confirm your email address.\n\n"+
+			"Use the link below within %d hours:\n%s\n\n"+
+			"If you did not create an account, you can ignore this email.\n", int(emailVerificationTTL.Hours()), link),
+	})
+}
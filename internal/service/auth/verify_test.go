@@ -0,0 +1,103 @@
+package auth_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	authsvc "go-todo-service/internal/service/auth"
+)
+
+func TestEmailVerificationFlow(t *testing.T) {
+	service, mail, _ := newResetFixture(t)
+	service.WithVerificationPolicy(authsvc.VerificationRequiredForLogin)
+	ctx := context.Background()
+
+	if _, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{}); err != authsvc.ErrEmailNotVerified {
+		t.Fatalf("expected ErrEmailNotVerified, got %v", err)
+	}
+
+	if err := service.SendVerificationEmail(ctx, "abc"); err != nil {
+		t.Fatalf("send verification failed: %v", err)
+	}
+	if len(mail.sent) != 1 || !strings.Contains(mail.sent[0].Body, "https://app.example.com/verify-email?token=") {
+		t.Fatalf("expected one verification email, got %+v", mail.sent)
+	}
+	verificationToken := tokenFromLink(t, mail.sent[0])
+
+	if err := service.ResetPassword(ctx, verificationToken, "new-password"); err != authsvc.ErrInvalidResetToken {
+		t.Fatalf("verification token must not reset passwords, got %v", err)
+	}
+	if err := service.VerifyEmail(ctx, verificationToken); err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if err := service.VerifyEmail(ctx, verificationToken); err != authsvc.ErrInvalidVerificationToken {
+		t.Fatalf("expected token to be single use, got %v", err)
+	}
+	if _, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("expected verified user to log in, got %v", err)
+	}
+
+	if err := service.SendVerificationEmail(ctx, "abc"); err != nil || len(mail.sent) != 1 {
+		t.Fatalf("expected no email for verified user, got err=%v sent=%d", err, len(mail.sent))
+	}
+}
+
+func TestResendVerificationThrottled(t *testing.T) {
+	service, mail, _ := newResetFixture(t)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return start })
+	ctx := context.Background()
+
+	if err := service.ResendVerificationEmail(ctx, "user@example.com"); err != nil {
+		t.Fatalf("first resend failed: %v", err)
+	}
+
+	service.WithNow(func() time.Time { return start.Add(20 * time.Second) })
+	if err := service.ResendVerificationEmail(ctx, "user@example.com"); err != nil {
+		t.Fatalf("expected a throttled resend to be dropped silently, got %v", err)
+	}
+	if len(mail.sent) != 1 {
+		t.Fatalf("expected no email within the interval, got %d", len(mail.sent))
+	}
+
+	service.WithNow(func() time.Time { return start.Add(time.Minute) })
+	if err := service.ResendVerificationEmail(ctx, "user@example.com"); err != nil {
+		t.Fatalf("resend after interval failed: %v", err)
+	}
+	if len(mail.sent) != 2 {
+		t.Fatalf("expected two emails, got %d", len(mail.sent))
+	}
+
+	if err := service.ResendVerificationEmail(ctx, "nobody@example.com"); err != nil {
+		t.Fatalf("expected unknown email to be ignored, got %v", err)
+	}
+}
+
+func TestCheckWriteAllowed(t *testing.T) {
+	service, mail, _ := newResetFixture(t)
+	ctx := context.Background()
+
+	if err := service.CheckWriteAllowed(ctx, "abc"); err != nil {
+		t.Fatalf("expected writes allowed by default, got %v", err)
+	}
+
+	service.WithVerificationPolicy(authsvc.VerificationRequiredForWrites)
+	if err := service.CheckWriteAllowed(ctx, "abc"); err != authsvc.ErrEmailNotVerified {
+		t.Fatalf("expected ErrEmailNotVerified, got %v", err)
+	}
+	if _, err := service.Login(ctx, "user@example.com", "password", authsvc.ClientInfo{}); err != nil {
+		t.Fatalf("expected login allowed under writes policy, got %v", err)
+	}
+
+	if err := service.SendVerificationEmail(ctx, "abc"); err != nil {
+		t.Fatalf("send verification failed: %v", err)
+	}
+	if err := service.VerifyEmail(ctx, tokenFromLink(t, mail.sent[0])); err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if err := service.CheckWriteAllowed(ctx, "abc"); err != nil {
+		t.Fatalf("expected writes allowed after verification, got %v", err)
+	}
+}
@@ -0,0 +1,750 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository"
+	projectsvc "go-todo-service/internal/service/project"
+	tagsvc "go-todo-service/internal/service/tag"
+	tasksvc "go-todo-service/internal/service/task"
+	"go-todo-service/pkg/rrule"
+	"go-todo-service/pkg/uuid"
+)
+
+const (
+	// Format identifies archives produced by this service.
+	Format = "go-todo-service/export"
+	// Version is the archive layout written by Export. Import accepts
+	// versions up to and including it. Version 2 added tags.json, version 3
+	// projects.json, version 4 task parents and version 5 recurrence.
+	Version = 5
+
+	// MaxArchiveSize bounds the compressed size of an uploaded archive.
+	MaxArchiveSize = 32 << 20
+	// maxEntrySize bounds the uncompressed size of a single archive file.
+	maxEntrySize = 64 << 20
+
+	manifestFile = "manifest.json"
+	profileFile  = "profile.json"
+	tasksFile    = "tasks.json"
+	tagsFile     = "tags.json"
+	projectsFile = "projects.json"
+)
+
+var (
+	// ErrInvalidArchive indicates an upload that is not a readable export archive.
+	ErrInvalidArchive = errors.New("invalid export archive")
+	// ErrUnsupportedVersion indicates an archive from a newer or unknown format.
+	ErrUnsupportedVersion = errors.New("unsupported export archive version")
+	// ErrInvalidConflictMode indicates an unknown conflict handling mode.
+	ErrInvalidConflictMode = errors.New("on_conflict must be one of skip, replace, duplicate")
+)
+
+// ConflictMode decides what Import does with an archived task whose id
+// matches a task the user already has.
+type ConflictMode string
+
+const (
+	// ConflictSkip keeps the existing task untouched.
+	ConflictSkip ConflictMode = "skip"
+	// ConflictReplace overwrites the existing task with the archived copy.
+	ConflictReplace ConflictMode = "replace"
+	// ConflictDuplicate stores the archived copy as an additional task.
+	ConflictDuplicate ConflictMode = "duplicate"
+)
+
+// Manifest is the manifest.json entry describing an archive.
+type Manifest struct {
+	Format     string         `json:"format"`
+	Version    int            `json:"version"`
+	ExportedAt time.Time      `json:"exported_at"`
+	UserID     string         `json:"user_id"`
+	Files      []ManifestFile `json:"files"`
+}
+
+// ManifestFile lists one data file of the archive.
+type ManifestFile struct {
+	Name    string `json:"name"`
+	Entries int    `json:"entries"`
+}
+
+// Profile is the profile.json entry. Credentials are never exported.
+type Profile struct {
+	ID              string     `json:"id"`
+	Email           string     `json:"email"`
+	DisplayName     string     `json:"display_name"`
+	TimeZone        string     `json:"time_zone"`
+	Locale          string     `json:"locale"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Task is one element of tasks.json.
+type Task struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	// Priority is absent from archives made before priorities existed.
+	Priority  string     `json:"priority,omitempty"`
+	DueAt     *time.Time `json:"due_at"`
+	DueAllDay bool       `json:"due_all_day"`
+	// ProjectID is the id of an entry of projects.json.
+	ProjectID string `json:"project_id,omitempty"`
+	// ParentID is the id of another entry of tasks.json.
+	ParentID string `json:"parent_id,omitempty"`
+	// Recurrence is an RRULE; it requires DueAt.
+	Recurrence      string `json:"recurrence,omitempty"`
+	RecurrenceBasis string `json:"recurrence_basis,omitempty"`
+	// Tags names entries of tags.json.
+	Tags      []string  `json:"tags,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Project is one element of projects.json.
+type Project struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Color       string    `json:"color"`
+	Archived    bool      `json:"archived"`
+	SortOrder   int       `json:"sort_order"`
+	Inbox       bool      `json:"inbox"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Tag is one element of tags.json. Tasks refer to tags by name.
+type Tag struct {
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ImportResult summarises an import.
+type ImportResult struct {
+	Created int
+	Updated int
+	Skipped int
+	// IDMap maps each archived task id to the id of the task now holding it.
+	IDMap map[string]string
+}
+
+// Service exports a user's data as a zip archive and restores tasks from one.
+type Service struct {
+	users    repository.UserRepository
+	tasks    *tasksvc.Service
+	tags     *tagsvc.Service
+	projects *projectsvc.Service
+	archive  repository.ArchiveRepository
+	now      func() time.Time
+}
+
+// New constructs an export service. Imports are written through archive.
+func New(users repository.UserRepository, tasks *tasksvc.Service, tags *tagsvc.Service, projects *projectsvc.Service, archive repository.ArchiveRepository) *Service {
+	return &Service{
+		users:    users,
+		tasks:    tasks,
+		tags:     tags,
+		projects: projects,
+		archive:  archive,
+		now:      time.Now,
+	}
+}
+
+// WithNow overrides the time source (testing).
+func (s *Service) WithNow(fn func() time.Time) {
+	if fn != nil {
+		s.now = fn
+	}
+}
+
+// Export writes the user's archive to w. Tasks are read page by page and
+// streamed, so memory use does not grow with the number of tasks. The
+// manifest is written last because it records how many entries each file
+// holds.
+func (s *Service) Export(ctx context.Context, userID string, w io.Writer) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	tags, err := s.tags.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+	projects, err := s.projects.List(ctx, userID, true)
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+	count, err := s.writeTasks(ctx, archive, userID)
+	if err != nil {
+		return err
+	}
+	archivedTags := make([]Tag, len(tags))
+	for i, tag := range tags {
+		archivedTags[i] = Tag{Name: tag.Name, Color: tag.Color, CreatedAt: tag.CreatedAt, UpdatedAt: tag.UpdatedAt}
+	}
+	if err := writeJSON(archive, tagsFile, archivedTags); err != nil {
+		return err
+	}
+	archivedProjects := make([]Project, len(projects))
+	for i, project := range projects {
+		archivedProjects[i] = Project{
+			ID:          project.ID,
+			Name:        project.Name,
+			Description: project.Description,
+			Color:       project.Color,
+			Archived:    project.Archived,
+			SortOrder:   project.SortOrder,
+			Inbox:       project.Inbox,
+			CreatedAt:   project.CreatedAt,
+			UpdatedAt:   project.UpdatedAt,
+		}
+	}
+	if err := writeJSON(archive, projectsFile, archivedProjects); err != nil {
+		return err
+	}
+	if err := writeJSON(archive, profileFile, Profile{
+		ID:              user.ID,
+		Email:           user.Email,
+		DisplayName:     user.DisplayName,
+		TimeZone:        user.TimeZone,
+		Locale:          user.Locale,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}); err != nil {
+		return err
+	}
+	if err := writeJSON(archive, manifestFile, Manifest{
+		Format:     Format,
+		Version:    Version,
+		ExportedAt: s.now().UTC(),
+		UserID:     user.ID,
+		Files: []ManifestFile{
+			{Name: profileFile, Entries: 1},
+			{Name: tasksFile, Entries: count},
+			{Name: tagsFile, Entries: len(tags)},
+			{Name: projectsFile, Entries: len(projects)},
+		},
+	}); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func (s *Service) writeTasks(ctx context.Context, archive *zip.Writer, userID string) (int, error) {
+	out, err := archive.Create(tasksFile)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := io.WriteString(out, "["); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	opts := tasksvc.ListOptions{Sort: "created_at:asc", Limit: tasksvc.MaxPageSize}
+	for {
+		page, err := s.tasks.ListTasks(ctx, userID, opts)
+		if err != nil {
+			return 0, err
+		}
+		for _, task := range page.Tasks {
+			data, err := json.Marshal(archiveTask(task))
+			if err != nil {
+				return 0, err
+			}
+			sep := ",\n"
+			if count == 0 {
+				sep = "\n"
+			}
+			if _, err := io.WriteString(out, sep); err != nil {
+				return 0, err
+			}
+			if _, err := out.Write(data); err != nil {
+				return 0, err
+			}
+			count++
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+
+	_, err = io.WriteString(out, "\n]\n")
+	return count, err
+}
+
+// Import restores the tasks of an archive into the user's account. Every
+// restored task gets a fresh id; ids are only reused when mode is
+// ConflictReplace and the archived id already belongs to one of the user's
+// tasks. The archive is validated completely and then written in one
+// transaction, so an import either fully applies or changes nothing.
+// Replaced tasks are overwritten as archived: completing a recurring task
+// through an import does not schedule its next occurrence. Archived tags are
+// matched to the user's tags by name, ignoring case, and created when
+// missing. Archived projects are reused when their id belongs to one of the
+// user's projects and created otherwise; the archived inbox maps to the
+// user's inbox. Subtasks are nested under the tasks now holding their
+// archived parents, within the usual cycle and depth limits.
+func (s *Service) Import(ctx context.Context, userID string, r io.ReaderAt, size int64, mode ConflictMode) (*ImportResult, error) {
+	switch mode {
+	case "":
+		mode = ConflictSkip
+	case ConflictSkip, ConflictReplace, ConflictDuplicate:
+	default:
+		return nil, ErrInvalidConflictMode
+	}
+
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	var manifest Manifest
+	if err := readJSON(archive, manifestFile, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Format != Format || manifest.Version < 1 || manifest.Version > Version {
+		return nil, ErrUnsupportedVersion
+	}
+	var tasks []Task
+	if err := readJSON(archive, tasksFile, &tasks); err != nil {
+		return nil, err
+	}
+	// Archives made before tags were exported have no tags.json; the tasks
+	// they replace keep their tags.
+	var tags []Tag
+	hasTags, err := readOptionalJSON(archive, tagsFile, &tags)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTags(tags); err != nil {
+		return nil, err
+	}
+	// Likewise, tasks replaced from archives without projects.json stay in
+	// their project.
+	var projects []Project
+	hasProjects, err := readOptionalJSON(archive, projectsFile, &projects)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateProjects(projects); err != nil {
+		return nil, err
+	}
+	if err := validateTasks(tasks, tags, projects); err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{IDMap: make(map[string]string, len(tasks))}
+	batch := repository.ArchiveBatch{TaskTags: make(map[string][]string)}
+	tagIDs, err := s.restoreTags(ctx, userID, tags, &batch)
+	if err != nil {
+		return nil, err
+	}
+	projectIDs, err := s.restoreProjects(ctx, userID, projects, &batch)
+	if err != nil {
+		return nil, err
+	}
+	// Tasks replaced from archives made before parents were exported stay
+	// where they are; all others take their parent from the archive, which
+	// is resolved once every archived id is mapped.
+	hasParents := manifest.Version >= 4
+	// Likewise for recurrence, which such replaced tasks keep while they
+	// stay due.
+	hasRecurrence := manifest.Version >= 5
+	archivedParents := make(map[string]string, len(tasks))
+	now := s.now().UTC()
+	for _, archived := range tasks {
+		existing, err := s.tasks.GetTask(ctx, userID, archived.ID)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			existing = nil
+		case err != nil:
+			return nil, err
+		}
+
+		if existing != nil && mode == ConflictSkip {
+			result.Skipped++
+			result.IDMap[archived.ID] = existing.ID
+			continue
+		}
+		restored, err := s.tasks.RestoredTask(ctx, userID, domain.Task{
+			Title:           archived.Title,
+			Description:     archived.Description,
+			Status:          domain.TaskStatus(archived.Status),
+			Priority:        archivedPriority(archived),
+			DueAt:           archived.DueAt,
+			DueAllDay:       archived.DueAllDay,
+			Recurrence:      archived.Recurrence,
+			RecurrenceBasis: domain.RecurrenceBasis(archived.RecurrenceBasis),
+			CreatedAt:       archived.CreatedAt,
+			UpdatedAt:       archived.UpdatedAt,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("restore task %s: %w", archived.ID, err)
+		}
+		if archived.ProjectID != "" {
+			restored.ProjectID = projectIDs[archived.ProjectID]
+		}
+		if existing != nil && mode == ConflictReplace {
+			// The archived copy takes over the existing task in place.
+			restored.ID = existing.ID
+			if !hasProjects {
+				restored.ProjectID = existing.ProjectID
+			}
+			if hasParents {
+				archivedParents[restored.ID] = archived.ParentID
+			} else {
+				restored.ParentID = existing.ParentID
+			}
+			if !hasRecurrence && restored.DueAt != nil {
+				restored.Recurrence = existing.Recurrence
+				restored.RecurrenceBasis = existing.RecurrenceBasis
+			}
+			restored.CreatedAt = existing.CreatedAt
+			restored.UpdatedAt = now
+			batch.Replace = append(batch.Replace, *restored)
+			if hasTags {
+				batch.TaskTags[restored.ID] = archivedTagIDs(archived, tagIDs)
+			}
+			result.Updated++
+			result.IDMap[archived.ID] = restored.ID
+			continue
+		}
+		batch.Create = append(batch.Create, *restored)
+		archivedParents[restored.ID] = archived.ParentID
+		if len(archived.Tags) > 0 {
+			batch.TaskTags[restored.ID] = archivedTagIDs(archived, tagIDs)
+		}
+		result.Created++
+		result.IDMap[archived.ID] = restored.ID
+	}
+
+	parents := make(map[string]string, len(archivedParents))
+	for id, archivedParent := range archivedParents {
+		parents[id] = result.IDMap[archivedParent]
+	}
+	if err := s.tasks.CheckRestoredParents(ctx, userID, parents); err != nil {
+		if errors.Is(err, tasksvc.ErrParentCycle) || errors.Is(err, tasksvc.ErrTooDeep) || errors.Is(err, tasksvc.ErrUnknownParent) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		return nil, err
+	}
+	for _, restored := range [][]domain.Task{batch.Create, batch.Replace} {
+		for i := range restored {
+			if parent, ok := parents[restored[i].ID]; ok {
+				restored[i].ParentID = parent
+			}
+		}
+	}
+	batch.Create = parentsFirst(batch.Create)
+
+	if err := s.archive.Restore(ctx, batch); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// restoreTags maps the lower-cased name of each archived tag to the id of
+// the user's tag of that name, adding the tags the user does not have yet
+// to the batch.
+func (s *Service) restoreTags(ctx context.Context, userID string, tags []Tag, batch *repository.ArchiveBatch) (map[string]string, error) {
+	ids := make(map[string]string, len(tags))
+	if len(tags) == 0 {
+		return ids, nil
+	}
+	existing, err := s.tags.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range existing {
+		ids[tagKey(tag.Name)] = tag.ID
+	}
+	for _, archived := range tags {
+		key := tagKey(archived.Name)
+		if _, ok := ids[key]; ok {
+			continue
+		}
+		restored, err := s.tags.RestoredTag(ctx, userID, domain.Tag{
+			Name:      archived.Name,
+			Color:     archived.Color,
+			CreatedAt: archived.CreatedAt,
+			UpdatedAt: archived.UpdatedAt,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: tag %q: %v", ErrInvalidArchive, archived.Name, err)
+		}
+		batch.Tags = append(batch.Tags, *restored)
+		ids[key] = restored.ID
+	}
+	return ids, nil
+}
+
+// restoreProjects maps the id of each archived project to the id of the
+// user's project now holding it, adding the projects the user does not have
+// yet to the batch.
+func (s *Service) restoreProjects(ctx context.Context, userID string, projects []Project, batch *repository.ArchiveBatch) (map[string]string, error) {
+	ids := make(map[string]string, len(projects))
+	for _, archived := range projects {
+		if archived.Inbox {
+			inbox, err := s.projects.Inbox(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			ids[archived.ID] = inbox.ID
+			continue
+		}
+		existing, err := s.projects.Get(ctx, userID, archived.ID)
+		switch {
+		case err == nil:
+			ids[archived.ID] = existing.ID
+			continue
+		case !errors.Is(err, domain.ErrNotFound):
+			return nil, err
+		}
+		restored, err := s.projects.RestoredProject(ctx, userID, domain.Project{
+			Name:        archived.Name,
+			Description: archived.Description,
+			Color:       archived.Color,
+			Archived:    archived.Archived,
+			SortOrder:   archived.SortOrder,
+			CreatedAt:   archived.CreatedAt,
+			UpdatedAt:   archived.UpdatedAt,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: project %s: %v", ErrInvalidArchive, archived.ID, err)
+		}
+		batch.Projects = append(batch.Projects, *restored)
+		ids[archived.ID] = restored.ID
+	}
+	return ids, nil
+}
+
+// parentsFirst orders tasks so that each follows its parent when both are
+// among them.
+func parentsFirst(tasks []domain.Task) []domain.Task {
+	index := make(map[string]int, len(tasks))
+	for i, task := range tasks {
+		index[task.ID] = i
+	}
+	placed := make([]bool, len(tasks))
+	ordered := make([]domain.Task, 0, len(tasks))
+	var place func(i int)
+	place = func(i int) {
+		if placed[i] {
+			return
+		}
+		placed[i] = true
+		if parent, ok := index[tasks[i].ParentID]; ok {
+			place(parent)
+		}
+		ordered = append(ordered, tasks[i])
+	}
+	for i := range tasks {
+		place(i)
+	}
+	return ordered
+}
+
+// archivedTagIDs resolves the tag names of an archived task.
+func archivedTagIDs(task Task, tagIDs map[string]string) []string {
+	ids := make([]string, 0, len(task.Tags))
+	for _, name := range task.Tags {
+		id := tagIDs[tagKey(name)]
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// validateTags rejects tag lists naming a tag twice.
+func validateTags(tags []Tag) error {
+	seen := make(map[string]bool, len(tags))
+	for i, tag := range tags {
+		key := tagKey(tag.Name)
+		switch {
+		case key == "":
+			return fmt.Errorf("%w: tag %d has no name", ErrInvalidArchive, i)
+		case seen[key]:
+			return fmt.Errorf("%w: tag %q appears twice", ErrInvalidArchive, tag.Name)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// validateProjects rejects project lists with invalid or repeated ids or
+// more than one inbox.
+func validateProjects(projects []Project) error {
+	seen := make(map[string]bool, len(projects))
+	inboxes := 0
+	for i, project := range projects {
+		switch {
+		case !uuid.Valid(project.ID):
+			return fmt.Errorf("%w: project %d has an invalid id", ErrInvalidArchive, i)
+		case seen[project.ID]:
+			return fmt.Errorf("%w: project id %s appears twice", ErrInvalidArchive, project.ID)
+		}
+		if project.Inbox {
+			inboxes++
+		}
+		seen[project.ID] = true
+	}
+	if inboxes > 1 {
+		return fmt.Errorf("%w: more than one inbox", ErrInvalidArchive)
+	}
+	return nil
+}
+
+// validateTasks rejects archives that RestoredTask would refuse, or whose
+// tasks name tags, projects or parents missing from the archive, before any
+// lookups are made for them.
+func validateTasks(tasks []Task, tags []Tag, projects []Project) error {
+	known := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		known[tagKey(tag.Name)] = true
+	}
+	knownProjects := make(map[string]bool, len(projects))
+	for _, project := range projects {
+		knownProjects[project.ID] = true
+	}
+	ids := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		ids[task.ID] = true
+	}
+	seen := make(map[string]bool, len(tasks))
+	for i, task := range tasks {
+		switch {
+		case !uuid.Valid(task.ID):
+			return fmt.Errorf("%w: task %d has an invalid id", ErrInvalidArchive, i)
+		case seen[task.ID]:
+			return fmt.Errorf("%w: task id %s appears twice", ErrInvalidArchive, task.ID)
+		case task.Title == "":
+			return fmt.Errorf("%w: task %s has no title", ErrInvalidArchive, task.ID)
+		}
+		switch domain.TaskStatus(task.Status) {
+		case "", domain.TaskStatusPending, domain.TaskStatusDone:
+		default:
+			return fmt.Errorf("%w: task %s has invalid status %q", ErrInvalidArchive, task.ID, task.Status)
+		}
+		if !archivedPriority(task).Valid() {
+			return fmt.Errorf("%w: task %s has invalid priority %q", ErrInvalidArchive, task.ID, task.Priority)
+		}
+		if task.Recurrence != "" {
+			if task.DueAt == nil {
+				return fmt.Errorf("%w: task %s recurs without a due date", ErrInvalidArchive, task.ID)
+			}
+			if _, err := rrule.Parse(task.Recurrence); err != nil {
+				return fmt.Errorf("%w: task %s: %v", ErrInvalidArchive, task.ID, err)
+			}
+		}
+		if basis := domain.RecurrenceBasis(task.RecurrenceBasis); basis != "" && !basis.Valid() {
+			return fmt.Errorf("%w: task %s has invalid recurrence basis %q", ErrInvalidArchive, task.ID, task.RecurrenceBasis)
+		}
+		if task.ParentID != "" && !ids[task.ParentID] {
+			return fmt.Errorf("%w: task %s has unknown parent %s", ErrInvalidArchive, task.ID, task.ParentID)
+		}
+		if task.ProjectID != "" && !knownProjects[task.ProjectID] {
+			return fmt.Errorf("%w: task %s has unknown project %s", ErrInvalidArchive, task.ID, task.ProjectID)
+		}
+		for _, name := range task.Tags {
+			if !known[tagKey(name)] {
+				return fmt.Errorf("%w: task %s has unknown tag %q", ErrInvalidArchive, task.ID, name)
+			}
+		}
+		seen[task.ID] = true
+	}
+	return nil
+}
+
+// archivedPriority reads the priority of an archived task, treating a
+// missing one as none.
+func archivedPriority(task Task) domain.TaskPriority {
+	if task.Priority == "" {
+		return domain.TaskPriorityNone
+	}
+	return domain.TaskPriority(task.Priority)
+}
+
+// tagKey is the case-insensitive form under which tags are matched.
+func tagKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func archiveTask(task domain.Task) Task {
+	var tags []string
+	for _, tag := range task.Tags {
+		tags = append(tags, tag.Name)
+	}
+	var basis string
+	if task.Recurrence != "" {
+		basis = string(task.RecurrenceBasis)
+	}
+	return Task{
+		ID:              task.ID,
+		Title:           task.Title,
+		Description:     task.Description,
+		Status:          string(task.Status),
+		Priority:        string(task.Priority),
+		DueAt:           task.DueAt,
+		DueAllDay:       task.DueAllDay,
+		ProjectID:       task.ProjectID,
+		ParentID:        task.ParentID,
+		Recurrence:      task.Recurrence,
+		RecurrenceBasis: basis,
+		Tags:            tags,
+		CreatedAt:       task.CreatedAt,
+		UpdatedAt:       task.UpdatedAt,
+	}
+}
+
+func writeJSON(archive *zip.Writer, name string, value any) error {
+	out, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// readOptionalJSON reads name like readJSON, reporting false when the
+// archive does not hold it.
+func readOptionalJSON(archive *zip.Reader, name string, value any) (bool, error) {
+	if _, err := fs.Stat(archive, name); errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return true, readJSON(archive, name, value)
+}
+
+func readJSON(archive *zip.Reader, name string, value any) error {
+	file, err := archive.Open(name)
+	if err != nil {
+		return fmt.Errorf("%w: missing %s", ErrInvalidArchive, name)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(io.LimitReader(file, maxEntrySize))
+	if err := decoder.Decode(value); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidArchive, name, err)
+	}
+	return nil
+}
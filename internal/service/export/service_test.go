@@ -0,0 +1,580 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository"
+	"go-todo-service/internal/service/export"
+	projectsvc "go-todo-service/internal/service/project"
+	tagsvc "go-todo-service/internal/service/tag"
+	tasksvc "go-todo-service/internal/service/task"
+)
+
+type fakeTaskRepo struct {
+	tasks map[string]domain.Task
+}
+
+func (r *fakeTaskRepo) Create(ctx context.Context, task *domain.Task) error {
+	r.tasks[task.ID] = *task
+	return nil
+}
+
+// ListByUser supports the created_at ascending order used by Export.
+func (r *fakeTaskRepo) ListByUser(ctx context.Context, userID string, opts repository.TaskListOptions) ([]domain.Task, error) {
+	var out []domain.Task
+	for _, task := range r.tasks {
+		if task.UserID == userID {
+			out = append(out, task)
+		}
+	}
+	compare := func(a, b domain.Task) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	}
+	slices.SortFunc(out, compare)
+	if opts.After != nil {
+		marker := domain.Task{ID: opts.After.ID, CreatedAt: opts.After.CreatedAt}
+		out = slices.DeleteFunc(out, func(task domain.Task) bool { return compare(task, marker) <= 0 })
+	}
+	if opts.Limit > 0 && len(out) > opts.Limit {
+		out = out[:opts.Limit]
+	}
+	return out, nil
+}
+
+func (r *fakeTaskRepo) GetByID(ctx context.Context, id string) (*domain.Task, error) {
+	task, ok := r.tasks[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &task, nil
+}
+
+func (r *fakeTaskRepo) Update(ctx context.Context, task *domain.Task) error {
+	r.tasks[task.ID] = *task
+	return nil
+}
+
+func (r *fakeTaskRepo) CompleteOccurrence(ctx context.Context, done, next *domain.Task) error {
+	r.tasks[done.ID] = *done
+	r.tasks[next.ID] = *next
+	return nil
+}
+
+func (r *fakeTaskRepo) Delete(ctx context.Context, id string) error {
+	delete(r.tasks, id)
+	return nil
+}
+
+func (r *fakeTaskRepo) ListAncestors(ctx context.Context, id string) ([]domain.Task, error) {
+	return nil, nil
+}
+
+func (r *fakeTaskRepo) ListDescendants(ctx context.Context, ids []string) ([]domain.Task, error) {
+	return nil, nil
+}
+
+func (r *fakeTaskRepo) CountSubtasks(ctx context.Context, ids []string) (map[string]domain.SubtaskProgress, error) {
+	return map[string]domain.SubtaskProgress{}, nil
+}
+
+// fakeTagRepo serves the tag lookups made by Export, Import and task
+// enrichment.
+type fakeTagRepo struct {
+	repository.TagRepository
+	tags     map[string]domain.Tag
+	assigned map[string][]string
+}
+
+func (r *fakeTagRepo) ListByUser(ctx context.Context, userID string) ([]domain.Tag, error) {
+	var out []domain.Tag
+	for _, tag := range r.tags {
+		if tag.UserID == userID {
+			out = append(out, tag)
+		}
+	}
+	slices.SortFunc(out, func(a, b domain.Tag) int { return strings.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+func (r *fakeTagRepo) GetByNames(ctx context.Context, userID string, names []string) ([]domain.Tag, error) {
+	var out []domain.Tag
+	for _, tag := range r.tags {
+		if tag.UserID == userID && slices.Contains(names, strings.ToLower(tag.Name)) {
+			out = append(out, tag)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeTagRepo) SetTaskTags(ctx context.Context, taskID string, tagIDs []string) error {
+	r.assigned[taskID] = slices.Clone(tagIDs)
+	return nil
+}
+
+func (r *fakeTagRepo) ListByTasks(ctx context.Context, taskIDs []string) (map[string][]domain.Tag, error) {
+	out := make(map[string][]domain.Tag)
+	for _, taskID := range taskIDs {
+		for _, id := range r.assigned[taskID] {
+			out[taskID] = append(out[taskID], r.tags[id])
+		}
+		slices.SortFunc(out[taskID], func(a, b domain.Tag) int { return strings.Compare(a.Name, b.Name) })
+	}
+	return out, nil
+}
+
+// fakeProjectRepo serves the project lookups made by Export, Import and
+// task filing.
+type fakeProjectRepo struct {
+	repository.ProjectRepository
+	projects map[string]domain.Project
+}
+
+func (r *fakeProjectRepo) Create(ctx context.Context, project *domain.Project) error {
+	r.projects[project.ID] = *project
+	return nil
+}
+
+func (r *fakeProjectRepo) GetByID(ctx context.Context, id string) (*domain.Project, error) {
+	project, ok := r.projects[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &project, nil
+}
+
+func (r *fakeProjectRepo) GetInbox(ctx context.Context, userID string) (*domain.Project, error) {
+	for _, project := range r.projects {
+		if project.UserID == userID && project.Inbox {
+			return &project, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *fakeProjectRepo) ListByUser(ctx context.Context, userID string, includeArchived bool) ([]domain.Project, error) {
+	var out []domain.Project
+	for _, project := range r.projects {
+		if project.UserID == userID && (includeArchived || !project.Archived) {
+			out = append(out, project)
+		}
+	}
+	slices.SortFunc(out, func(a, b domain.Project) int { return a.SortOrder - b.SortOrder })
+	return out, nil
+}
+
+// fakeArchiveRepo applies restores to the task, tag and project
+// repositories, or fails them without writing anything when err is set. Like
+// the parent_id foreign key, it refuses tasks stored before their parent.
+type fakeArchiveRepo struct {
+	tasks    *fakeTaskRepo
+	tags     *fakeTagRepo
+	projects *fakeProjectRepo
+	err      error
+}
+
+func (r *fakeArchiveRepo) Restore(ctx context.Context, batch repository.ArchiveBatch) error {
+	if r.err != nil {
+		return r.err
+	}
+	for _, project := range batch.Projects {
+		r.projects.projects[project.ID] = project
+	}
+	for _, tag := range batch.Tags {
+		r.tags.tags[tag.ID] = tag
+	}
+	for taskID, tagIDs := range batch.TaskTags {
+		r.tags.assigned[taskID] = slices.Clone(tagIDs)
+	}
+	stored := maps.Clone(r.tasks.tasks)
+	for _, task := range batch.Create {
+		if _, ok := stored[task.ParentID]; task.ParentID != "" && !ok {
+			return fmt.Errorf("task %s stored before its parent %s", task.ID, task.ParentID)
+		}
+		stored[task.ID] = task
+	}
+	for _, task := range batch.Create {
+		r.tasks.tasks[task.ID] = task
+	}
+	for _, task := range batch.Replace {
+		r.tasks.tasks[task.ID] = task
+	}
+	return nil
+}
+
+// fakeUserRepo serves GetByID; Export uses no other UserRepository methods.
+type fakeUserRepo struct {
+	repository.UserRepository
+	users map[string]*domain.User
+}
+
+func (r *fakeUserRepo) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	u := *user
+	return &u, nil
+}
+
+func newFixture(t *testing.T) (*export.Service, *tasksvc.Service, *fakeTaskRepo) {
+	service, taskService, tasks, _ := newArchiveFixture(t)
+	return service, taskService, tasks
+}
+
+func newArchiveFixture(t *testing.T) (*export.Service, *tasksvc.Service, *fakeTaskRepo, *fakeArchiveRepo) {
+	t.Helper()
+	tasks := &fakeTaskRepo{tasks: make(map[string]domain.Task)}
+	tags := &fakeTagRepo{tags: make(map[string]domain.Tag), assigned: make(map[string][]string)}
+	projects := &fakeProjectRepo{projects: make(map[string]domain.Project)}
+	archive := &fakeArchiveRepo{tasks: tasks, tags: tags, projects: projects}
+	users := &fakeUserRepo{users: map[string]*domain.User{
+		"user-1": {ID: "user-1", Email: "user@example.com", PasswordHash: "secret-hash", DisplayName: "Jane", TimeZone: "UTC", Locale: "en"},
+		"user-2": {ID: "user-2", Email: "other@example.com"},
+	}}
+	taskService := tasksvc.New(tasks)
+	taskService.WithNow(func() time.Time { return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC) })
+	taskService.WithTags(tags)
+	projectService := projectsvc.New(projects)
+	taskService.WithProjects(projectService)
+	service := export.New(users, taskService, tagsvc.New(tags), projectService, archive)
+	service.WithNow(func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) })
+	return service, taskService, tasks, archive
+}
+
+func readEntry(t *testing.T, archive []byte, name string, value any) {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	file, err := reader.Open(name)
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+	defer file.Close()
+	if err := json.NewDecoder(file).Decode(value); err != nil {
+		t.Fatalf("decode %s: %v", name, err)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	service, taskService, repo, store := newArchiveFixture(t)
+	ctx := context.Background()
+
+	tags := store.tags
+	tags.tags["tag-work"] = domain.Tag{ID: "tag-work", UserID: "user-1", Name: "Work", Color: "#1e90ff"}
+	tags.tags["tag-home"] = domain.Tag{ID: "tag-home", UserID: "user-1", Name: "Home", Color: "#228b22"}
+	// user-2 already has the Work tag under another case; it is reused.
+	tags.tags["tag-other"] = domain.Tag{ID: "tag-other", UserID: "user-2", Name: "WORK", Color: "#000000"}
+	const workID = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	store.projects.projects[workID] = domain.Project{ID: workID, UserID: "user-1", Name: "Work", Color: "#1e90ff", SortOrder: 3}
+
+	due := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	var first *domain.Task
+	for i := 0; i < tasksvc.MaxPageSize+5; i++ {
+		input := tasksvc.CreateTaskInput{Title: "Task"}
+		switch i {
+		case 0:
+			input.DueAt, input.DueAllDay = &due, true
+			input.Priority = "urgent"
+			input.Tags = []string{"work", "home"}
+			input.ProjectID = workID
+			input.Recurrence, input.RecurrenceBasis = "FREQ=WEEKLY;BYDAY=MO", "completion"
+		case 1, 2:
+			input.ParentID = first.ID
+		}
+		task, err := taskService.CreateTask(ctx, "user-1", input)
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		if i == 0 {
+			first = task
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := service.Export(ctx, "user-1", &buf); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	var manifest export.Manifest
+	readEntry(t, buf.Bytes(), "manifest.json", &manifest)
+	if manifest.Format != export.Format || manifest.Version != export.Version || manifest.UserID != "user-1" {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+	var profile map[string]any
+	readEntry(t, buf.Bytes(), "profile.json", &profile)
+	if profile["display_name"] != "Jane" || profile["password_hash"] != nil {
+		t.Fatalf("unexpected profile %v", profile)
+	}
+	var archived []export.Task
+	readEntry(t, buf.Bytes(), "tasks.json", &archived)
+	if len(archived) != tasksvc.MaxPageSize+5 {
+		t.Fatalf("expected every task across pages, got %d", len(archived))
+	}
+	var archivedTags []export.Tag
+	readEntry(t, buf.Bytes(), "tags.json", &archivedTags)
+	if len(archivedTags) != 2 {
+		t.Fatalf("expected both tags, got %+v", archivedTags)
+	}
+	var archivedProjects []export.Project
+	readEntry(t, buf.Bytes(), "projects.json", &archivedProjects)
+	if len(archivedProjects) != 2 {
+		t.Fatalf("expected the inbox and Work, got %+v", archivedProjects)
+	}
+
+	result, err := service.Import(ctx, "user-2", bytes.NewReader(buf.Bytes()), int64(buf.Len()), "")
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if result.Created != len(archived) || result.Skipped != 0 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	for _, task := range archived {
+		newID := result.IDMap[task.ID]
+		if newID == "" || newID == task.ID {
+			t.Fatalf("expected %s to be remapped, got %q", task.ID, newID)
+		}
+		restored := repo.tasks[newID]
+		if restored.UserID != "user-2" || !restored.CreatedAt.Equal(task.CreatedAt) {
+			t.Fatalf("unexpected restored task %+v", restored)
+		}
+		if task.DueAt != nil && (restored.DueAt == nil || !restored.DueAt.Equal(due) || !restored.DueAllDay) {
+			t.Fatalf("expected due date to survive, got %+v", restored)
+		}
+		if string(restored.Priority) != task.Priority {
+			t.Fatalf("expected priority %q to survive, got %q", task.Priority, restored.Priority)
+		}
+		if restored.Recurrence != task.Recurrence || (task.Recurrence != "" && restored.RecurrenceBasis != domain.RecurFromCompletion) {
+			t.Fatalf("expected recurrence %q to survive, got %q (%s)", task.Recurrence, restored.Recurrence, restored.RecurrenceBasis)
+		}
+		if restored.ParentID != result.IDMap[task.ParentID] {
+			t.Fatalf("expected parent %q to be remapped, got %q", task.ParentID, restored.ParentID)
+		}
+		var names, want []string
+		for _, id := range tags.assigned[newID] {
+			names = append(names, strings.ToLower(tags.tags[id].Name))
+		}
+		for _, name := range task.Tags {
+			want = append(want, strings.ToLower(name))
+		}
+		slices.Sort(names)
+		slices.Sort(want)
+		if !slices.Equal(names, want) {
+			t.Fatalf("expected tags %v to survive, got %v", task.Tags, names)
+		}
+		if len(task.Tags) > 0 && !slices.Contains(tags.assigned[newID], "tag-other") {
+			t.Fatalf("expected the existing WORK tag to be reused, got %v", tags.assigned[newID])
+		}
+	}
+	inbox, err := store.projects.GetInbox(ctx, "user-2")
+	if err != nil {
+		t.Fatalf("inbox: %v", err)
+	}
+	userProjects, _ := store.projects.ListByUser(ctx, "user-2", true)
+	if len(userProjects) != 2 || userProjects[1].Name != "Work" || userProjects[1].ID == workID || userProjects[1].SortOrder != 3 {
+		t.Fatalf("expected Work to be created beside the inbox, got %+v", userProjects)
+	}
+	for _, task := range archived {
+		want := inbox.ID
+		if task.ProjectID == workID {
+			want = userProjects[1].ID
+		}
+		if got := repo.tasks[result.IDMap[task.ID]].ProjectID; got != want {
+			t.Fatalf("expected task %s in project %s, got %s", task.ID, want, got)
+		}
+	}
+	userTags, _ := tags.ListByUser(ctx, "user-2")
+	if len(userTags) != 2 || userTags[0].Name != "Home" || userTags[0].Color != "#228b22" {
+		t.Fatalf("expected Home to be created beside WORK, got %+v", userTags)
+	}
+}
+
+func TestImportConflictModes(t *testing.T) {
+	service, taskService, repo, store := newArchiveFixture(t)
+	ctx := context.Background()
+
+	task, err := taskService.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "Original"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := service.Export(ctx, "user-1", &buf); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if _, err := taskService.UpdateTask(ctx, "user-1", task.ID, tasksvc.UpdateTaskInput{Title: "Edited", Status: "done"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	archive := bytes.NewReader(buf.Bytes())
+
+	result, err := service.Import(ctx, "user-1", archive, archive.Size(), export.ConflictSkip)
+	if err != nil || result.Skipped != 1 || result.IDMap[task.ID] != task.ID || repo.tasks[task.ID].Title != "Edited" {
+		t.Fatalf("skip: unexpected result %+v (%v)", result, err)
+	}
+
+	result, err = service.Import(ctx, "user-1", archive, archive.Size(), export.ConflictReplace)
+	if err != nil || result.Updated != 1 || repo.tasks[task.ID].Title != "Original" || repo.tasks[task.ID].Status != domain.TaskStatusPending {
+		t.Fatalf("replace: unexpected result %+v (%v), task %+v", result, err, repo.tasks[task.ID])
+	}
+
+	result, err = service.Import(ctx, "user-1", archive, archive.Size(), export.ConflictDuplicate)
+	if err != nil || result.Created != 1 || result.IDMap[task.ID] == task.ID || len(repo.tasks) != 2 {
+		t.Fatalf("duplicate: unexpected result %+v (%v)", result, err)
+	}
+	if len(store.projects.projects) != 1 || repo.tasks[result.IDMap[task.ID]].ProjectID != task.ProjectID {
+		t.Fatalf("expected the inbox to be reused, got %+v", store.projects.projects)
+	}
+
+	if _, err := service.Import(ctx, "user-1", archive, archive.Size(), "merge"); !errors.Is(err, export.ErrInvalidConflictMode) {
+		t.Fatalf("expected ErrInvalidConflictMode, got %v", err)
+	}
+}
+
+// buildArchive writes an archive holding the manifest, the tasks and, when
+// given, the tags.
+func buildArchive(manifest export.Manifest, tasks []export.Task, tags ...export.Tag) *bytes.Reader {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	files := map[string]any{"manifest.json": manifest, "tasks.json": tasks}
+	if tags != nil {
+		files["tags.json"] = tags
+	}
+	for name, value := range files {
+		out, _ := archive.Create(name)
+		_ = json.NewEncoder(out).Encode(value)
+	}
+	_ = archive.Close()
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestImportIsAllOrNothing(t *testing.T) {
+	service, taskService, repo, archive := newArchiveFixture(t)
+	ctx := context.Background()
+
+	task, err := taskService.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "Original"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	upload := buildArchive(export.Manifest{Format: export.Format, Version: export.Version}, []export.Task{
+		{ID: task.ID, Title: "Replaced"},
+		{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Title: "New"},
+	})
+	archive.err = errors.New("connection lost")
+	if _, err := service.Import(ctx, "user-1", upload, upload.Size(), export.ConflictReplace); err == nil {
+		t.Fatal("expected the failed write to be reported")
+	}
+	if len(repo.tasks) != 1 || repo.tasks[task.ID].Title != "Original" {
+		t.Fatalf("expected nothing to change, got %+v", repo.tasks)
+	}
+}
+
+func TestImportReplaceDoesNotScheduleOccurrences(t *testing.T) {
+	service, taskService, repo := newFixture(t)
+	ctx := context.Background()
+
+	due := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	task, err := taskService.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "Stand-up", DueAt: &due, Recurrence: "FREQ=DAILY"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	upload := buildArchive(export.Manifest{Format: export.Format, Version: export.Version}, []export.Task{
+		{ID: task.ID, Title: "Stand-up", Status: "done", DueAt: &due, Recurrence: "FREQ=WEEKLY"},
+	})
+	result, err := service.Import(ctx, "user-1", upload, upload.Size(), export.ConflictReplace)
+	if err != nil || result.Updated != 1 {
+		t.Fatalf("import: unexpected result %+v (%v)", result, err)
+	}
+	if len(repo.tasks) != 1 || repo.tasks[task.ID].Status != domain.TaskStatusDone {
+		t.Fatalf("expected the task to be completed without a next occurrence, got %+v", repo.tasks)
+	}
+	if repo.tasks[task.ID].Recurrence != "FREQ=WEEKLY" {
+		t.Fatalf("expected the archived recurrence, got %q", repo.tasks[task.ID].Recurrence)
+	}
+}
+
+func TestImportNestsSubtasksListedBeforeTheirParent(t *testing.T) {
+	service, _, repo := newFixture(t)
+	ctx := context.Background()
+
+	const parentID, childID = "0f8fad5b-d9cb-469f-a165-70867728950e", "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	upload := buildArchive(export.Manifest{Format: export.Format, Version: export.Version}, []export.Task{
+		{ID: childID, Title: "Child", ParentID: parentID},
+		{ID: parentID, Title: "Parent"},
+	})
+	result, err := service.Import(ctx, "user-1", upload, upload.Size(), "")
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if child := repo.tasks[result.IDMap[childID]]; child.ParentID != result.IDMap[parentID] {
+		t.Fatalf("expected the child under the restored parent, got %+v", child)
+	}
+}
+
+func TestImportRejectsInvalidArchives(t *testing.T) {
+	service, _, repo := newFixture(t)
+	ctx := context.Background()
+
+	build := buildArchive
+	valid := export.Manifest{Format: export.Format, Version: export.Version}
+	good := export.Task{ID: "0f8fad5b-d9cb-469f-a165-70867728950e", Title: "Valid"}
+
+	due := time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+	// A chain one level deeper than allowed.
+	var chain []export.Task
+	for i := range tasksvc.MaxDepth + 1 {
+		task := export.Task{ID: fmt.Sprintf("0f8fad5b-d9cb-469f-a165-70867728950%d", i), Title: "x"}
+		if i > 0 {
+			task.ParentID = chain[i-1].ID
+		}
+		chain = append(chain, task)
+	}
+
+	cases := []struct {
+		name    string
+		archive *bytes.Reader
+		want    error
+	}{
+		{"not a zip", bytes.NewReader([]byte("plain text")), export.ErrInvalidArchive},
+		{"newer version", build(export.Manifest{Format: export.Format, Version: export.Version + 1}, nil), export.ErrUnsupportedVersion},
+		{"foreign format", build(export.Manifest{Format: "other", Version: 1}, nil), export.ErrUnsupportedVersion},
+		{"missing title", build(valid, []export.Task{good, {ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}), export.ErrInvalidArchive},
+		{"duplicate id", build(valid, []export.Task{good, good}), export.ErrInvalidArchive},
+		{"bad id", build(valid, []export.Task{{ID: "1", Title: "x"}}), export.ErrInvalidArchive},
+		{"bad status", build(valid, []export.Task{{ID: good.ID, Title: "x", Status: "later"}}), export.ErrInvalidArchive},
+		{"bad priority", build(valid, []export.Task{{ID: good.ID, Title: "x", Priority: "someday"}}), export.ErrInvalidArchive},
+		{"unknown tag", build(valid, []export.Task{{ID: good.ID, Title: "x", Tags: []string{"work"}}}), export.ErrInvalidArchive},
+		{"duplicate tag", build(valid, nil, export.Tag{Name: "Work"}, export.Tag{Name: "work"}), export.ErrInvalidArchive},
+		{"bad tag colour", build(valid, nil, export.Tag{Name: "Work", Color: "blue"}), export.ErrInvalidArchive},
+		{"unknown parent", build(valid, []export.Task{{ID: good.ID, Title: "x", ParentID: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}), export.ErrInvalidArchive},
+		{"parent cycle", build(valid, []export.Task{
+			{ID: good.ID, Title: "x", ParentID: "7c9e6679-7425-40de-944b-e07fc1f90ae7"},
+			{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Title: "y", ParentID: good.ID},
+		}), export.ErrInvalidArchive},
+		{"too deep", build(valid, chain), export.ErrInvalidArchive},
+		{"bad recurrence", build(valid, []export.Task{{ID: good.ID, Title: "x", DueAt: &due, Recurrence: "FREQ=HOURLY"}}), export.ErrInvalidArchive},
+		{"recurrence without due date", build(valid, []export.Task{{ID: good.ID, Title: "x", Recurrence: "FREQ=DAILY"}}), export.ErrInvalidArchive},
+		{"bad recurrence basis", build(valid, []export.Task{{ID: good.ID, Title: "x", DueAt: &due, Recurrence: "FREQ=DAILY", RecurrenceBasis: "later"}}), export.ErrInvalidArchive},
+		{"unknown project", build(valid, []export.Task{{ID: good.ID, Title: "x", ProjectID: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}), export.ErrInvalidArchive},
+	}
+	for _, tc := range cases {
+		if _, err := service.Import(ctx, "user-1", tc.archive, tc.archive.Size(), ""); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+	if len(repo.tasks) != 0 {
+		t.Fatalf("expected no partial import, got %d tasks", len(repo.tasks))
+	}
+}
@@ -0,0 +1,293 @@
+// Package lockout slows down and temporarily blocks repeated failed sign-in
+// attempts per account and per client IP.
+package lockout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository"
+)
+
+const (
+	accountKeyPrefix   = KindAccount + ":"
+	ipKeyPrefix        = KindIP + ":"
+	magicLinkKeyPrefix = KindMagicLink + ":"
+	resetKeyPrefix     = KindPasswordReset + ":"
+)
+
+// Policy configures when attempts are delayed or refused.
+type Policy struct {
+	// MaxAccountFailures locks an account after this many consecutive failures.
+	MaxAccountFailures int
+	// MaxIPFailures blocks a client IP after this many failures across all accounts.
+	MaxIPFailures int
+	// FreeAttempts failures are tolerated before backoff starts.
+	FreeAttempts int
+	// BaseDelay is the first backoff delay; it doubles with each further failure.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff delay.
+	MaxDelay time.Duration
+	// LockoutDuration is how long a lock lasts once a threshold is reached.
+	LockoutDuration time.Duration
+	// Window forgets failures after this long without a new one.
+	Window time.Duration
+	// MaxMagicLinkRequests refuses sign-in link requests from a client IP
+	// after this many within Window. Zero disables the limit.
+	MaxMagicLinkRequests int
+	// MaxPasswordResetRequests refuses password reset requests from a client
+	// IP after this many within Window. Zero disables the limit.
+	MaxPasswordResetRequests int
+}
+
+// DefaultPolicy returns the limits used when nothing is configured.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAccountFailures: 10,
+		MaxIPFailures:      100,
+		FreeAttempts:       3,
+		BaseDelay:          time.Second,
+		MaxDelay:           time.Minute,
+		LockoutDuration:    15 * time.Minute,
+		Window:             15 * time.Minute,
+
+		MaxMagicLinkRequests:     20,
+		MaxPasswordResetRequests: 20,
+	}
+}
+
+// BlockedError reports that an attempt was refused.
+type BlockedError struct {
+	RetryAfter time.Duration
+	// Locked is true for an account lockout, false for backoff or an IP block.
+	Locked bool
+}
+
+func (e *BlockedError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("account locked, retry in %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("too many attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// Kinds of keys counted by the service.
+const (
+	KindAccount = "account"
+	KindIP      = "ip"
+	// KindMagicLink counts sign-in link requests per client IP, apart from
+	// failed sign-ins so that requesting links never blocks a password login.
+	KindMagicLink = "magic-link"
+	// KindPasswordReset counts password reset requests per client IP.
+	KindPasswordReset = "password-reset"
+)
+
+// Entry describes a blocked key for administrators.
+type Entry struct {
+	// Kind is KindAccount, KindIP, KindMagicLink or KindPasswordReset.
+	Kind         string
+	Subject      string
+	Failures     int
+	LastFailure  time.Time
+	BlockedUntil time.Time
+}
+
+// Service applies a Policy using a shared counter store.
+type Service struct {
+	store  repository.LoginThrottleRepository
+	policy Policy
+	now    func() time.Time
+}
+
+// New constructs a lockout service.
+func New(store repository.LoginThrottleRepository, policy Policy) *Service {
+	return &Service{
+		store:  store,
+		policy: policy,
+		now:    time.Now,
+	}
+}
+
+// WithNow overrides the time source (testing).
+func (s *Service) WithNow(fn func() time.Time) {
+	if fn != nil {
+		s.now = fn
+	}
+}
+
+// Check returns a *BlockedError when the account or IP may not attempt to
+// sign in right now. An empty account or ip skips that check.
+func (s *Service) Check(ctx context.Context, account, ip string) error {
+	now := s.now()
+	var blocked *BlockedError
+	for _, key := range s.keys(account, ip) {
+		throttle, err := s.store.Get(ctx, key)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				continue
+			}
+			return err
+		}
+		if !throttle.BlockedAt(now) {
+			continue
+		}
+		if blocked == nil {
+			blocked = &BlockedError{}
+		}
+		if wait := throttle.BlockedUntil.Sub(now); wait > blocked.RetryAfter {
+			blocked.RetryAfter = wait
+		}
+		if strings.HasPrefix(key, accountKeyPrefix) && throttle.Failures >= s.policy.MaxAccountFailures {
+			blocked.Locked = true
+		}
+	}
+	if blocked != nil {
+		return blocked
+	}
+	return nil
+}
+
+// RecordFailure counts a failed attempt against the account and IP and
+// applies backoff or a lockout once the thresholds are reached. An empty
+// account or ip is not counted.
+func (s *Service) RecordFailure(ctx context.Context, account, ip string) error {
+	now := s.now().UTC()
+	for _, key := range s.keys(account, ip) {
+		throttle, err := s.store.RecordFailure(ctx, key, now, s.policy.Window)
+		if err != nil {
+			return err
+		}
+		max := s.policy.MaxAccountFailures
+		if strings.HasPrefix(key, ipKeyPrefix) {
+			max = s.policy.MaxIPFailures
+		}
+		if delay := s.delay(throttle.Failures, max); delay > 0 {
+			if err := s.store.Block(ctx, key, now.Add(delay)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RecordMagicLinkRequest counts a sign-in link request from ip and returns a
+// *BlockedError once the client has used up Policy.MaxMagicLinkRequests for
+// the current window. An empty ip is not counted.
+func (s *Service) RecordMagicLinkRequest(ctx context.Context, ip string) error {
+	return s.recordRequest(ctx, magicLinkKeyPrefix, ip, s.policy.MaxMagicLinkRequests)
+}
+
+// RecordPasswordResetRequest counts a password reset request from ip like
+// RecordMagicLinkRequest, against Policy.MaxPasswordResetRequests.
+func (s *Service) RecordPasswordResetRequest(ctx context.Context, ip string) error {
+	return s.recordRequest(ctx, resetKeyPrefix, ip, s.policy.MaxPasswordResetRequests)
+}
+
+// recordRequest counts an emailed-link request under prefix+ip and blocks the
+// key for Window once max requests were made.
+func (s *Service) recordRequest(ctx context.Context, prefix, ip string, max int) error {
+	if ip == "" || max <= 0 {
+		return nil
+	}
+	now := s.now().UTC()
+	key := prefix + ip
+	throttle, err := s.store.Get(ctx, key)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+	case err != nil:
+		return err
+	case throttle.BlockedAt(now):
+		return &BlockedError{RetryAfter: throttle.BlockedUntil.Sub(now)}
+	}
+	throttle, err = s.store.RecordFailure(ctx, key, now, s.policy.Window)
+	if err != nil {
+		return err
+	}
+	if throttle.Failures >= max {
+		return s.store.Block(ctx, key, now.Add(s.policy.Window))
+	}
+	return nil
+}
+
+// RecordSuccess clears the account counter after a successful sign-in. The
+// IP counter is left alone so one valid account cannot mask guessing
+// against others from the same address.
+func (s *Service) RecordSuccess(ctx context.Context, account string) error {
+	return s.store.Reset(ctx, accountKey(account))
+}
+
+// ListBlocked returns accounts and IPs that are currently blocked.
+func (s *Service) ListBlocked(ctx context.Context) ([]Entry, error) {
+	throttles, err := s.store.ListBlocked(ctx, s.now())
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(throttles))
+	for _, throttle := range throttles {
+		kind, subject, _ := strings.Cut(throttle.Key, ":")
+		entries = append(entries, Entry{
+			Kind:         kind,
+			Subject:      subject,
+			Failures:     throttle.Failures,
+			LastFailure:  throttle.LastFailureAt,
+			BlockedUntil: *throttle.BlockedUntil,
+		})
+	}
+	return entries, nil
+}
+
+// UnlockAccount lifts a lockout and forgets the account's failures.
+func (s *Service) UnlockAccount(ctx context.Context, account string) error {
+	return s.store.Reset(ctx, accountKey(account))
+}
+
+// UnlockIP lifts a block on a client address and forgets its failures.
+func (s *Service) UnlockIP(ctx context.Context, ip string) error {
+	return s.store.Reset(ctx, ipKeyPrefix+strings.TrimSpace(ip))
+}
+
+// UnlockMagicLink lets a client address request sign-in links again.
+func (s *Service) UnlockMagicLink(ctx context.Context, ip string) error {
+	return s.store.Reset(ctx, magicLinkKeyPrefix+strings.TrimSpace(ip))
+}
+
+// UnlockPasswordReset lets a client address request password resets again.
+func (s *Service) UnlockPasswordReset(ctx context.Context, ip string) error {
+	return s.store.Reset(ctx, resetKeyPrefix+strings.TrimSpace(ip))
+}
+
+// delay returns how long to block after the given number of consecutive failures.
+func (s *Service) delay(failures, max int) time.Duration {
+	if max > 0 && failures >= max {
+		return s.policy.LockoutDuration
+	}
+	if failures <= s.policy.FreeAttempts || s.policy.BaseDelay <= 0 {
+		return 0
+	}
+	delay := s.policy.BaseDelay
+	for i := s.policy.FreeAttempts + 1; i < failures && delay < s.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if s.policy.MaxDelay > 0 && delay > s.policy.MaxDelay {
+		delay = s.policy.MaxDelay
+	}
+	return delay
+}
+
+func (s *Service) keys(account, ip string) []string {
+	var keys []string
+	if account != "" {
+		keys = append(keys, accountKey(account))
+	}
+	if ip != "" {
+		keys = append(keys, ipKeyPrefix+ip)
+	}
+	return keys
+}
+
+func accountKey(account string) string {
+	return accountKeyPrefix + strings.TrimSpace(strings.ToLower(account))
+}
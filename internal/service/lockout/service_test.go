@@ -0,0 +1,163 @@
+package lockout_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-todo-service/internal/repository/memory"
+	"go-todo-service/internal/service/lockout"
+)
+
+func newTestService(now *time.Time) *lockout.Service {
+	service := lockout.New(memory.NewLoginThrottleRepository(), lockout.Policy{
+		MaxAccountFailures: 5,
+		MaxIPFailures:      8,
+		FreeAttempts:       2,
+		BaseDelay:          time.Second,
+		MaxDelay:           4 * time.Second,
+		LockoutDuration:    15 * time.Minute,
+		Window:             time.Hour,
+
+		MaxMagicLinkRequests: 3,
+	})
+	service.WithNow(func() time.Time { return *now })
+	return service
+}
+
+func blockedError(t *testing.T, err error) *lockout.BlockedError {
+	t.Helper()
+	var blocked *lockout.BlockedError
+	if !errors.As(err, &blocked) {
+		t.Fatalf("expected BlockedError, got %v", err)
+	}
+	return blocked
+}
+
+func TestBackoffThenAccountLockout(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service := newTestService(&now)
+	ctx := context.Background()
+
+	expected := []time.Duration{0, 0, time.Second, 2 * time.Second, 15 * time.Minute}
+	for i, want := range expected {
+		if err := service.Check(ctx, "User@Example.com", "10.0.0.1"); err != nil {
+			t.Fatalf("attempt %d: expected to be allowed, got %v", i+1, err)
+		}
+		if err := service.RecordFailure(ctx, "user@example.com", "10.0.0.1"); err != nil {
+			t.Fatalf("record failure: %v", err)
+		}
+		err := service.Check(ctx, "user@example.com", "10.0.0.1")
+		if want == 0 {
+			if err != nil {
+				t.Fatalf("failure %d: expected no delay, got %v", i+1, err)
+			}
+			continue
+		}
+		blocked := blockedError(t, err)
+		if blocked.RetryAfter != want {
+			t.Fatalf("failure %d: expected retry after %s, got %s", i+1, want, blocked.RetryAfter)
+		}
+		locked := i == len(expected)-1
+		if blocked.Locked != locked {
+			t.Fatalf("failure %d: expected locked=%v", i+1, locked)
+		}
+		if !locked {
+			now = now.Add(want)
+		}
+	}
+
+	entries, err := service.ListBlocked(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Kind != "account" || entries[0].Subject != "user@example.com" || entries[0].Failures != 5 {
+		t.Fatalf("expected locked account first, got %+v", entries)
+	}
+	if entries[1].Kind != "ip" || entries[1].Subject != "10.0.0.1" {
+		t.Fatalf("expected backed-off ip second, got %+v", entries[1])
+	}
+
+	now = now.Add(5 * time.Second)
+
+	if err := service.UnlockAccount(ctx, "user@example.com"); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if err := service.Check(ctx, "user@example.com", "10.0.0.1"); err != nil {
+		t.Fatalf("expected unlocked account, got %v", err)
+	}
+}
+
+func TestIPBlockedAcrossAccounts(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service := newTestService(&now)
+	ctx := context.Background()
+
+	for i := 0; i < 8; i++ {
+		account := string(rune('a'+i)) + "@example.com"
+		if err := service.RecordFailure(ctx, account, "10.0.0.1"); err != nil {
+			t.Fatalf("record failure: %v", err)
+		}
+	}
+
+	blocked := blockedError(t, service.Check(ctx, "fresh@example.com", "10.0.0.1"))
+	if blocked.Locked || blocked.RetryAfter != 15*time.Minute {
+		t.Fatalf("expected ip block without account lock, got %+v", blocked)
+	}
+	if err := service.Check(ctx, "fresh@example.com", "10.0.0.2"); err != nil {
+		t.Fatalf("expected other ip to be allowed, got %v", err)
+	}
+
+	if err := service.UnlockIP(ctx, "10.0.0.1"); err != nil {
+		t.Fatalf("unlock ip: %v", err)
+	}
+	if err := service.Check(ctx, "fresh@example.com", "10.0.0.1"); err != nil {
+		t.Fatalf("expected ip to be unlocked, got %v", err)
+	}
+}
+
+func TestSuccessResetsAccountOnly(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service := newTestService(&now)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_ = service.RecordFailure(ctx, "user@example.com", "")
+	}
+	if err := service.Check(ctx, "user@example.com", ""); err == nil {
+		t.Fatal("expected backoff after three failures")
+	}
+	if err := service.RecordSuccess(ctx, "user@example.com"); err != nil {
+		t.Fatalf("record success: %v", err)
+	}
+	if err := service.Check(ctx, "user@example.com", ""); err != nil {
+		t.Fatalf("expected reset after success, got %v", err)
+	}
+}
+
+func TestMagicLinkRequestsLimitedApartFromFailures(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service := newTestService(&now)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := service.RecordMagicLinkRequest(ctx, "10.0.0.1"); err != nil {
+			t.Fatalf("request %d: expected to be allowed, got %v", i+1, err)
+		}
+	}
+	blocked := blockedError(t, service.RecordMagicLinkRequest(ctx, "10.0.0.1"))
+	if blocked.Locked || blocked.RetryAfter != time.Hour {
+		t.Fatalf("expected the window to be waited out, got %+v", blocked)
+	}
+	if err := service.Check(ctx, "user@example.com", "10.0.0.1"); err != nil {
+		t.Fatalf("expected sign-in from the address to be allowed, got %v", err)
+	}
+
+	if err := service.UnlockMagicLink(ctx, "10.0.0.1"); err != nil {
+		t.Fatalf("unlock magic link: %v", err)
+	}
+	if err := service.RecordMagicLinkRequest(ctx, "10.0.0.1"); err != nil {
+		t.Fatalf("expected requests to be allowed again, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package pat
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository"
+	"go-todo-service/pkg/token"
+	"go-todo-service/pkg/uuid"
+)
+
+// TokenPrefix marks personal access tokens so they can be told apart from JWTs.
+const TokenPrefix = "tdp_"
+
+// lastUsedResolution bounds how often last-used timestamps are written.
+const lastUsedResolution = time.Minute
+
+var (
+	// ErrNameRequired indicates a missing token name.
+	ErrNameRequired = errors.New("name is required")
+	// ErrScopesRequired indicates no scopes were requested.
+	ErrScopesRequired = errors.New("at least one scope is required")
+	// ErrInvalidScope indicates a scope that cannot be granted.
+	ErrInvalidScope = errors.New("invalid scope")
+	// ErrInvalidExpiry indicates an expiry that is not in the future.
+	ErrInvalidExpiry = errors.New("expires_at must be in the future")
+	// ErrInvalidToken indicates an unknown, revoked or expired token.
+	ErrInvalidToken = errors.New("invalid personal access token")
+)
+
+// Service manages personal access tokens.
+type Service struct {
+	tokens repository.PersonalAccessTokenRepository
+	now    func() time.Time
+}
+
+// CreateInput carries the fields accepted when creating a token.
+type CreateInput struct {
+	Name   string
+	Scopes []string
+	// ExpiresAt is optional; tokens without it never expire.
+	ExpiresAt *time.Time
+}
+
+// New constructs a personal access token service.
+func New(tokens repository.PersonalAccessTokenRepository) *Service {
+	return &Service{
+		tokens: tokens,
+		now:    time.Now,
+	}
+}
+
+// WithNow overrides the time source (testing).
+func (s *Service) WithNow(fn func() time.Time) {
+	if fn != nil {
+		s.now = fn
+	}
+}
+
+// Create issues a token for the user. The plaintext token is returned once
+// and never stored.
+func (s *Service) Create(ctx context.Context, userID string, input CreateInput) (*domain.PersonalAccessToken, string, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, "", ErrNameRequired
+	}
+	scopes, err := parseScopes(input.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := s.now().UTC()
+	var expiresAt *time.Time
+	if input.ExpiresAt != nil {
+		if !input.ExpiresAt.After(now) {
+			return nil, "", ErrInvalidExpiry
+		}
+		at := input.ExpiresAt.UTC()
+		expiresAt = &at
+	}
+
+	secret, err := token.Generate()
+	if err != nil {
+		return nil, "", err
+	}
+	plain := TokenPrefix + secret
+
+	id, err := uuid.NewString()
+	if err != nil {
+		return nil, "", err
+	}
+	pat := &domain.PersonalAccessToken{
+		ID:        id,
+		UserID:    userID,
+		Name:      name,
+		TokenHash: token.Hash(plain),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	if err := s.tokens.Create(ctx, pat); err != nil {
+		return nil, "", err
+	}
+	return pat, plain, nil
+}
+
+// List returns the user's tokens.
+func (s *Service) List(ctx context.Context, userID string) ([]domain.PersonalAccessToken, error) {
+	return s.tokens.ListByUser(ctx, userID)
+}
+
+// Revoke invalidates one of the user's tokens.
+func (s *Service) Revoke(ctx context.Context, userID, id string) error {
+	return s.tokens.Revoke(ctx, userID, id, s.now().UTC())
+}
+
+// Authenticate resolves a plaintext token to its active record and records
+// its use.
+func (s *Service) Authenticate(ctx context.Context, plain string) (*domain.PersonalAccessToken, error) {
+	if !IsToken(plain) {
+		return nil, ErrInvalidToken
+	}
+	pat, err := s.tokens.GetByHash(ctx, token.Hash(plain))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	if pat.RevokedAt != nil || (pat.ExpiresAt != nil && !now.Before(*pat.ExpiresAt)) {
+		return nil, ErrInvalidToken
+	}
+
+	if pat.LastUsedAt == nil || now.Sub(*pat.LastUsedAt) >= lastUsedResolution {
+		if err := s.tokens.TouchLastUsed(ctx, pat.ID, now); err != nil {
+			return nil, err
+		}
+		pat.LastUsedAt = &now
+	}
+	return pat, nil
+}
+
+// IsToken reports whether the bearer credential looks like a personal access token.
+func IsToken(value string) bool {
+	return strings.HasPrefix(value, TokenPrefix)
+}
+
+func parseScopes(values []string) ([]domain.Scope, error) {
+	if len(values) == 0 {
+		return nil, ErrScopesRequired
+	}
+	scopes := make([]domain.Scope, 0, len(values))
+	for _, value := range values {
+		scope := domain.Scope(strings.TrimSpace(value))
+		if !slices.Contains(domain.AssignableScopes, scope) {
+			return nil, ErrInvalidScope
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
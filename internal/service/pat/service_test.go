@@ -0,0 +1,149 @@
+package pat_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go-todo-service/internal/domain"
+	patsvc "go-todo-service/internal/service/pat"
+)
+
+type fakeTokenRepo struct {
+	tokens map[string]*domain.PersonalAccessToken
+}
+
+func newFakeTokenRepo() *fakeTokenRepo {
+	return &fakeTokenRepo{
+		tokens: make(map[string]*domain.PersonalAccessToken),
+	}
+}
+
+func (r *fakeTokenRepo) Create(ctx context.Context, token *domain.PersonalAccessToken) error {
+	t := *token
+	r.tokens[token.ID] = &t
+	return nil
+}
+
+func (r *fakeTokenRepo) ListByUser(ctx context.Context, userID string) ([]domain.PersonalAccessToken, error) {
+	var out []domain.PersonalAccessToken
+	for _, token := range r.tokens {
+		if token.UserID == userID {
+			out = append(out, *token)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeTokenRepo) GetByHash(ctx context.Context, tokenHash string) (*domain.PersonalAccessToken, error) {
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			t := *token
+			return &t, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *fakeTokenRepo) Revoke(ctx context.Context, userID, id string, at time.Time) error {
+	token, ok := r.tokens[id]
+	if !ok || token.UserID != userID || token.RevokedAt != nil {
+		return domain.ErrNotFound
+	}
+	token.RevokedAt = &at
+	return nil
+}
+
+func (r *fakeTokenRepo) RevokeByUser(ctx context.Context, userID string, at time.Time) error {
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *fakeTokenRepo) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	if token, ok := r.tokens[id]; ok {
+		token.LastUsedAt = &at
+	}
+	return nil
+}
+
+func TestCreateAndAuthenticate(t *testing.T) {
+	repo := newFakeTokenRepo()
+	service := patsvc.New(repo)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return now })
+
+	created, plain, err := service.Create(context.Background(), "user-1", patsvc.CreateInput{
+		Name:   "ci",
+		Scopes: []string{"tasks:read"},
+	})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if !strings.HasPrefix(plain, patsvc.TokenPrefix) {
+		t.Fatalf("expected token prefix, got %q", plain)
+	}
+	if repo.tokens[created.ID].TokenHash == plain {
+		t.Fatal("token must not be stored in plaintext")
+	}
+
+	authenticated, err := service.Authenticate(context.Background(), plain)
+	if err != nil {
+		t.Fatalf("authenticate failed: %v", err)
+	}
+	if authenticated.UserID != "user-1" || !slices.Equal(authenticated.Scopes, []domain.Scope{domain.ScopeTasksRead}) {
+		t.Fatalf("unexpected token %+v", authenticated)
+	}
+	if last := repo.tokens[created.ID].LastUsedAt; last == nil || !last.Equal(now) {
+		t.Fatalf("expected last used to be recorded, got %v", last)
+	}
+}
+
+func TestCreateRejectsUnknownScope(t *testing.T) {
+	service := patsvc.New(newFakeTokenRepo())
+	_, _, err := service.Create(context.Background(), "user-1", patsvc.CreateInput{Name: "ci", Scopes: []string{"admin"}})
+	if err != patsvc.ErrInvalidScope {
+		t.Fatalf("expected ErrInvalidScope, got %v", err)
+	}
+}
+
+func TestAuthenticateRejectsExpiredAndRevoked(t *testing.T) {
+	service := patsvc.New(newFakeTokenRepo())
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return now })
+	expiresAt := now.Add(time.Hour)
+
+	expiring, expiringPlain, err := service.Create(context.Background(), "user-1", patsvc.CreateInput{
+		Name:      "short",
+		Scopes:    []string{"tasks:write"},
+		ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	_, revokedPlain, err := service.Create(context.Background(), "user-1", patsvc.CreateInput{Name: "revoked", Scopes: []string{"tasks:read"}})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	tokens, _ := service.List(context.Background(), "user-1")
+	for _, token := range tokens {
+		if token.ID != expiring.ID {
+			if err := service.Revoke(context.Background(), "user-1", token.ID); err != nil {
+				t.Fatalf("revoke failed: %v", err)
+			}
+		}
+	}
+
+	if _, err := service.Authenticate(context.Background(), revokedPlain); err != patsvc.ErrInvalidToken {
+		t.Fatalf("expected revoked token to be rejected, got %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+	if _, err := service.Authenticate(context.Background(), expiringPlain); err != patsvc.ErrInvalidToken {
+		t.Fatalf("expected expired token to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,318 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository"
+	"go-todo-service/pkg/uuid"
+)
+
+const (
+	// MaxNameLength bounds project names in characters.
+	MaxNameLength = 100
+	// MaxDescriptionLength bounds project descriptions in characters.
+	MaxDescriptionLength = 2000
+)
+
+var (
+	// ErrNameRequired indicates a missing project name.
+	ErrNameRequired = errors.New("name is required")
+	// ErrInvalidName indicates a name that is too long or contains control characters.
+	ErrInvalidName = errors.New("name must be at most 100 characters without control characters")
+	// ErrDescriptionTooLong indicates a description over MaxDescriptionLength.
+	ErrDescriptionTooLong = errors.New("description must be at most 2000 characters")
+	// ErrInvalidColor indicates a colour that is not a #rrggbb hex value.
+	ErrInvalidColor = errors.New("color must be a hex colour such as #1e90ff")
+	// ErrInboxProtected indicates an attempt to archive or delete the inbox.
+	ErrInboxProtected = errors.New("the inbox cannot be archived or deleted")
+	// ErrInvalidDeleteMode indicates an unsupported way of handling a deleted project's tasks.
+	ErrInvalidDeleteMode = errors.New("mode must be one of move, cascade")
+)
+
+// DeleteMode selects what happens to the tasks of a deleted project.
+type DeleteMode string
+
+const (
+	// DeleteMoveToInbox moves the tasks to the user's inbox.
+	DeleteMoveToInbox DeleteMode = "move"
+	// DeleteCascade deletes the tasks with the project.
+	DeleteCascade DeleteMode = "cascade"
+)
+
+// Service manages a user's projects.
+type Service struct {
+	projects repository.ProjectRepository
+	now      func() time.Time
+}
+
+// CreateInput carries the fields accepted when creating a project.
+type CreateInput struct {
+	Name        string
+	Description string
+	// Color defaults to domain.DefaultColor when empty.
+	Color string
+	// SortOrder defaults to after the user's last project when nil.
+	SortOrder *int
+}
+
+// UpdateInput carries the fields accepted when updating a project. Nil fields
+// keep the stored value.
+type UpdateInput struct {
+	Name        *string
+	Description *string
+	Color       *string
+	Archived    *bool
+	SortOrder   *int
+}
+
+// New constructs a project service.
+func New(projects repository.ProjectRepository) *Service {
+	return &Service{
+		projects: projects,
+		now:      time.Now,
+	}
+}
+
+// WithNow overrides the time source (testing).
+func (s *Service) WithNow(fn func() time.Time) {
+	if fn != nil {
+		s.now = fn
+	}
+}
+
+// Create stores a new project.
+func (s *Service) Create(ctx context.Context, userID string, input CreateInput) (*domain.Project, error) {
+	name, err := parseName(input.Name)
+	if err != nil {
+		return nil, err
+	}
+	description, err := parseDescription(input.Description)
+	if err != nil {
+		return nil, err
+	}
+	color := domain.DefaultColor
+	if strings.TrimSpace(input.Color) != "" {
+		if color, err = parseColor(input.Color); err != nil {
+			return nil, err
+		}
+	}
+
+	var sortOrder int
+	if input.SortOrder != nil {
+		sortOrder = *input.SortOrder
+	} else {
+		existing, err := s.projects.ListByUser(ctx, userID, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, project := range existing {
+			sortOrder = max(sortOrder, project.SortOrder+1)
+		}
+	}
+
+	project, err := s.newProject(userID, name)
+	if err != nil {
+		return nil, err
+	}
+	project.Description = description
+	project.Color = color
+	project.SortOrder = sortOrder
+	if err := s.projects.Create(ctx, project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// Inbox returns the user's inbox project, creating it on first use.
+func (s *Service) Inbox(ctx context.Context, userID string) (*domain.Project, error) {
+	inbox, err := s.projects.GetInbox(ctx, userID)
+	if err == nil || !errors.Is(err, domain.ErrNotFound) {
+		return inbox, err
+	}
+
+	inbox, err = s.newProject(userID, domain.InboxName)
+	if err != nil {
+		return nil, err
+	}
+	inbox.Color = domain.DefaultColor
+	inbox.Inbox = true
+	if err := s.projects.Create(ctx, inbox); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			// Created concurrently by another request.
+			return s.projects.GetInbox(ctx, userID)
+		}
+		return nil, err
+	}
+	return inbox, nil
+}
+
+// RestoredProject validates a project carried over from an export archive
+// and returns it ready to be stored for the user; it stores nothing itself.
+// The project gets a new id and keeps its archive flag, sort order and
+// timestamps. It is never an inbox: archived inboxes map to the user's own.
+func (s *Service) RestoredProject(ctx context.Context, userID string, source domain.Project) (*domain.Project, error) {
+	name, err := parseName(source.Name)
+	if err != nil {
+		return nil, err
+	}
+	description, err := parseDescription(source.Description)
+	if err != nil {
+		return nil, err
+	}
+	color := domain.DefaultColor
+	if strings.TrimSpace(source.Color) != "" {
+		if color, err = parseColor(source.Color); err != nil {
+			return nil, err
+		}
+	}
+
+	project, err := s.newProject(userID, name)
+	if err != nil {
+		return nil, err
+	}
+	project.Description = description
+	project.Color = color
+	project.Archived = source.Archived
+	project.SortOrder = source.SortOrder
+	if !source.CreatedAt.IsZero() {
+		project.CreatedAt = source.CreatedAt.UTC()
+		project.UpdatedAt = project.CreatedAt
+	}
+	if !source.UpdatedAt.IsZero() {
+		project.UpdatedAt = source.UpdatedAt.UTC()
+	}
+	return project, nil
+}
+
+// List returns the user's projects in display order, archived ones only when asked.
+func (s *Service) List(ctx context.Context, userID string, includeArchived bool) ([]domain.Project, error) {
+	return s.projects.ListByUser(ctx, userID, includeArchived)
+}
+
+// Get fetches a project owned by the user.
+func (s *Service) Get(ctx context.Context, userID, id string) (*domain.Project, error) {
+	if !uuid.Valid(id) {
+		return nil, domain.ErrNotFound
+	}
+	project, err := s.projects.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if project.UserID != userID {
+		return nil, domain.ErrNotFound
+	}
+	return project, nil
+}
+
+// Update changes the provided fields of a project owned by the user.
+func (s *Service) Update(ctx context.Context, userID, id string, input UpdateInput) (*domain.Project, error) {
+	project, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Name != nil {
+		if project.Name, err = parseName(*input.Name); err != nil {
+			return nil, err
+		}
+	}
+	if input.Description != nil {
+		if project.Description, err = parseDescription(*input.Description); err != nil {
+			return nil, err
+		}
+	}
+	if input.Color != nil {
+		if project.Color, err = parseColor(*input.Color); err != nil {
+			return nil, err
+		}
+	}
+	if input.Archived != nil {
+		if *input.Archived && project.Inbox {
+			return nil, ErrInboxProtected
+		}
+		project.Archived = *input.Archived
+	}
+	if input.SortOrder != nil {
+		project.SortOrder = *input.SortOrder
+	}
+
+	project.UpdatedAt = s.now().UTC()
+	if err := s.projects.Update(ctx, project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// Delete removes a project owned by the user. Its tasks move to the inbox
+// unless mode is DeleteCascade; an empty mode means DeleteMoveToInbox.
+func (s *Service) Delete(ctx context.Context, userID, id string, mode DeleteMode) error {
+	switch mode {
+	case "", DeleteMoveToInbox, DeleteCascade:
+	default:
+		return ErrInvalidDeleteMode
+	}
+	project, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if project.Inbox {
+		return ErrInboxProtected
+	}
+
+	var moveTo string
+	if mode != DeleteCascade {
+		inbox, err := s.Inbox(ctx, userID)
+		if err != nil {
+			return err
+		}
+		moveTo = inbox.ID
+	}
+	return s.projects.Delete(ctx, project.ID, moveTo)
+}
+
+func (s *Service) newProject(userID, name string) (*domain.Project, error) {
+	id, err := uuid.NewString()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().UTC()
+	return &domain.Project{
+		ID:        id,
+		UserID:    userID,
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+func parseName(value string) (string, error) {
+	name := strings.TrimSpace(value)
+	if name == "" {
+		return "", ErrNameRequired
+	}
+	if utf8.RuneCountInString(name) > MaxNameLength || strings.ContainsFunc(name, unicode.IsControl) {
+		return "", ErrInvalidName
+	}
+	return name, nil
+}
+
+func parseDescription(value string) (string, error) {
+	description := strings.TrimSpace(value)
+	if utf8.RuneCountInString(description) > MaxDescriptionLength {
+		return "", ErrDescriptionTooLong
+	}
+	return description, nil
+}
+
+func parseColor(value string) (string, error) {
+	color, ok := domain.NormalizeColor(value)
+	if !ok {
+		return "", ErrInvalidColor
+	}
+	return color, nil
+}
@@ -0,0 +1,215 @@
+package project_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"go-todo-service/internal/domain"
+	projectsvc "go-todo-service/internal/service/project"
+)
+
+type fakeProjectRepo struct {
+	projects map[string]domain.Project
+	// taskProjects maps task ids to the project they are filed under.
+	taskProjects map[string]string
+}
+
+func newFakeProjectRepo() *fakeProjectRepo {
+	return &fakeProjectRepo{
+		projects:     make(map[string]domain.Project),
+		taskProjects: make(map[string]string),
+	}
+}
+
+func (r *fakeProjectRepo) Create(ctx context.Context, project *domain.Project) error {
+	if project.Inbox {
+		if _, err := r.GetInbox(ctx, project.UserID); err == nil {
+			return domain.ErrConflict
+		}
+	}
+	r.projects[project.ID] = *project
+	return nil
+}
+
+func (r *fakeProjectRepo) GetByID(ctx context.Context, id string) (*domain.Project, error) {
+	project, ok := r.projects[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &project, nil
+}
+
+func (r *fakeProjectRepo) GetInbox(ctx context.Context, userID string) (*domain.Project, error) {
+	for _, project := range r.projects {
+		if project.UserID == userID && project.Inbox {
+			return &project, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *fakeProjectRepo) ListByUser(ctx context.Context, userID string, includeArchived bool) ([]domain.Project, error) {
+	var out []domain.Project
+	for _, project := range r.projects {
+		if project.UserID == userID && (includeArchived || !project.Archived) {
+			out = append(out, project)
+		}
+	}
+	slices.SortFunc(out, func(a, b domain.Project) int {
+		if a.SortOrder != b.SortOrder {
+			return a.SortOrder - b.SortOrder
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return out, nil
+}
+
+func (r *fakeProjectRepo) Update(ctx context.Context, project *domain.Project) error {
+	if _, ok := r.projects[project.ID]; !ok {
+		return domain.ErrNotFound
+	}
+	r.projects[project.ID] = *project
+	return nil
+}
+
+func (r *fakeProjectRepo) Delete(ctx context.Context, id, moveTasksTo string) error {
+	if _, ok := r.projects[id]; !ok {
+		return domain.ErrNotFound
+	}
+	for taskID, projectID := range r.taskProjects {
+		if projectID != id {
+			continue
+		}
+		if moveTasksTo != "" {
+			r.taskProjects[taskID] = moveTasksTo
+		} else {
+			delete(r.taskProjects, taskID)
+		}
+	}
+	delete(r.projects, id)
+	return nil
+}
+
+func TestInboxIsCreatedOnce(t *testing.T) {
+	service := projectsvc.New(newFakeProjectRepo())
+	ctx := context.Background()
+
+	inbox, err := service.Inbox(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !inbox.Inbox || inbox.Name != domain.InboxName {
+		t.Fatalf("unexpected inbox: %+v", inbox)
+	}
+	again, err := service.Inbox(ctx, "user-1")
+	if err != nil || again.ID != inbox.ID {
+		t.Fatalf("expected the same inbox, got %+v, %v", again, err)
+	}
+
+	archived := true
+	if _, err := service.Update(ctx, "user-1", inbox.ID, projectsvc.UpdateInput{Archived: &archived}); err != projectsvc.ErrInboxProtected {
+		t.Fatalf("expected ErrInboxProtected on archive, got %v", err)
+	}
+	if err := service.Delete(ctx, "user-1", inbox.ID, projectsvc.DeleteCascade); err != projectsvc.ErrInboxProtected {
+		t.Fatalf("expected ErrInboxProtected on delete, got %v", err)
+	}
+}
+
+func TestCreateProjectDefaultsAndValidation(t *testing.T) {
+	service := projectsvc.New(newFakeProjectRepo())
+	ctx := context.Background()
+
+	first, err := service.Create(ctx, "user-1", projectsvc.CreateInput{Name: " Work ", Color: "#FFAA00"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.Name != "Work" || first.Color != "#ffaa00" || first.SortOrder != 0 {
+		t.Fatalf("unexpected project: %+v", first)
+	}
+	second, err := service.Create(ctx, "user-1", projectsvc.CreateInput{Name: "Home"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.Color != domain.DefaultColor || second.SortOrder != 1 {
+		t.Fatalf("expected default colour and next sort order, got %+v", second)
+	}
+
+	cases := []struct {
+		input projectsvc.CreateInput
+		want  error
+	}{
+		{projectsvc.CreateInput{}, projectsvc.ErrNameRequired},
+		{projectsvc.CreateInput{Name: strings.Repeat("x", projectsvc.MaxNameLength+1)}, projectsvc.ErrInvalidName},
+		{projectsvc.CreateInput{Name: "x", Description: strings.Repeat("x", projectsvc.MaxDescriptionLength+1)}, projectsvc.ErrDescriptionTooLong},
+		{projectsvc.CreateInput{Name: "x", Color: "blue"}, projectsvc.ErrInvalidColor},
+	}
+	for _, tc := range cases {
+		if _, err := service.Create(ctx, "user-1", tc.input); err != tc.want {
+			t.Fatalf("expected %v, got %v", tc.want, err)
+		}
+	}
+}
+
+func TestUpdateProjectArchivesAndHidesFromList(t *testing.T) {
+	service := projectsvc.New(newFakeProjectRepo())
+	ctx := context.Background()
+
+	project, err := service.Create(ctx, "user-1", projectsvc.CreateInput{Name: "Old", Description: "keep"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.Update(ctx, "user-2", project.ID, projectsvc.UpdateInput{}); err != domain.ErrNotFound {
+		t.Fatalf("expected ErrNotFound for another user, got %v", err)
+	}
+	archived, order := true, 5
+	updated, err := service.Update(ctx, "user-1", project.ID, projectsvc.UpdateInput{Archived: &archived, SortOrder: &order})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !updated.Archived || updated.SortOrder != 5 || updated.Name != "Old" || updated.Description != "keep" {
+		t.Fatalf("expected only archived and sort order to change, got %+v", updated)
+	}
+
+	active, _ := service.List(ctx, "user-1", false)
+	all, _ := service.List(ctx, "user-1", true)
+	if len(active) != 0 || len(all) != 1 {
+		t.Fatalf("expected archived project only with includeArchived, got %d and %d", len(active), len(all))
+	}
+}
+
+func TestDeleteProjectMovesOrCascadesTasks(t *testing.T) {
+	repo := newFakeProjectRepo()
+	service := projectsvc.New(repo)
+	ctx := context.Background()
+
+	inbox, err := service.Inbox(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	moved, _ := service.Create(ctx, "user-1", projectsvc.CreateInput{Name: "Moved"})
+	dropped, _ := service.Create(ctx, "user-1", projectsvc.CreateInput{Name: "Dropped"})
+	repo.taskProjects["task-1"] = moved.ID
+	repo.taskProjects["task-2"] = dropped.ID
+
+	if err := service.Delete(ctx, "user-1", moved.ID, "archive"); !errors.Is(err, projectsvc.ErrInvalidDeleteMode) {
+		t.Fatalf("expected ErrInvalidDeleteMode, got %v", err)
+	}
+	if err := service.Delete(ctx, "user-1", moved.ID, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.taskProjects["task-1"] != inbox.ID {
+		t.Fatalf("expected task to move to the inbox, got %q", repo.taskProjects["task-1"])
+	}
+	if err := service.Delete(ctx, "user-1", dropped.ID, projectsvc.DeleteCascade); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := repo.taskProjects["task-2"]; ok {
+		t.Fatal("expected task to be deleted with its project")
+	}
+	if _, err := service.Get(ctx, "user-1", dropped.ID); err != domain.ErrNotFound {
+		t.Fatalf("expected deleted project to be gone, got %v", err)
+	}
+}
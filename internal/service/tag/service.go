@@ -0,0 +1,196 @@
+package tag
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository"
+	"go-todo-service/pkg/uuid"
+)
+
+// MaxNameLength bounds tag names in characters.
+const MaxNameLength = 50
+
+var (
+	// ErrNameRequired indicates a missing tag name.
+	ErrNameRequired = errors.New("name is required")
+	// ErrInvalidName indicates a name that is too long or contains control characters.
+	ErrInvalidName = errors.New("name must be at most 50 characters without control characters")
+	// ErrInvalidColor indicates a colour that is not a #rrggbb hex value.
+	ErrInvalidColor = errors.New("color must be a hex colour such as #1e90ff")
+)
+
+// Service manages a user's tags.
+type Service struct {
+	tags repository.TagRepository
+	now  func() time.Time
+}
+
+// Input carries the fields accepted when creating or updating a tag. On
+// update, empty fields keep the stored value; on create an empty colour
+// selects domain.DefaultColor.
+type Input struct {
+	Name  string
+	Color string
+}
+
+// New constructs a tag service.
+func New(tags repository.TagRepository) *Service {
+	return &Service{
+		tags: tags,
+		now:  time.Now,
+	}
+}
+
+// WithNow overrides the time source (testing).
+func (s *Service) WithNow(fn func() time.Time) {
+	if fn != nil {
+		s.now = fn
+	}
+}
+
+// Create stores a new tag. A name the user already uses, in any case,
+// returns domain.ErrConflict.
+func (s *Service) Create(ctx context.Context, userID string, input Input) (*domain.Tag, error) {
+	name, err := parseName(input.Name)
+	if err != nil {
+		return nil, err
+	}
+	color := domain.DefaultColor
+	if strings.TrimSpace(input.Color) != "" {
+		if color, err = parseColor(input.Color); err != nil {
+			return nil, err
+		}
+	}
+
+	id, err := uuid.NewString()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().UTC()
+	tag := &domain.Tag{
+		ID:        id,
+		UserID:    userID,
+		Name:      name,
+		Color:     color,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.tags.Create(ctx, tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// RestoredTag validates a tag carried over from an export archive and
+// returns it ready to be stored for the user; it stores nothing itself. The
+// tag gets a new id and keeps its timestamps.
+func (s *Service) RestoredTag(ctx context.Context, userID string, source domain.Tag) (*domain.Tag, error) {
+	name, err := parseName(source.Name)
+	if err != nil {
+		return nil, err
+	}
+	color := domain.DefaultColor
+	if strings.TrimSpace(source.Color) != "" {
+		if color, err = parseColor(source.Color); err != nil {
+			return nil, err
+		}
+	}
+
+	id, err := uuid.NewString()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().UTC()
+	tag := &domain.Tag{
+		ID:        id,
+		UserID:    userID,
+		Name:      name,
+		Color:     color,
+		CreatedAt: source.CreatedAt.UTC(),
+		UpdatedAt: source.UpdatedAt.UTC(),
+	}
+	if tag.CreatedAt.IsZero() {
+		tag.CreatedAt = now
+	}
+	if tag.UpdatedAt.IsZero() {
+		tag.UpdatedAt = tag.CreatedAt
+	}
+	return tag, nil
+}
+
+// List returns the user's tags ordered by name.
+func (s *Service) List(ctx context.Context, userID string) ([]domain.Tag, error) {
+	return s.tags.ListByUser(ctx, userID)
+}
+
+// Get fetches a tag owned by the user.
+func (s *Service) Get(ctx context.Context, userID, id string) (*domain.Tag, error) {
+	if !uuid.Valid(id) {
+		return nil, domain.ErrNotFound
+	}
+	tag, err := s.tags.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if tag.UserID != userID {
+		return nil, domain.ErrNotFound
+	}
+	return tag, nil
+}
+
+// Update renames or recolours a tag owned by the user. Renaming keeps the
+// tag on every task it is attached to.
+func (s *Service) Update(ctx context.Context, userID, id string, input Input) (*domain.Tag, error) {
+	tag, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(input.Name) != "" {
+		if tag.Name, err = parseName(input.Name); err != nil {
+			return nil, err
+		}
+	}
+	if strings.TrimSpace(input.Color) != "" {
+		if tag.Color, err = parseColor(input.Color); err != nil {
+			return nil, err
+		}
+	}
+	tag.UpdatedAt = s.now().UTC()
+	if err := s.tags.Update(ctx, tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// Delete removes a tag owned by the user and detaches it from its tasks.
+func (s *Service) Delete(ctx context.Context, userID, id string) error {
+	if _, err := s.Get(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.tags.Delete(ctx, id)
+}
+
+func parseName(value string) (string, error) {
+	name := strings.TrimSpace(value)
+	if name == "" {
+		return "", ErrNameRequired
+	}
+	if utf8.RuneCountInString(name) > MaxNameLength || strings.ContainsFunc(name, unicode.IsControl) {
+		return "", ErrInvalidName
+	}
+	return name, nil
+}
+
+func parseColor(value string) (string, error) {
+	color, ok := domain.NormalizeColor(value)
+	if !ok {
+		return "", ErrInvalidColor
+	}
+	return color, nil
+}
@@ -0,0 +1,154 @@
+package tag_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-todo-service/internal/domain"
+	tagsvc "go-todo-service/internal/service/tag"
+)
+
+type fakeTagRepo struct {
+	tags map[string]domain.Tag
+}
+
+func newFakeTagRepo() *fakeTagRepo {
+	return &fakeTagRepo{
+		tags: make(map[string]domain.Tag),
+	}
+}
+
+func (r *fakeTagRepo) conflicts(tag *domain.Tag) bool {
+	for _, other := range r.tags {
+		if other.ID != tag.ID && other.UserID == tag.UserID && strings.EqualFold(other.Name, tag.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *fakeTagRepo) Create(ctx context.Context, tag *domain.Tag) error {
+	if r.conflicts(tag) {
+		return domain.ErrConflict
+	}
+	r.tags[tag.ID] = *tag
+	return nil
+}
+
+func (r *fakeTagRepo) GetByID(ctx context.Context, id string) (*domain.Tag, error) {
+	tag, ok := r.tags[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &tag, nil
+}
+
+func (r *fakeTagRepo) ListByUser(ctx context.Context, userID string) ([]domain.Tag, error) {
+	var out []domain.Tag
+	for _, tag := range r.tags {
+		if tag.UserID == userID {
+			out = append(out, tag)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeTagRepo) GetByNames(ctx context.Context, userID string, names []string) ([]domain.Tag, error) {
+	return nil, nil
+}
+
+func (r *fakeTagRepo) Update(ctx context.Context, tag *domain.Tag) error {
+	if r.conflicts(tag) {
+		return domain.ErrConflict
+	}
+	r.tags[tag.ID] = *tag
+	return nil
+}
+
+func (r *fakeTagRepo) Delete(ctx context.Context, id string) error {
+	if _, ok := r.tags[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(r.tags, id)
+	return nil
+}
+
+func (r *fakeTagRepo) SetTaskTags(ctx context.Context, taskID string, tagIDs []string) error {
+	return nil
+}
+
+func (r *fakeTagRepo) ListByTasks(ctx context.Context, taskIDs []string) (map[string][]domain.Tag, error) {
+	return map[string][]domain.Tag{}, nil
+}
+
+func TestCreateTagDefaultsAndValidation(t *testing.T) {
+	service := tagsvc.New(newFakeTagRepo())
+	fixed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time { return fixed })
+	ctx := context.Background()
+
+	tag, err := service.Create(ctx, "user-1", tagsvc.Input{Name: "  Work "})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag.Name != "Work" || tag.Color != domain.DefaultColor || !tag.CreatedAt.Equal(fixed) {
+		t.Fatalf("unexpected tag: %+v", tag)
+	}
+	if _, err := service.Create(ctx, "user-1", tagsvc.Input{Name: "WORK"}); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("expected ErrConflict for duplicate name, got %v", err)
+	}
+	if _, err := service.Create(ctx, "user-2", tagsvc.Input{Name: "work", Color: "#1E90FF"}); err != nil {
+		t.Fatalf("expected another user to reuse the name, got %v", err)
+	}
+
+	cases := []struct {
+		input tagsvc.Input
+		want  error
+	}{
+		{tagsvc.Input{Name: " "}, tagsvc.ErrNameRequired},
+		{tagsvc.Input{Name: strings.Repeat("x", tagsvc.MaxNameLength+1)}, tagsvc.ErrInvalidName},
+		{tagsvc.Input{Name: "a\tb"}, tagsvc.ErrInvalidName},
+		{tagsvc.Input{Name: "home", Color: "red"}, tagsvc.ErrInvalidColor},
+		{tagsvc.Input{Name: "home", Color: "#12345g"}, tagsvc.ErrInvalidColor},
+	}
+	for _, tc := range cases {
+		if _, err := service.Create(ctx, "user-1", tc.input); err != tc.want {
+			t.Fatalf("%+v: expected %v, got %v", tc.input, tc.want, err)
+		}
+	}
+}
+
+func TestUpdateAndDeleteTagOwnership(t *testing.T) {
+	service := tagsvc.New(newFakeTagRepo())
+	ctx := context.Background()
+
+	tag, err := service.Create(ctx, "user-1", tagsvc.Input{Name: "home", Color: "#00AA00"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag.Color != "#00aa00" {
+		t.Fatalf("expected colour to be lower-cased, got %s", tag.Color)
+	}
+	if _, err := service.Update(ctx, "user-2", tag.ID, tagsvc.Input{Name: "mine"}); err != domain.ErrNotFound {
+		t.Fatalf("expected ErrNotFound for another user, got %v", err)
+	}
+	updated, err := service.Update(ctx, "user-1", tag.ID, tagsvc.Input{Name: "House"})
+	if err != nil || updated.Name != "House" || updated.Color != "#00aa00" {
+		t.Fatalf("expected rename keeping colour, got %+v, %v", updated, err)
+	}
+	if _, err := service.Get(ctx, "user-1", "not-a-uuid"); err != domain.ErrNotFound {
+		t.Fatalf("expected ErrNotFound for malformed id, got %v", err)
+	}
+	if err := service.Delete(ctx, "user-2", tag.ID); err != domain.ErrNotFound {
+		t.Fatalf("expected ErrNotFound for another user, got %v", err)
+	}
+	if err := service.Delete(ctx, "user-1", tag.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.Get(ctx, "user-1", tag.ID); err != domain.ErrNotFound {
+		t.Fatalf("expected deleted tag to be gone, got %v", err)
+	}
+}
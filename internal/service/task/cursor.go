@@ -0,0 +1,118 @@
+package task
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository"
+)
+
+// cursorPayload is the JSON document behind an opaque page cursor. It records
+// the sort it was issued for so it cannot be replayed against another ordering.
+type cursorPayload struct {
+	Sort  string `json:"s"`
+	ID    string `json:"id"`
+	Value string `json:"v"`
+	// Due holds the due date of the last task for the priority sort.
+	Due string `json:"d,omitempty"`
+}
+
+// parseSort reads "field" or "field:asc|desc". An empty value yields the
+// default ordering of newest first.
+func parseSort(value string) (repository.TaskSort, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return repository.TaskSort{Field: repository.TaskSortCreatedAt, Descending: true}, nil
+	}
+
+	field, direction, _ := strings.Cut(value, ":")
+	sort := repository.TaskSort{Field: repository.TaskSortField(field)}
+	switch sort.Field {
+	case repository.TaskSortCreatedAt, repository.TaskSortUpdatedAt, repository.TaskSortTitle:
+	case repository.TaskSortPriority:
+		// Most urgent first unless asked otherwise.
+		sort.Descending = direction == ""
+	default:
+		return repository.TaskSort{}, ErrInvalidSort
+	}
+	switch direction {
+	case "", "asc":
+	case "desc":
+		sort.Descending = true
+	default:
+		return repository.TaskSort{}, ErrInvalidSort
+	}
+	return sort, nil
+}
+
+func sortKey(sort repository.TaskSort) string {
+	if sort.Descending {
+		return string(sort.Field) + ":desc"
+	}
+	return string(sort.Field) + ":asc"
+}
+
+func encodeCursor(sort repository.TaskSort, task domain.Task) (string, error) {
+	payload := cursorPayload{Sort: sortKey(sort), ID: task.ID}
+	switch sort.Field {
+	case repository.TaskSortUpdatedAt:
+		payload.Value = task.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case repository.TaskSortTitle:
+		payload.Value = task.Title
+	case repository.TaskSortPriority:
+		payload.Value = string(task.Priority)
+		if task.DueAt != nil {
+			payload.Due = task.DueAt.UTC().Format(time.RFC3339Nano)
+		}
+	default:
+		payload.Value = task.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(sort repository.TaskSort, value string) (*repository.TaskCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if payload.Sort != sortKey(sort) || payload.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &repository.TaskCursor{ID: payload.ID}
+	switch sort.Field {
+	case repository.TaskSortTitle:
+		cursor.Title = payload.Value
+	case repository.TaskSortPriority:
+		cursor.Priority = domain.TaskPriority(payload.Value)
+		if !cursor.Priority.Valid() {
+			return nil, ErrInvalidCursor
+		}
+		if payload.Due != "" {
+			due, err := time.Parse(time.RFC3339Nano, payload.Due)
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			cursor.DueAt = &due
+		}
+	default:
+		at, err := time.Parse(time.RFC3339Nano, payload.Value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		cursor.CreatedAt = at
+		cursor.UpdatedAt = at
+	}
+	return cursor, nil
+}
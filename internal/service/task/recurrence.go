@@ -0,0 +1,97 @@
+package task
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/pkg/rrule"
+	"go-todo-service/pkg/uuid"
+)
+
+// parseRecurrence validates an RRULE and returns it in canonical form.
+func parseRecurrence(value string) (string, error) {
+	rule, err := rrule.Parse(value)
+	if err != nil {
+		return "", err
+	}
+	return rule.String(), nil
+}
+
+// parseRecurrenceBasis validates a basis, treating an empty value as
+// scheduling from the due date.
+func parseRecurrenceBasis(value string) (domain.RecurrenceBasis, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return domain.RecurFromDue, nil
+	}
+	basis := domain.RecurrenceBasis(value)
+	if !basis.Valid() {
+		return "", ErrInvalidRecurrenceBasis
+	}
+	return basis, nil
+}
+
+// nextOccurrence builds, without storing it, the task that follows a
+// recurring task completed now. It returns nil once the series has ended
+// through COUNT or UNTIL.
+//
+// Timed tasks are scheduled on the wall clock of the user's time zone so
+// they keep their local time of day across DST changes; all-day tasks are
+// scheduled on calendar dates.
+func (s *Service) nextOccurrence(ctx context.Context, task *domain.Task) (*domain.Task, error) {
+	rule, err := rrule.Parse(task.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	userLoc, err := s.location(ctx, task.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// All-day due dates are midnight UTC, so their calendar lives in UTC.
+	loc := time.UTC
+	if !task.DueAllDay {
+		loc = userLoc
+	}
+
+	start := task.DueAt.In(loc)
+	if task.RecurrenceBasis == domain.RecurFromCompletion {
+		// Restart the series on the day of completion, at the usual time.
+		y, m, d := s.now().In(userLoc).Date()
+		hour, minute, second := start.Clock()
+		start = time.Date(y, m, d, hour, minute, second, 0, loc)
+	}
+	due, ok := rule.Next(start, start)
+	if !ok {
+		return nil, nil
+	}
+	// COUNT carries on as the number of occurrences left, the next one
+	// included.
+	if rule.Count > 0 {
+		rule.Count--
+	}
+
+	id, err := uuid.NewString()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().UTC()
+	due = due.UTC()
+	return &domain.Task{
+		ID:              id,
+		UserID:          task.UserID,
+		Title:           task.Title,
+		Description:     task.Description,
+		Status:          domain.TaskStatusPending,
+		Priority:        task.Priority,
+		ProjectID:       task.ProjectID,
+		ParentID:        task.ParentID,
+		DueAt:           &due,
+		DueAllDay:       task.DueAllDay,
+		Recurrence:      rule.String(),
+		RecurrenceBasis: task.RecurrenceBasis,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}, nil
+}
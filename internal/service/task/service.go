@@ -3,11 +3,14 @@ package task
 import (
 	"context"
 	"errors"
+	"slices"
 	"strings"
 	"time"
 
 	"go-todo-service/internal/domain"
 	"go-todo-service/internal/repository"
+	projectsvc "go-todo-service/internal/service/project"
+	"go-todo-service/pkg/rrule"
 	"go-todo-service/pkg/uuid"
 )
 
@@ -18,12 +21,172 @@ var (
 	ErrTitleRequired = errors.New("title is required")
 	// ErrInvalidStatus indicates status is outside supported values.
 	ErrInvalidStatus = errors.New("invalid status")
+	// ErrInvalidPriority indicates priority is outside supported values.
+	ErrInvalidPriority = errors.New("priority must be one of none, low, medium, high, urgent")
+	// ErrInvalidDueRange indicates due_after does not precede due_before.
+	ErrInvalidDueRange = errors.New("due_after must be before due_before")
+	// ErrInvalidSort indicates an unsupported sort field or direction.
+	ErrInvalidSort = errors.New("sort must be one of created_at, updated_at, title, priority with optional :asc or :desc")
+	// ErrInvalidCursor indicates a malformed cursor or one issued for a different sort.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidDueWindow indicates an unsupported calendar view.
+	ErrInvalidDueWindow = errors.New("due must be one of today, week")
+	// ErrInvalidLimit indicates a page size outside the supported range.
+	ErrInvalidLimit = errors.New("limit must be between 1 and 100")
+	// ErrUnknownTag indicates a tag name the user has not created.
+	ErrUnknownTag = errors.New("unknown tag")
+	// ErrInvalidTagMatch indicates an unsupported tag matching mode.
+	ErrInvalidTagMatch = errors.New("tag_match must be one of any, all")
+	// ErrUnknownProject indicates a project the user does not own.
+	ErrUnknownProject = errors.New("unknown project")
+	// ErrProjectArchived indicates an attempt to file a task under an archived project.
+	ErrProjectArchived = errors.New("project is archived")
+	// ErrUnknownParent indicates a parent task the user does not own.
+	ErrUnknownParent = errors.New("unknown parent task")
+	// ErrParentCycle indicates an attempt to nest a task under itself or one of its subtasks.
+	ErrParentCycle = errors.New("a task cannot be nested under itself or its own subtasks")
+	// ErrTooDeep indicates subtasks nested beyond MaxDepth levels.
+	ErrTooDeep = errors.New("subtasks can be nested at most 5 levels deep")
+	// ErrInvalidRecurrence indicates a recurrence that is not a supported
+	// RRULE. Returned errors wrap it with the reason.
+	ErrInvalidRecurrence = rrule.ErrInvalidRule
+	// ErrInvalidRecurrenceBasis indicates an unsupported recurrence basis.
+	ErrInvalidRecurrenceBasis = errors.New("recurrence_basis must be one of due, completion")
+	// ErrRecurrenceNeedsDue indicates a recurring task without a due date.
+	ErrRecurrenceNeedsDue = errors.New("recurring tasks need a due date")
+)
+
+const (
+	// DefaultPageSize is used when ListOptions.Limit is zero.
+	DefaultPageSize = 50
+	// MaxPageSize bounds ListOptions.Limit.
+	MaxPageSize = 100
+	// MaxDepth bounds how many levels a task hierarchy may have, counting
+	// the top-level task.
+	MaxDepth = domain.MaxTaskDepth
+)
+
+// DueWindow names a calendar view evaluated in the user's time zone.
+type DueWindow string
+
+const (
+	// DueToday selects tasks due on the user's current calendar day.
+	DueToday DueWindow = "today"
+	// DueThisWeek selects tasks due in the user's current ISO week (Monday to Sunday).
+	DueThisWeek DueWindow = "week"
+)
+
+// TagMatch selects how a tag filter combines several tags.
+type TagMatch string
+
+const (
+	// TagMatchAny keeps tasks carrying at least one of the tags.
+	TagMatchAny TagMatch = "any"
+	// TagMatchAll keeps tasks carrying every one of the tags.
+	TagMatchAll TagMatch = "all"
 )
 
 // Service encapsulates task management use cases.
 type Service struct {
-	tasks repository.TaskRepository
-	now   func() time.Time
+	tasks    repository.TaskRepository
+	users    repository.UserRepository
+	tags     repository.TagRepository
+	projects *projectsvc.Service
+	now      func() time.Time
+}
+
+// CreateTaskInput carries the fields accepted when creating a task.
+type CreateTaskInput struct {
+	Title       string
+	Description string
+	// Priority defaults to none when empty.
+	Priority  string
+	DueAt     *time.Time
+	DueAllDay bool
+	// Tags names existing tags of the user to attach.
+	Tags []string
+	// ProjectID files the task under a project; empty selects the parent's
+	// project for subtasks and the inbox otherwise.
+	ProjectID string
+	// ParentID makes the task a subtask of another task.
+	ParentID string
+	// Recurrence is an RRULE repeating the task; it requires a due date.
+	Recurrence string
+	// RecurrenceBasis defaults to scheduling from the due date when empty.
+	RecurrenceBasis string
+}
+
+// UpdateTaskInput carries the fields accepted when updating a task. An empty
+// title, status or priority keeps the stored value.
+type UpdateTaskInput struct {
+	Title       string
+	Description string
+	Status      string
+	Priority    string
+	// DueAt replaces the due date when set; ClearDue removes it. Leaving both
+	// unset keeps the stored due date.
+	DueAt     *time.Time
+	DueAllDay bool
+	ClearDue  bool
+	// Tags replaces the attached tags when non-nil; an empty slice removes
+	// them all and nil keeps them.
+	Tags []string
+	// ProjectID moves the task to another project when set.
+	ProjectID string
+	// ParentID moves the task under another task when set; ClearParent
+	// makes it a top-level task.
+	ParentID    string
+	ClearParent bool
+	// Recurrence replaces the RRULE when set; ClearRecurrence makes the task
+	// a one-off. An empty RecurrenceBasis keeps the stored basis.
+	Recurrence      string
+	ClearRecurrence bool
+	RecurrenceBasis string
+}
+
+// ListOptions narrows, orders and paginates the tasks returned by ListTasks.
+type ListOptions struct {
+	// ProjectID keeps tasks filed under the project.
+	ProjectID string
+	// ParentID keeps the direct subtasks of the task.
+	ParentID string
+	// Tree pages through top-level tasks, or the subtasks of ParentID, and
+	// returns each with its complete subtree in TaskPage.Tree. Filters only
+	// apply to the paged tasks, not to their subtrees.
+	Tree   bool
+	Status string
+	// Priorities keeps tasks with any of the listed priorities.
+	Priorities []string
+	// Query matches against title and description.
+	Query     string
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	// Overdue restricts results to pending tasks whose due date has passed.
+	Overdue bool
+	// DueWithin restricts results to tasks due today or this week.
+	DueWithin DueWindow
+	// Tags keeps tasks carrying the named tags, combined as TagMatch says.
+	Tags []string
+	// TagMatch defaults to TagMatchAny.
+	TagMatch TagMatch
+	// Sort is "field" or "field:asc|desc" where field is created_at,
+	// updated_at, title or priority. Defaults to created_at:desc. Fields sort
+	// ascending unless stated, except priority which puts the most urgent
+	// first; within a priority tasks run by due date, soonest first.
+	Sort string
+	// Cursor is the NextCursor of a previous page requested with the same Sort.
+	Cursor string
+	// Limit is the page size; zero selects DefaultPageSize.
+	Limit int
+}
+
+// TaskPage is one page of a task listing.
+type TaskPage struct {
+	Tasks []domain.Task
+	// Tree holds the same tasks with their subtrees when ListOptions.Tree is set.
+	Tree []TaskNode
+	// NextCursor is empty on the last page.
+	NextCursor string
 }
 
 // New constructs a task service.
@@ -34,6 +197,32 @@ func New(tasks repository.TaskRepository) *Service {
 	}
 }
 
+// WithUsers lets the service look up each user's time zone so that "today",
+// "this week" and overdue all-day tasks follow the user's calendar. Without it
+// every user is treated as being in UTC.
+func (s *Service) WithUsers(users repository.UserRepository) {
+	if users != nil {
+		s.users = users
+	}
+}
+
+// WithTags enables attaching tags to tasks and filtering by them. Without it
+// tasks carry no tags and naming one is rejected with ErrUnknownTag.
+func (s *Service) WithTags(tags repository.TagRepository) {
+	if tags != nil {
+		s.tags = tags
+	}
+}
+
+// WithProjects files tasks under projects, new tasks landing in the user's
+// inbox unless another project is named. Without it tasks belong to no
+// project and naming one is rejected with ErrUnknownProject.
+func (s *Service) WithProjects(projects *projectsvc.Service) {
+	if projects != nil {
+		s.projects = projects
+	}
+}
+
 // WithNow overrides the time source (testing).
 func (s *Service) WithNow(fn func() time.Time) {
 	if fn != nil {
@@ -42,14 +231,50 @@ func (s *Service) WithNow(fn func() time.Time) {
 }
 
 // CreateTask stores a new task for the provided user.
-func (s *Service) CreateTask(ctx context.Context, userID, title, description string) (*domain.Task, error) {
-	title = strings.TrimSpace(title)
+func (s *Service) CreateTask(ctx context.Context, userID string, input CreateTaskInput) (*domain.Task, error) {
+	title := strings.TrimSpace(input.Title)
 	if userID == "" {
 		return nil, ErrUserRequired
 	}
 	if title == "" {
 		return nil, ErrTitleRequired
 	}
+	priority, err := parsePriority(input.Priority)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.resolveTags(ctx, userID, input.Tags)
+	if err != nil {
+		return nil, err
+	}
+	projectID := input.ProjectID
+	var parentID string
+	if input.ParentID != "" {
+		parent, err := s.checkParent(ctx, userID, "", input.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		parentID = parent.ID
+		if strings.TrimSpace(projectID) == "" {
+			projectID = parent.ProjectID
+		}
+	}
+	if projectID, err = s.resolveProject(ctx, userID, projectID); err != nil {
+		return nil, err
+	}
+	var recurrence string
+	if strings.TrimSpace(input.Recurrence) != "" {
+		if input.DueAt == nil {
+			return nil, ErrRecurrenceNeedsDue
+		}
+		if recurrence, err = parseRecurrence(input.Recurrence); err != nil {
+			return nil, err
+		}
+	}
+	basis, err := parseRecurrenceBasis(input.RecurrenceBasis)
+	if err != nil {
+		return nil, err
+	}
 
 	id, err := uuid.NewString()
 	if err != nil {
@@ -58,28 +283,166 @@ func (s *Service) CreateTask(ctx context.Context, userID, title, description str
 
 	now := s.now().UTC()
 	task := &domain.Task{
-		ID:          id,
-		UserID:      userID,
-		Title:       title,
-		Description: strings.TrimSpace(description),
-		Status:      domain.TaskStatusPending,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:              id,
+		UserID:          userID,
+		Title:           title,
+		Description:     strings.TrimSpace(input.Description),
+		Status:          domain.TaskStatusPending,
+		Priority:        priority,
+		ProjectID:       projectID,
+		ParentID:        parentID,
+		Recurrence:      recurrence,
+		RecurrenceBasis: basis,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if input.DueAt != nil {
+		setDue(task, *input.DueAt, input.DueAllDay)
 	}
 
 	if err := s.tasks.Create(ctx, task); err != nil {
-		return nil, err
+		return nil, s.placementError(ctx, userID, "", task.ParentID, err)
+	}
+	if len(tags) > 0 {
+		if err := s.setTags(ctx, task, tags); err != nil {
+			return nil, err
+		}
 	}
 
 	return task, nil
 }
 
-// ListTasks returns all tasks for the provided user.
-func (s *Service) ListTasks(ctx context.Context, userID string) ([]domain.Task, error) {
+// ListTasks returns a page of tasks for the provided user matching the options.
+func (s *Service) ListTasks(ctx context.Context, userID string, opts ListOptions) (*TaskPage, error) {
 	if userID == "" {
 		return nil, ErrUserRequired
 	}
-	return s.tasks.ListByUser(ctx, userID)
+	if opts.DueBefore != nil && opts.DueAfter != nil && !opts.DueAfter.Before(*opts.DueBefore) {
+		return nil, ErrInvalidDueRange
+	}
+
+	limit := opts.Limit
+	if limit == 0 {
+		limit = DefaultPageSize
+	}
+	if limit < 0 || limit > MaxPageSize {
+		return nil, ErrInvalidLimit
+	}
+
+	sort, err := parseSort(opts.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	listOpts := repository.TaskListOptions{
+		Filter: repository.TaskFilter{
+			ProjectID: strings.TrimSpace(opts.ProjectID),
+			ParentID:  strings.TrimSpace(opts.ParentID),
+			RootsOnly: opts.Tree && strings.TrimSpace(opts.ParentID) == "",
+			Query:     strings.TrimSpace(opts.Query),
+			DueBefore: opts.DueBefore,
+			DueAfter:  opts.DueAfter,
+		},
+		Sort: sort,
+		// Fetch one extra row to learn whether another page follows.
+		Limit: limit + 1,
+	}
+	if listOpts.Filter.ProjectID != "" {
+		if s.projects == nil {
+			return nil, ErrUnknownProject
+		}
+		if _, err := s.projects.Get(ctx, userID, listOpts.Filter.ProjectID); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return nil, ErrUnknownProject
+			}
+			return nil, err
+		}
+	}
+	if listOpts.Filter.ParentID != "" {
+		if _, err := s.ownedTask(ctx, userID, listOpts.Filter.ParentID); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return nil, ErrUnknownParent
+			}
+			return nil, err
+		}
+	}
+	if opts.Status != "" {
+		status := domain.TaskStatus(opts.Status)
+		if status != domain.TaskStatusPending && status != domain.TaskStatusDone {
+			return nil, ErrInvalidStatus
+		}
+		listOpts.Filter.Status = &status
+	}
+	for _, value := range opts.Priorities {
+		priority := domain.TaskPriority(strings.TrimSpace(value))
+		if !priority.Valid() {
+			return nil, ErrInvalidPriority
+		}
+		if !slices.Contains(listOpts.Filter.Priorities, priority) {
+			listOpts.Filter.Priorities = append(listOpts.Filter.Priorities, priority)
+		}
+	}
+	if len(opts.Tags) > 0 {
+		switch opts.TagMatch {
+		case "", TagMatchAny:
+		case TagMatchAll:
+			listOpts.Filter.MatchAllTags = true
+		default:
+			return nil, ErrInvalidTagMatch
+		}
+		for _, name := range opts.Tags {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name != "" && !slices.Contains(listOpts.Filter.Tags, name) {
+				listOpts.Filter.Tags = append(listOpts.Filter.Tags, name)
+			}
+		}
+	}
+	if opts.Overdue || opts.DueWithin != "" {
+		loc, err := s.location(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		now := s.now().In(loc)
+		if opts.Overdue {
+			listOpts.Filter.Overdue = &repository.OverdueCutoff{
+				Now:   now.UTC(),
+				Today: calendarDate(now),
+			}
+		}
+		if opts.DueWithin != "" {
+			if listOpts.Filter.DueWithin, err = dueWindow(opts.DueWithin, now); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if opts.Cursor != "" {
+		if listOpts.After, err = decodeCursor(sort, opts.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	tasks, err := s.tasks.ListByUser(ctx, userID, listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &TaskPage{Tasks: tasks}
+	if len(tasks) > limit {
+		page.Tasks = tasks[:limit]
+		if page.NextCursor, err = encodeCursor(sort, page.Tasks[limit-1]); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Tree {
+		if page.Tree, err = s.loadTree(ctx, page.Tasks); err != nil {
+			return nil, err
+		}
+		return page, nil
+	}
+	if err := s.enrich(ctx, page.Tasks); err != nil {
+		return nil, err
+	}
+	return page, nil
 }
 
 // GetTask fetches a single task ensuring the owner matches.
@@ -91,11 +454,17 @@ func (s *Service) GetTask(ctx context.Context, userID, id string) (*domain.Task,
 	if task.UserID != userID {
 		return nil, domain.ErrNotFound
 	}
-	return task, nil
+	tasks := []domain.Task{*task}
+	if err := s.enrich(ctx, tasks); err != nil {
+		return nil, err
+	}
+	return &tasks[0], nil
 }
 
-// UpdateTask updates mutable fields of a task.
-func (s *Service) UpdateTask(ctx context.Context, userID, id, title, description, status string) (*domain.Task, error) {
+// UpdateTask updates mutable fields of a task. Marking a recurring task done
+// creates its next occurrence, returned in NextOccurrence, which takes over
+// the recurrence from the completed task.
+func (s *Service) UpdateTask(ctx context.Context, userID, id string, input UpdateTaskInput) (*domain.Task, error) {
 	task, err := s.tasks.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -103,30 +472,192 @@ func (s *Service) UpdateTask(ctx context.Context, userID, id, title, description
 	if task.UserID != userID {
 		return nil, domain.ErrNotFound
 	}
+	wasDone := task.Status == domain.TaskStatusDone
 
-	if title = strings.TrimSpace(title); title != "" {
+	if title := strings.TrimSpace(input.Title); title != "" {
 		task.Title = title
 	}
-	task.Description = strings.TrimSpace(description)
+	task.Description = strings.TrimSpace(input.Description)
 
-	if status != "" {
-		switch domain.TaskStatus(status) {
+	if input.Status != "" {
+		switch domain.TaskStatus(input.Status) {
 		case domain.TaskStatusPending, domain.TaskStatusDone:
-			task.Status = domain.TaskStatus(status)
+			task.Status = domain.TaskStatus(input.Status)
 		default:
 			return nil, ErrInvalidStatus
 		}
 	}
+	if input.Priority != "" {
+		if task.Priority, err = parsePriority(input.Priority); err != nil {
+			return nil, err
+		}
+	}
+
+	if input.ProjectID != "" {
+		if task.ProjectID, err = s.resolveProject(ctx, userID, input.ProjectID); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case input.ClearParent:
+		task.ParentID = ""
+	case input.ParentID != "":
+		parent, err := s.checkParent(ctx, userID, task.ID, input.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		task.ParentID = parent.ID
+	}
+	var tags []domain.Tag
+	if input.Tags != nil {
+		if tags, err = s.resolveTags(ctx, userID, input.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case input.ClearDue:
+		task.DueAt = nil
+		task.DueAllDay = false
+	case input.DueAt != nil:
+		setDue(task, *input.DueAt, input.DueAllDay)
+	}
+
+	switch {
+	case input.ClearRecurrence:
+		task.Recurrence = ""
+	case strings.TrimSpace(input.Recurrence) != "":
+		if task.Recurrence, err = parseRecurrence(input.Recurrence); err != nil {
+			return nil, err
+		}
+	}
+	if strings.TrimSpace(input.RecurrenceBasis) != "" {
+		if task.RecurrenceBasis, err = parseRecurrenceBasis(input.RecurrenceBasis); err != nil {
+			return nil, err
+		}
+	}
+	if task.Recurrence != "" && task.DueAt == nil {
+		return nil, ErrRecurrenceNeedsDue
+	}
+	var next *domain.Task
+	if !wasDone && task.Status == domain.TaskStatusDone && task.Recurrence != "" {
+		if next, err = s.nextOccurrence(ctx, task); err != nil {
+			return nil, err
+		}
+		// The series moves on to the next occurrence, so reopening and
+		// completing this task again does not repeat it twice.
+		task.Recurrence = ""
+	}
 
 	task.UpdatedAt = s.now().UTC()
 
-	if err := s.tasks.Update(ctx, task); err != nil {
+	if next != nil {
+		// Tags are settled first so the next occurrence inherits the new
+		// set; the completion and the next occurrence are then stored
+		// together so the series cannot be lost half-way.
+		if input.Tags != nil {
+			if err := s.setTags(ctx, task, tags); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.tasks.CompleteOccurrence(ctx, task, next); err != nil {
+			return nil, s.placementError(ctx, userID, task.ID, task.ParentID, err)
+		}
+	} else {
+		if err := s.tasks.Update(ctx, task); err != nil {
+			return nil, s.placementError(ctx, userID, task.ID, task.ParentID, err)
+		}
+		if input.Tags != nil {
+			if err := s.setTags(ctx, task, tags); err != nil {
+				return nil, err
+			}
+		}
+	}
+	tasks := []domain.Task{*task}
+	if err := s.enrich(ctx, tasks); err != nil {
+		return nil, err
+	}
+	if next != nil {
+		next.Tags = tasks[0].Tags
+		tasks[0].NextOccurrence = next
+	}
+	return &tasks[0], nil
+}
+
+// RestoredTask validates a task carried over from an export archive and
+// returns it ready to be stored for the user; it stores nothing itself. The
+// task gets a new id and is filed in the inbox; its status, due date,
+// recurrence and timestamps are kept.
+func (s *Service) RestoredTask(ctx context.Context, userID string, source domain.Task) (*domain.Task, error) {
+	if userID == "" {
+		return nil, ErrUserRequired
+	}
+	title := strings.TrimSpace(source.Title)
+	if title == "" {
+		return nil, ErrTitleRequired
+	}
+	status := source.Status
+	switch status {
+	case "":
+		status = domain.TaskStatusPending
+	case domain.TaskStatusPending, domain.TaskStatusDone:
+	default:
+		return nil, ErrInvalidStatus
+	}
+	priority, err := parsePriority(string(source.Priority))
+	if err != nil {
+		return nil, err
+	}
+	var recurrence string
+	if strings.TrimSpace(source.Recurrence) != "" {
+		if source.DueAt == nil {
+			return nil, ErrRecurrenceNeedsDue
+		}
+		if recurrence, err = parseRecurrence(source.Recurrence); err != nil {
+			return nil, err
+		}
+	}
+	basis, err := parseRecurrenceBasis(string(source.RecurrenceBasis))
+	if err != nil {
+		return nil, err
+	}
+	projectID, err := s.resolveProject(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.NewString()
+	if err != nil {
 		return nil, err
 	}
+
+	now := s.now().UTC()
+	task := &domain.Task{
+		ID:              id,
+		UserID:          userID,
+		Title:           title,
+		Description:     strings.TrimSpace(source.Description),
+		Status:          status,
+		Priority:        priority,
+		ProjectID:       projectID,
+		Recurrence:      recurrence,
+		RecurrenceBasis: basis,
+		CreatedAt:       source.CreatedAt.UTC(),
+		UpdatedAt:       source.UpdatedAt.UTC(),
+	}
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = now
+	}
+	if task.UpdatedAt.IsZero() {
+		task.UpdatedAt = task.CreatedAt
+	}
+	if source.DueAt != nil {
+		setDue(task, *source.DueAt, source.DueAllDay)
+	}
 	return task, nil
 }
 
-// DeleteTask removes a task owned by the user.
+// DeleteTask removes a task owned by the user together with its subtasks.
 func (s *Service) DeleteTask(ctx context.Context, userID, id string) error {
 	task, err := s.tasks.GetByID(ctx, id)
 	if err != nil {
@@ -137,3 +668,101 @@ func (s *Service) DeleteTask(ctx context.Context, userID, id string) error {
 	}
 	return s.tasks.Delete(ctx, id)
 }
+
+// parsePriority validates a priority, treating an empty value as none.
+func parsePriority(value string) (domain.TaskPriority, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return domain.TaskPriorityNone, nil
+	}
+	priority := domain.TaskPriority(value)
+	if !priority.Valid() {
+		return "", ErrInvalidPriority
+	}
+	return priority, nil
+}
+
+// resolveProject returns the id of the project a task is filed under: the
+// named project, which must be the user's and not archived, or the inbox.
+func (s *Service) resolveProject(ctx context.Context, userID, id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if s.projects == nil {
+		if id != "" {
+			return "", ErrUnknownProject
+		}
+		return "", nil
+	}
+	if id == "" {
+		inbox, err := s.projects.Inbox(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+		return inbox.ID, nil
+	}
+	project, err := s.projects.Get(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return "", ErrUnknownProject
+		}
+		return "", err
+	}
+	if project.Archived {
+		return "", ErrProjectArchived
+	}
+	return project.ID, nil
+}
+
+// setDue stores the due date on the task. All-day deadlines keep only the
+// calendar date of the supplied time, expressed as midnight UTC.
+func setDue(task *domain.Task, at time.Time, allDay bool) {
+	due := at.UTC()
+	if allDay {
+		y, m, d := at.Date()
+		due = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	task.DueAt = &due
+	task.DueAllDay = allDay
+}
+
+// location returns the user's time zone, or UTC when users are not wired in.
+func (s *Service) location(ctx context.Context, userID string) (*time.Location, error) {
+	if s.users == nil {
+		return time.UTC, nil
+	}
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return user.Location(), nil
+}
+
+// dueWindow spans the calendar days of the view containing now, using now's
+// location. Local midnights are computed with time.Date so days that are 23
+// or 25 hours long around DST changes are bounded correctly.
+func dueWindow(window DueWindow, now time.Time) (*repository.DueWindow, error) {
+	y, m, d := now.Date()
+	var days int
+	switch window {
+	case DueToday:
+		days = 1
+	case DueThisWeek:
+		// Go weekdays start on Sunday; ISO weeks start on Monday.
+		d -= (int(now.Weekday()) + 6) % 7
+		days = 7
+	default:
+		return nil, ErrInvalidDueWindow
+	}
+	loc := now.Location()
+	return &repository.DueWindow{
+		Start:     time.Date(y, m, d, 0, 0, 0, 0, loc).UTC(),
+		End:       time.Date(y, m, d+days, 0, 0, 0, 0, loc).UTC(),
+		StartDate: time.Date(y, m, d, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(y, m, d+days, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+// calendarDate returns t's calendar date, in t's location, as midnight UTC.
+func calendarDate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
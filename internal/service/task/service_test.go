@@ -2,15 +2,23 @@ package task_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"go-todo-service/internal/domain"
+	"go-todo-service/internal/repository"
+	projectsvc "go-todo-service/internal/service/project"
 	tasksvc "go-todo-service/internal/service/task"
 )
 
 type fakeTaskRepo struct {
 	tasks map[string]domain.Task
+	// tagRepo, when set, resolves tag filters.
+	tagRepo *fakeTagRepo
 }
 
 func newFakeTaskRepo() *fakeTaskRepo {
@@ -24,12 +32,138 @@ func (r *fakeTaskRepo) Create(ctx context.Context, task *domain.Task) error {
 	return nil
 }
 
-func (r *fakeTaskRepo) ListByUser(ctx context.Context, userID string) ([]domain.Task, error) {
+func (r *fakeTaskRepo) ListByUser(ctx context.Context, userID string, opts repository.TaskListOptions) ([]domain.Task, error) {
+	filter := opts.Filter
 	var out []domain.Task
 	for _, task := range r.tasks {
-		if task.UserID == userID {
-			out = append(out, task)
+		if task.UserID != userID {
+			continue
 		}
+		if filter.ProjectID != "" && task.ProjectID != filter.ProjectID {
+			continue
+		}
+		if filter.ParentID != "" && task.ParentID != filter.ParentID {
+			continue
+		}
+		if filter.RootsOnly && task.ParentID != "" {
+			continue
+		}
+		if filter.Status != nil && task.Status != *filter.Status {
+			continue
+		}
+		if len(filter.Priorities) > 0 && !slices.Contains(filter.Priorities, task.Priority) {
+			continue
+		}
+		if filter.Query != "" && !strings.Contains(strings.ToLower(task.Title+" "+task.Description), strings.ToLower(filter.Query)) {
+			continue
+		}
+		if filter.DueBefore != nil && (task.DueAt == nil || !task.DueAt.Before(*filter.DueBefore)) {
+			continue
+		}
+		if filter.DueAfter != nil && (task.DueAt == nil || task.DueAt.Before(*filter.DueAfter)) {
+			continue
+		}
+		if filter.Overdue != nil {
+			if task.Status != domain.TaskStatusPending || task.DueAt == nil {
+				continue
+			}
+			cutoff := filter.Overdue.Now
+			if task.DueAllDay {
+				cutoff = filter.Overdue.Today
+			}
+			if !task.DueAt.Before(cutoff) {
+				continue
+			}
+		}
+		if len(filter.Tags) > 0 {
+			var matched int
+			if r.tagRepo != nil {
+				for _, id := range r.tagRepo.assigned[task.ID] {
+					if slices.Contains(filter.Tags, strings.ToLower(r.tagRepo.tags[id].Name)) {
+						matched++
+					}
+				}
+			}
+			if matched == 0 || filter.MatchAllTags && matched < len(filter.Tags) {
+				continue
+			}
+		}
+		if window := filter.DueWithin; window != nil {
+			if task.DueAt == nil {
+				continue
+			}
+			start, end := window.Start, window.End
+			if task.DueAllDay {
+				start, end = window.StartDate, window.EndDate
+			}
+			if task.DueAt.Before(start) || !task.DueAt.Before(end) {
+				continue
+			}
+		}
+		out = append(out, task)
+	}
+
+	// compare orders a before b in ascending sort order.
+	compare := func(a, b domain.Task) int {
+		var c int
+		switch opts.Sort.Field {
+		case repository.TaskSortTitle:
+			c = strings.Compare(a.Title, b.Title)
+		case repository.TaskSortUpdatedAt:
+			c = a.UpdatedAt.Compare(b.UpdatedAt)
+		default:
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		}
+		if c == 0 {
+			c = strings.Compare(a.ID, b.ID)
+		}
+		return c
+	}
+	// order applies the sort direction. The priority sort only reverses
+	// the priority itself; due dates stay soonest first, undated last.
+	order := func(a, b domain.Task) int {
+		if opts.Sort.Field == repository.TaskSortPriority {
+			c := a.Priority.Rank() - b.Priority.Rank()
+			if opts.Sort.Descending {
+				c = -c
+			}
+			if c == 0 {
+				switch {
+				case a.DueAt == nil && b.DueAt != nil:
+					c = 1
+				case a.DueAt != nil && b.DueAt == nil:
+					c = -1
+				case a.DueAt != nil:
+					c = a.DueAt.Compare(*b.DueAt)
+				}
+			}
+			if c == 0 {
+				c = strings.Compare(a.ID, b.ID)
+			}
+			return c
+		}
+		if opts.Sort.Descending {
+			return compare(b, a)
+		}
+		return compare(a, b)
+	}
+	slices.SortFunc(out, order)
+
+	if opts.After != nil {
+		marker := domain.Task{
+			ID:        opts.After.ID,
+			Title:     opts.After.Title,
+			Priority:  opts.After.Priority,
+			DueAt:     opts.After.DueAt,
+			CreatedAt: opts.After.CreatedAt,
+			UpdatedAt: opts.After.UpdatedAt,
+		}
+		out = slices.DeleteFunc(out, func(task domain.Task) bool {
+			return order(task, marker) <= 0
+		})
+	}
+	if opts.Limit > 0 && len(out) > opts.Limit {
+		out = out[:opts.Limit]
 	}
 	return out, nil
 }
@@ -51,11 +185,193 @@ func (r *fakeTaskRepo) Update(ctx context.Context, task *domain.Task) error {
 	return nil
 }
 
+func (r *fakeTaskRepo) CompleteOccurrence(ctx context.Context, done, next *domain.Task) error {
+	if err := r.Update(ctx, done); err != nil {
+		return err
+	}
+	r.tasks[next.ID] = *next
+	if r.tagRepo != nil {
+		r.tagRepo.assigned[next.ID] = slices.Clone(r.tagRepo.assigned[done.ID])
+	}
+	return nil
+}
+
 func (r *fakeTaskRepo) Delete(ctx context.Context, id string) error {
 	if _, ok := r.tasks[id]; !ok {
 		return domain.ErrNotFound
 	}
 	delete(r.tasks, id)
+	for _, task := range r.tasks {
+		if task.ParentID == id {
+			r.Delete(ctx, task.ID)
+		}
+	}
+	return nil
+}
+
+func (r *fakeTaskRepo) ListAncestors(ctx context.Context, id string) ([]domain.Task, error) {
+	var out []domain.Task
+	for parentID := r.tasks[id].ParentID; parentID != ""; parentID = r.tasks[parentID].ParentID {
+		out = append(out, r.tasks[parentID])
+	}
+	return out, nil
+}
+
+func (r *fakeTaskRepo) ListDescendants(ctx context.Context, ids []string) ([]domain.Task, error) {
+	var out []domain.Task
+	for _, task := range r.tasks {
+		for parentID := task.ParentID; parentID != ""; parentID = r.tasks[parentID].ParentID {
+			if slices.Contains(ids, parentID) {
+				out = append(out, task)
+				break
+			}
+		}
+	}
+	slices.SortFunc(out, func(a, b domain.Task) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return out, nil
+}
+
+func (r *fakeTaskRepo) CountSubtasks(ctx context.Context, ids []string) (map[string]domain.SubtaskProgress, error) {
+	out := make(map[string]domain.SubtaskProgress)
+	for _, task := range r.tasks {
+		if task.ParentID == "" || !slices.Contains(ids, task.ParentID) {
+			continue
+		}
+		progress := out[task.ParentID]
+		progress.Total++
+		if task.Status == domain.TaskStatusDone {
+			progress.Done++
+		}
+		out[task.ParentID] = progress
+	}
+	return out, nil
+}
+
+type fakeTagRepo struct {
+	tags     map[string]domain.Tag
+	assigned map[string][]string
+}
+
+func newFakeTagRepo(tags ...domain.Tag) *fakeTagRepo {
+	r := &fakeTagRepo{
+		tags:     make(map[string]domain.Tag),
+		assigned: make(map[string][]string),
+	}
+	for _, tag := range tags {
+		r.tags[tag.ID] = tag
+	}
+	return r
+}
+
+func (r *fakeTagRepo) Create(ctx context.Context, tag *domain.Tag) error {
+	r.tags[tag.ID] = *tag
+	return nil
+}
+
+func (r *fakeTagRepo) GetByID(ctx context.Context, id string) (*domain.Tag, error) {
+	tag, ok := r.tags[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &tag, nil
+}
+
+func (r *fakeTagRepo) ListByUser(ctx context.Context, userID string) ([]domain.Tag, error) {
+	var out []domain.Tag
+	for _, tag := range r.tags {
+		if tag.UserID == userID {
+			out = append(out, tag)
+		}
+	}
+	slices.SortFunc(out, func(a, b domain.Tag) int { return strings.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+func (r *fakeTagRepo) GetByNames(ctx context.Context, userID string, names []string) ([]domain.Tag, error) {
+	var out []domain.Tag
+	for _, tag := range r.tags {
+		if tag.UserID == userID && slices.Contains(names, strings.ToLower(tag.Name)) {
+			out = append(out, tag)
+		}
+	}
+	slices.SortFunc(out, func(a, b domain.Tag) int { return strings.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+func (r *fakeTagRepo) Update(ctx context.Context, tag *domain.Tag) error {
+	r.tags[tag.ID] = *tag
+	return nil
+}
+
+func (r *fakeTagRepo) Delete(ctx context.Context, id string) error {
+	delete(r.tags, id)
+	return nil
+}
+
+func (r *fakeTagRepo) SetTaskTags(ctx context.Context, taskID string, tagIDs []string) error {
+	r.assigned[taskID] = slices.Clone(tagIDs)
+	return nil
+}
+
+func (r *fakeTagRepo) ListByTasks(ctx context.Context, taskIDs []string) (map[string][]domain.Tag, error) {
+	out := make(map[string][]domain.Tag)
+	for _, taskID := range taskIDs {
+		for _, id := range r.assigned[taskID] {
+			out[taskID] = append(out[taskID], r.tags[id])
+		}
+		slices.SortFunc(out[taskID], func(a, b domain.Tag) int { return strings.Compare(a.Name, b.Name) })
+	}
+	return out, nil
+}
+
+type fakeProjectRepo struct {
+	projects map[string]domain.Project
+}
+
+func (r *fakeProjectRepo) Create(ctx context.Context, project *domain.Project) error {
+	r.projects[project.ID] = *project
+	return nil
+}
+
+func (r *fakeProjectRepo) GetByID(ctx context.Context, id string) (*domain.Project, error) {
+	project, ok := r.projects[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &project, nil
+}
+
+func (r *fakeProjectRepo) GetInbox(ctx context.Context, userID string) (*domain.Project, error) {
+	for _, project := range r.projects {
+		if project.UserID == userID && project.Inbox {
+			return &project, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *fakeProjectRepo) ListByUser(ctx context.Context, userID string, includeArchived bool) ([]domain.Project, error) {
+	var out []domain.Project
+	for _, project := range r.projects {
+		if project.UserID == userID && (includeArchived || !project.Archived) {
+			out = append(out, project)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeProjectRepo) Update(ctx context.Context, project *domain.Project) error {
+	r.projects[project.ID] = *project
+	return nil
+}
+
+func (r *fakeProjectRepo) Delete(ctx context.Context, id, moveTasksTo string) error {
+	delete(r.projects, id)
 	return nil
 }
 
@@ -65,7 +381,7 @@ func TestCreateTask(t *testing.T) {
 	fixed := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
 	service.WithNow(func() time.Time { return fixed })
 
-	task, err := service.CreateTask(context.Background(), "user-1", "Title", "Desc")
+	task, err := service.CreateTask(context.Background(), "user-1", tasksvc.CreateTaskInput{Title: "Title", Description: "Desc"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -79,7 +395,7 @@ func TestCreateTask(t *testing.T) {
 
 func TestCreateTaskMissingTitle(t *testing.T) {
 	service := tasksvc.New(newFakeTaskRepo())
-	if _, err := service.CreateTask(context.Background(), "user-1", "", "desc"); err == nil {
+	if _, err := service.CreateTask(context.Background(), "user-1", tasksvc.CreateTaskInput{Description: "desc"}); err == nil {
 		t.Fatal("expected error for missing title")
 	}
 }
@@ -96,7 +412,7 @@ func TestUpdateTaskInvalidStatus(t *testing.T) {
 	repo.tasks[task.ID] = task
 
 	service := tasksvc.New(repo)
-	if _, err := service.UpdateTask(context.Background(), "user-1", task.ID, "", "", "invalid"); err != tasksvc.ErrInvalidStatus {
+	if _, err := service.UpdateTask(context.Background(), "user-1", task.ID, tasksvc.UpdateTaskInput{Status: "invalid"}); err != tasksvc.ErrInvalidStatus {
 		t.Fatalf("expected ErrInvalidStatus, got %v", err)
 	}
 }
@@ -135,3 +451,945 @@ func TestDeleteTask(t *testing.T) {
 		t.Fatal("task should be deleted")
 	}
 }
+
+func TestCreateTaskAllDayDueKeepsDate(t *testing.T) {
+	service := tasksvc.New(newFakeTaskRepo())
+	due := time.Date(2024, 3, 5, 23, 30, 0, 0, time.FixedZone("UTC-5", -5*60*60))
+
+	task, err := service.CreateTask(context.Background(), "user-1", tasksvc.CreateTaskInput{Title: "Title", DueAt: &due, DueAllDay: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	if task.DueAt == nil || !task.DueAt.Equal(want) || !task.DueAllDay {
+		t.Fatalf("expected all-day due %v, got %v (all day %v)", want, task.DueAt, task.DueAllDay)
+	}
+}
+
+func TestUpdateTaskClearDue(t *testing.T) {
+	repo := newFakeTaskRepo()
+	due := time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC)
+	repo.tasks["task-1"] = domain.Task{ID: "task-1", UserID: "user-1", Title: "Title", Status: domain.TaskStatusPending, DueAt: &due}
+
+	service := tasksvc.New(repo)
+	task, err := service.UpdateTask(context.Background(), "user-1", "task-1", tasksvc.UpdateTaskInput{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.DueAt == nil {
+		t.Fatal("expected due date to be kept when not supplied")
+	}
+
+	task, err = service.UpdateTask(context.Background(), "user-1", "task-1", tasksvc.UpdateTaskInput{ClearDue: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.DueAt != nil || task.DueAllDay {
+		t.Fatalf("expected due date cleared, got %v", task.DueAt)
+	}
+}
+
+func TestListTasksOverdueUsesNow(t *testing.T) {
+	repo := newFakeTaskRepo()
+	now := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	pastTimed := now.Add(-time.Hour)
+	futureTimed := now.Add(time.Hour)
+	today := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	yesterday := today.AddDate(0, 0, -1)
+
+	repo.tasks["late"] = domain.Task{ID: "late", UserID: "user-1", Status: domain.TaskStatusPending, DueAt: &pastTimed}
+	repo.tasks["upcoming"] = domain.Task{ID: "upcoming", UserID: "user-1", Status: domain.TaskStatusPending, DueAt: &futureTimed}
+	repo.tasks["due-today"] = domain.Task{ID: "due-today", UserID: "user-1", Status: domain.TaskStatusPending, DueAt: &today, DueAllDay: true}
+	repo.tasks["due-yesterday"] = domain.Task{ID: "due-yesterday", UserID: "user-1", Status: domain.TaskStatusPending, DueAt: &yesterday, DueAllDay: true}
+	repo.tasks["done"] = domain.Task{ID: "done", UserID: "user-1", Status: domain.TaskStatusDone, DueAt: &pastTimed}
+
+	service := tasksvc.New(repo)
+	service.WithNow(func() time.Time { return now })
+
+	page, err := service.ListTasks(context.Background(), "user-1", tasksvc.ListOptions{Overdue: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := make(map[string]bool)
+	for _, task := range page.Tasks {
+		got[task.ID] = true
+	}
+	if len(got) != 2 || !got["late"] || !got["due-yesterday"] {
+		t.Fatalf("expected late and due-yesterday to be overdue, got %v", got)
+	}
+}
+
+// fakeUserRepo serves user lookups for time zone resolution; the task service
+// uses no other UserRepository methods.
+type fakeUserRepo struct {
+	repository.UserRepository
+	users map[string]*domain.User
+}
+
+func (r *fakeUserRepo) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	u := *user
+	return &u, nil
+}
+
+func taskIDs(tasks []domain.Task) map[string]bool {
+	ids := make(map[string]bool)
+	for _, task := range tasks {
+		ids[task.ID] = true
+	}
+	return ids
+}
+
+func TestListTasksOverdueUsesUserTimeZone(t *testing.T) {
+	repo := newFakeTaskRepo()
+	// 03:00 on March 6 in Tokyo while it is still March 5 in UTC.
+	now := time.Date(2024, 3, 5, 18, 0, 0, 0, time.UTC)
+	march5 := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	repo.tasks["due-march-5"] = domain.Task{ID: "due-march-5", UserID: "user-1", Status: domain.TaskStatusPending, DueAt: &march5, DueAllDay: true}
+
+	service := tasksvc.New(repo)
+	service.WithNow(func() time.Time { return now })
+
+	page, err := service.ListTasks(context.Background(), "user-1", tasksvc.ListOptions{Overdue: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Tasks) != 0 {
+		t.Fatalf("expected nothing overdue in UTC, got %v", taskIDs(page.Tasks))
+	}
+
+	service.WithUsers(&fakeUserRepo{users: map[string]*domain.User{
+		"user-1": {ID: "user-1", TimeZone: "Asia/Tokyo"},
+	}})
+	page, err = service.ListTasks(context.Background(), "user-1", tasksvc.ListOptions{Overdue: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := taskIDs(page.Tasks); len(got) != 1 || !got["due-march-5"] {
+		t.Fatalf("expected yesterday's task to be overdue in Tokyo, got %v", got)
+	}
+}
+
+func TestListTasksDueTodayAcrossDSTChange(t *testing.T) {
+	repo := newFakeTaskRepo()
+	// November 3, 2024 is 25 hours long in New York (EDT -> EST).
+	now := time.Date(2024, 11, 3, 15, 0, 0, 0, time.UTC)
+	lateEvening := time.Date(2024, 11, 4, 4, 30, 0, 0, time.UTC)   // 23:30 EST on Nov 3
+	afterMidnight := time.Date(2024, 11, 4, 5, 30, 0, 0, time.UTC) // 00:30 EST on Nov 4
+	earlyMorning := time.Date(2024, 11, 3, 4, 30, 0, 0, time.UTC)  // 00:30 EDT on Nov 3
+	nov3 := time.Date(2024, 11, 3, 0, 0, 0, 0, time.UTC)
+	nov4 := time.Date(2024, 11, 4, 0, 0, 0, 0, time.UTC)
+
+	repo.tasks["late-evening"] = domain.Task{ID: "late-evening", UserID: "user-1", DueAt: &lateEvening}
+	repo.tasks["after-midnight"] = domain.Task{ID: "after-midnight", UserID: "user-1", DueAt: &afterMidnight}
+	repo.tasks["early-morning"] = domain.Task{ID: "early-morning", UserID: "user-1", DueAt: &earlyMorning}
+	repo.tasks["all-day-today"] = domain.Task{ID: "all-day-today", UserID: "user-1", DueAt: &nov3, DueAllDay: true}
+	repo.tasks["all-day-tomorrow"] = domain.Task{ID: "all-day-tomorrow", UserID: "user-1", DueAt: &nov4, DueAllDay: true}
+
+	service := tasksvc.New(repo)
+	service.WithNow(func() time.Time { return now })
+	service.WithUsers(&fakeUserRepo{users: map[string]*domain.User{
+		"user-1": {ID: "user-1", TimeZone: "America/New_York"},
+	}})
+
+	page, err := service.ListTasks(context.Background(), "user-1", tasksvc.ListOptions{DueWithin: tasksvc.DueToday})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := taskIDs(page.Tasks)
+	if len(got) != 3 || !got["late-evening"] || !got["early-morning"] || !got["all-day-today"] {
+		t.Fatalf("expected today's tasks in New York, got %v", got)
+	}
+}
+
+func TestListTasksDueThisWeek(t *testing.T) {
+	repo := newFakeTaskRepo()
+	// Sunday, March 10, 2024 in Berlin; the ISO week started Monday March 4.
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	nextMonday := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+	sundayNight := time.Date(2024, 3, 10, 22, 30, 0, 0, time.UTC) // 23:30 in Berlin
+
+	repo.tasks["monday"] = domain.Task{ID: "monday", UserID: "user-1", DueAt: &monday, DueAllDay: true}
+	repo.tasks["next-monday"] = domain.Task{ID: "next-monday", UserID: "user-1", DueAt: &nextMonday, DueAllDay: true}
+	repo.tasks["sunday-night"] = domain.Task{ID: "sunday-night", UserID: "user-1", DueAt: &sundayNight}
+
+	service := tasksvc.New(repo)
+	service.WithNow(func() time.Time { return now })
+	service.WithUsers(&fakeUserRepo{users: map[string]*domain.User{
+		"user-1": {ID: "user-1", TimeZone: "Europe/Berlin"},
+	}})
+
+	page, err := service.ListTasks(context.Background(), "user-1", tasksvc.ListOptions{DueWithin: tasksvc.DueThisWeek})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := taskIDs(page.Tasks)
+	if len(got) != 2 || !got["monday"] || !got["sunday-night"] {
+		t.Fatalf("expected this week's tasks, got %v", got)
+	}
+
+	if _, err := service.ListTasks(context.Background(), "user-1", tasksvc.ListOptions{DueWithin: "month"}); err != tasksvc.ErrInvalidDueWindow {
+		t.Fatalf("expected ErrInvalidDueWindow, got %v", err)
+	}
+}
+
+func TestListTasksInvalidDueRange(t *testing.T) {
+	service := tasksvc.New(newFakeTaskRepo())
+	before := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	after := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	_, err := service.ListTasks(context.Background(), "user-1", tasksvc.ListOptions{DueBefore: &before, DueAfter: &after})
+	if err != tasksvc.ErrInvalidDueRange {
+		t.Fatalf("expected ErrInvalidDueRange, got %v", err)
+	}
+}
+
+func TestListTasksPaginatesWithCursor(t *testing.T) {
+	repo := newFakeTaskRepo()
+	base := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("task-%d", i)
+		repo.tasks[id] = domain.Task{
+			ID:        id,
+			UserID:    "user-1",
+			Title:     fmt.Sprintf("Title %d", i),
+			Status:    domain.TaskStatusPending,
+			CreatedAt: base.Add(time.Duration(i) * time.Minute),
+		}
+	}
+	service := tasksvc.New(repo)
+
+	var seen []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not terminate")
+		}
+		page, err := service.ListTasks(context.Background(), "user-1", tasksvc.ListOptions{Limit: 2, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, task := range page.Tasks {
+			seen = append(seen, task.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	want := []string{"task-4", "task-3", "task-2", "task-1", "task-0"}
+	if !slices.Equal(seen, want) {
+		t.Fatalf("expected %v, got %v", want, seen)
+	}
+}
+
+func TestListTasksCursorBoundToSort(t *testing.T) {
+	repo := newFakeTaskRepo()
+	for i := 0; i < 3; i++ {
+		id := fmt.Sprintf("task-%d", i)
+		repo.tasks[id] = domain.Task{ID: id, UserID: "user-1", Title: id, Status: domain.TaskStatusPending}
+	}
+	service := tasksvc.New(repo)
+
+	page, err := service.ListTasks(context.Background(), "user-1", tasksvc.ListOptions{Sort: "title:asc", Limit: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Tasks) != 1 || page.Tasks[0].ID != "task-0" || page.NextCursor == "" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+
+	_, err = service.ListTasks(context.Background(), "user-1", tasksvc.ListOptions{Sort: "title:desc", Cursor: page.NextCursor})
+	if err != tasksvc.ErrInvalidCursor {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestListTasksInvalidSort(t *testing.T) {
+	service := tasksvc.New(newFakeTaskRepo())
+	if _, err := service.ListTasks(context.Background(), "user-1", tasksvc.ListOptions{Sort: "owner"}); err != tasksvc.ErrInvalidSort {
+		t.Fatalf("expected ErrInvalidSort, got %v", err)
+	}
+}
+
+func TestRestoredTaskKeepsHistory(t *testing.T) {
+	repo := newFakeTaskRepo()
+	service := tasksvc.New(repo)
+	created := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	due := time.Date(2023, 5, 2, 0, 0, 0, 0, time.UTC)
+
+	task, err := service.RestoredTask(context.Background(), "user-1", domain.Task{
+		ID:              "archived-id",
+		Title:           " Archived ",
+		Status:          domain.TaskStatusDone,
+		DueAt:           &due,
+		DueAllDay:       true,
+		Recurrence:      "freq=weekly;interval=1",
+		RecurrenceBasis: domain.RecurFromCompletion,
+		CreatedAt:       created,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.ID == "archived-id" || task.Title != "Archived" || task.Status != domain.TaskStatusDone {
+		t.Fatalf("unexpected task %+v", task)
+	}
+	if !task.CreatedAt.Equal(created) || !task.UpdatedAt.Equal(created) || !task.DueAt.Equal(due) {
+		t.Fatalf("expected timestamps to be kept, got %+v", task)
+	}
+	if task.Recurrence != "FREQ=WEEKLY" || task.RecurrenceBasis != domain.RecurFromCompletion {
+		t.Fatalf("expected the recurrence to be kept in canonical form, got %q (%s)", task.Recurrence, task.RecurrenceBasis)
+	}
+	if len(repo.tasks) != 0 {
+		t.Fatalf("expected nothing to be stored, got %d tasks", len(repo.tasks))
+	}
+
+	if _, err := service.RestoredTask(context.Background(), "user-1", domain.Task{Title: "x", Status: "later"}); err != tasksvc.ErrInvalidStatus {
+		t.Fatalf("expected ErrInvalidStatus, got %v", err)
+	}
+	if _, err := service.RestoredTask(context.Background(), "user-1", domain.Task{Title: "x", Recurrence: "FREQ=DAILY"}); err != tasksvc.ErrRecurrenceNeedsDue {
+		t.Fatalf("expected ErrRecurrenceNeedsDue, got %v", err)
+	}
+}
+
+func TestTaskPriorityValidation(t *testing.T) {
+	repo := newFakeTaskRepo()
+	service := tasksvc.New(repo)
+	ctx := context.Background()
+
+	task, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "Plain"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.Priority != domain.TaskPriorityNone {
+		t.Fatalf("expected priority none by default, got %q", task.Priority)
+	}
+	if _, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "Bad", Priority: "critical"}); err != tasksvc.ErrInvalidPriority {
+		t.Fatalf("expected ErrInvalidPriority, got %v", err)
+	}
+
+	updated, err := service.UpdateTask(ctx, "user-1", task.ID, tasksvc.UpdateTaskInput{Priority: "high"})
+	if err != nil || updated.Priority != domain.TaskPriorityHigh {
+		t.Fatalf("expected priority high, got %+v, %v", updated, err)
+	}
+	updated, err = service.UpdateTask(ctx, "user-1", task.ID, tasksvc.UpdateTaskInput{Status: "done"})
+	if err != nil || updated.Priority != domain.TaskPriorityHigh {
+		t.Fatalf("expected omitted priority to be kept, got %+v, %v", updated, err)
+	}
+	if _, err := service.UpdateTask(ctx, "user-1", task.ID, tasksvc.UpdateTaskInput{Priority: "later"}); err != tasksvc.ErrInvalidPriority {
+		t.Fatalf("expected ErrInvalidPriority, got %v", err)
+	}
+	if _, err := service.ListTasks(ctx, "user-1", tasksvc.ListOptions{Priorities: []string{"high", "someday"}}); err != tasksvc.ErrInvalidPriority {
+		t.Fatalf("expected ErrInvalidPriority, got %v", err)
+	}
+}
+
+func TestListTasksByPriorityThenDueDate(t *testing.T) {
+	repo := newFakeTaskRepo()
+	day := func(d int) *time.Time {
+		due := time.Date(2024, 3, d, 9, 0, 0, 0, time.UTC)
+		return &due
+	}
+	for _, task := range []domain.Task{
+		{ID: "low-early", Priority: domain.TaskPriorityLow, DueAt: day(1)},
+		{ID: "urgent-undated", Priority: domain.TaskPriorityUrgent},
+		{ID: "urgent-late", Priority: domain.TaskPriorityUrgent, DueAt: day(9)},
+		{ID: "urgent-early", Priority: domain.TaskPriorityUrgent, DueAt: day(2)},
+		{ID: "high", Priority: domain.TaskPriorityHigh, DueAt: day(3)},
+		{ID: "none", Priority: domain.TaskPriorityNone},
+	} {
+		task.UserID = "user-1"
+		task.Title = task.ID
+		task.Status = domain.TaskStatusPending
+		repo.tasks[task.ID] = task
+	}
+	service := tasksvc.New(repo)
+
+	list := func(opts tasksvc.ListOptions) []string {
+		t.Helper()
+		var ids []string
+		for pages := 0; ; pages++ {
+			if pages > 6 {
+				t.Fatal("pagination did not terminate")
+			}
+			page, err := service.ListTasks(context.Background(), "user-1", opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, task := range page.Tasks {
+				ids = append(ids, task.ID)
+			}
+			if page.NextCursor == "" {
+				return ids
+			}
+			opts.Cursor = page.NextCursor
+		}
+	}
+
+	want := []string{"urgent-early", "urgent-late", "urgent-undated", "high", "low-early", "none"}
+	if got := list(tasksvc.ListOptions{Sort: "priority", Limit: 2}); !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	want = []string{"none", "low-early", "high", "urgent-early", "urgent-late", "urgent-undated"}
+	if got := list(tasksvc.ListOptions{Sort: "priority:asc", Limit: 1}); !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	want = []string{"urgent-early", "urgent-late", "urgent-undated", "high"}
+	if got := list(tasksvc.ListOptions{Sort: "priority", Priorities: []string{"high", "urgent"}, Limit: 3}); !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestTaskTagsAssignment(t *testing.T) {
+	ctx := context.Background()
+	tags := newFakeTagRepo(
+		domain.Tag{ID: "tag-home", UserID: "user-1", Name: "Home"},
+		domain.Tag{ID: "tag-work", UserID: "user-1", Name: "work"},
+		domain.Tag{ID: "tag-other", UserID: "user-2", Name: "errands"},
+	)
+	service := tasksvc.New(newFakeTaskRepo())
+	service.WithTags(tags)
+
+	task, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "Title", Tags: []string{"work", "home", "HOME"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(task.Tags) != 2 || task.Tags[0].Name != "Home" || task.Tags[1].Name != "work" {
+		t.Fatalf("expected tags Home and work, got %+v", task.Tags)
+	}
+	if _, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "Title", Tags: []string{"errands"}}); !errors.Is(err, tasksvc.ErrUnknownTag) {
+		t.Fatalf("expected ErrUnknownTag for another user's tag, got %v", err)
+	}
+
+	updated, err := service.UpdateTask(ctx, "user-1", task.ID, tasksvc.UpdateTaskInput{Title: "Renamed"})
+	if err != nil || len(updated.Tags) != 2 {
+		t.Fatalf("expected omitted tags to be kept, got %+v, %v", updated, err)
+	}
+	updated, err = service.UpdateTask(ctx, "user-1", task.ID, tasksvc.UpdateTaskInput{Tags: []string{}})
+	if err != nil || len(updated.Tags) != 0 {
+		t.Fatalf("expected empty tags to clear, got %+v, %v", updated, err)
+	}
+	fetched, err := service.GetTask(ctx, "user-1", task.ID)
+	if err != nil || len(fetched.Tags) != 0 {
+		t.Fatalf("expected no tags after clearing, got %+v, %v", fetched, err)
+	}
+}
+
+func TestListTasksByTags(t *testing.T) {
+	repo := newFakeTaskRepo()
+	tags := newFakeTagRepo(
+		domain.Tag{ID: "tag-home", UserID: "user-1", Name: "Home"},
+		domain.Tag{ID: "tag-work", UserID: "user-1", Name: "work"},
+		domain.Tag{ID: "tag-urgent", UserID: "user-1", Name: "urgent"},
+	)
+	repo.tagRepo = tags
+	for id, tagIDs := range map[string][]string{
+		"home":      {"tag-home"},
+		"work":      {"tag-work"},
+		"home-work": {"tag-home", "tag-work"},
+		"untagged":  nil,
+	} {
+		repo.tasks[id] = domain.Task{ID: id, UserID: "user-1", Title: id, Status: domain.TaskStatusPending}
+		tags.assigned[id] = tagIDs
+	}
+	service := tasksvc.New(repo)
+	service.WithTags(tags)
+
+	list := func(opts tasksvc.ListOptions) []string {
+		t.Helper()
+		opts.Sort = "title"
+		page, err := service.ListTasks(context.Background(), "user-1", opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var ids []string
+		for _, task := range page.Tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	if got, want := list(tasksvc.ListOptions{Tags: []string{"HOME", "work"}}), []string{"home", "home-work", "work"}; !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if got, want := list(tasksvc.ListOptions{Tags: []string{"home", "work", "Work"}, TagMatch: tasksvc.TagMatchAll}), []string{"home-work"}; !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if got := list(tasksvc.ListOptions{Tags: []string{"urgent"}}); len(got) != 0 {
+		t.Fatalf("expected no tasks, got %v", got)
+	}
+	if _, err := service.ListTasks(context.Background(), "user-1", tasksvc.ListOptions{Tags: []string{"home"}, TagMatch: "some"}); err != tasksvc.ErrInvalidTagMatch {
+		t.Fatalf("expected ErrInvalidTagMatch, got %v", err)
+	}
+}
+
+func TestTaskProjects(t *testing.T) {
+	ctx := context.Background()
+	projects := projectsvc.New(&fakeProjectRepo{projects: make(map[string]domain.Project)})
+	service := tasksvc.New(newFakeTaskRepo())
+	service.WithProjects(projects)
+
+	task, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "Unfiled"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inbox, err := projects.Inbox(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.ProjectID != inbox.ID {
+		t.Fatalf("expected task in the inbox %s, got %q", inbox.ID, task.ProjectID)
+	}
+
+	work, err := projects.Create(ctx, "user-1", projectsvc.CreateInput{Name: "Work"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	moved, err := service.UpdateTask(ctx, "user-1", task.ID, tasksvc.UpdateTaskInput{ProjectID: work.ID})
+	if err != nil || moved.ProjectID != work.ID {
+		t.Fatalf("expected task moved to %s, got %+v, %v", work.ID, moved, err)
+	}
+	kept, err := service.UpdateTask(ctx, "user-1", task.ID, tasksvc.UpdateTaskInput{Title: "Renamed"})
+	if err != nil || kept.ProjectID != work.ID {
+		t.Fatalf("expected omitted project to be kept, got %+v, %v", kept, err)
+	}
+
+	page, err := service.ListTasks(ctx, "user-1", tasksvc.ListOptions{ProjectID: inbox.ID})
+	if err != nil || len(page.Tasks) != 0 {
+		t.Fatalf("expected the inbox to be empty, got %+v, %v", page, err)
+	}
+	page, err = service.ListTasks(ctx, "user-1", tasksvc.ListOptions{ProjectID: work.ID})
+	if err != nil || len(page.Tasks) != 1 {
+		t.Fatalf("expected one task in the project, got %+v, %v", page, err)
+	}
+	if _, err := service.ListTasks(ctx, "user-2", tasksvc.ListOptions{ProjectID: work.ID}); err != tasksvc.ErrUnknownProject {
+		t.Fatalf("expected ErrUnknownProject for another user's project, got %v", err)
+	}
+
+	other, _ := projects.Create(ctx, "user-2", projectsvc.CreateInput{Name: "Theirs"})
+	if _, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "x", ProjectID: other.ID}); err != tasksvc.ErrUnknownProject {
+		t.Fatalf("expected ErrUnknownProject, got %v", err)
+	}
+	archived := true
+	if _, err := projects.Update(ctx, "user-1", work.ID, projectsvc.UpdateInput{Archived: &archived}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "x", ProjectID: work.ID}); err != tasksvc.ErrProjectArchived {
+		t.Fatalf("expected ErrProjectArchived, got %v", err)
+	}
+}
+
+func TestSubtasksRollUpProgress(t *testing.T) {
+	ctx := context.Background()
+	service := tasksvc.New(newFakeTaskRepo())
+
+	parent, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "Move house"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var children []*domain.Task
+	for _, title := range []string{"Pack", "Book van", "Clean"} {
+		child, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: title, ParentID: parent.ID})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if child.ParentID != parent.ID {
+			t.Fatalf("expected parent %s, got %q", parent.ID, child.ParentID)
+		}
+		children = append(children, child)
+	}
+	if _, err := service.UpdateTask(ctx, "user-1", children[0].ID, tasksvc.UpdateTaskInput{Status: "done"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fetched, err := service.GetTask(ctx, "user-1", parent.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fetched.Subtasks != (domain.SubtaskProgress{Total: 3, Done: 1}) {
+		t.Fatalf("expected 1 of 3 subtasks done, got %+v", fetched.Subtasks)
+	}
+
+	page, err := service.ListTasks(ctx, "user-1", tasksvc.ListOptions{ParentID: parent.ID})
+	if err != nil || len(page.Tasks) != 3 {
+		t.Fatalf("expected 3 subtasks, got %+v, %v", page, err)
+	}
+	if _, err := service.ListTasks(ctx, "user-2", tasksvc.ListOptions{ParentID: parent.ID}); err != tasksvc.ErrUnknownParent {
+		t.Fatalf("expected ErrUnknownParent for another user's task, got %v", err)
+	}
+	if _, err := service.CreateTask(ctx, "user-2", tasksvc.CreateTaskInput{Title: "x", ParentID: parent.ID}); err != tasksvc.ErrUnknownParent {
+		t.Fatalf("expected ErrUnknownParent, got %v", err)
+	}
+
+	if err := service.DeleteTask(ctx, "user-1", parent.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.GetTask(ctx, "user-1", children[1].ID); err != domain.ErrNotFound {
+		t.Fatalf("expected subtasks to be deleted with their parent, got %v", err)
+	}
+}
+
+func TestSubtasksPreventCyclesAndDeepNesting(t *testing.T) {
+	ctx := context.Background()
+	service := tasksvc.New(newFakeTaskRepo())
+
+	// Build a chain of MaxDepth levels.
+	var chain []*domain.Task
+	for i := 0; i < tasksvc.MaxDepth; i++ {
+		input := tasksvc.CreateTaskInput{Title: fmt.Sprintf("level %d", i+1)}
+		if i > 0 {
+			input.ParentID = chain[i-1].ID
+		}
+		task, err := service.CreateTask(ctx, "user-1", input)
+		if err != nil {
+			t.Fatalf("level %d: unexpected error: %v", i+1, err)
+		}
+		chain = append(chain, task)
+	}
+	last := chain[len(chain)-1]
+
+	if _, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "too deep", ParentID: last.ID}); err != tasksvc.ErrTooDeep {
+		t.Fatalf("expected ErrTooDeep, got %v", err)
+	}
+	if _, err := service.UpdateTask(ctx, "user-1", chain[0].ID, tasksvc.UpdateTaskInput{ParentID: chain[0].ID}); err != tasksvc.ErrParentCycle {
+		t.Fatalf("expected ErrParentCycle for self, got %v", err)
+	}
+	if _, err := service.UpdateTask(ctx, "user-1", chain[1].ID, tasksvc.UpdateTaskInput{ParentID: chain[3].ID}); err != tasksvc.ErrParentCycle {
+		t.Fatalf("expected ErrParentCycle for a descendant, got %v", err)
+	}
+
+	// Moving a three-level subtree under a top-level task gives four levels.
+	other, _ := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "other"})
+	moved, err := service.UpdateTask(ctx, "user-1", chain[2].ID, tasksvc.UpdateTaskInput{ParentID: other.ID})
+	if err != nil || moved.ParentID != other.ID {
+		t.Fatalf("expected subtree to move, got %+v, %v", moved, err)
+	}
+	// Under the second level that subtree would need six.
+	if _, err := service.UpdateTask(ctx, "user-1", other.ID, tasksvc.UpdateTaskInput{ParentID: chain[1].ID}); err != tasksvc.ErrTooDeep {
+		t.Fatalf("expected ErrTooDeep when moving a subtree, got %v", err)
+	}
+	top, err := service.UpdateTask(ctx, "user-1", chain[2].ID, tasksvc.UpdateTaskInput{ClearParent: true})
+	if err != nil || top.ParentID != "" {
+		t.Fatalf("expected task to become top-level, got %+v, %v", top, err)
+	}
+}
+
+// racingMoveRepo commits a move from another request just before the next
+// update is stored, and then turns the update down as the database
+// re-check would.
+type racingMoveRepo struct {
+	*fakeTaskRepo
+	move *domain.Task
+}
+
+func (r *racingMoveRepo) Update(ctx context.Context, task *domain.Task) error {
+	if move := r.move; move != nil {
+		r.move = nil
+		r.tasks[move.ID] = *move
+		return domain.ErrConcurrentUpdate
+	}
+	return r.fakeTaskRepo.Update(ctx, task)
+}
+
+func TestSubtaskMoveRacingAnotherMove(t *testing.T) {
+	ctx := context.Background()
+	repo := &racingMoveRepo{fakeTaskRepo: newFakeTaskRepo()}
+	service := tasksvc.New(repo)
+
+	a, _ := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "a"})
+	b, _ := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "b"})
+
+	// b is moved under a after a's move under b has been checked.
+	moved := *b
+	moved.ParentID = a.ID
+	repo.move = &moved
+	if _, err := service.UpdateTask(ctx, "user-1", a.ID, tasksvc.UpdateTaskInput{ParentID: b.ID}); err != tasksvc.ErrParentCycle {
+		t.Fatalf("expected ErrParentCycle, got %v", err)
+	}
+	stored, _ := service.GetTask(ctx, "user-1", a.ID)
+	if stored.ParentID != "" {
+		t.Fatalf("expected a to stay top-level, got parent %q", stored.ParentID)
+	}
+
+	// A conflict that has passed by the time it is explained is reported
+	// as such, so the client can retry.
+	repo.move = b
+	if _, err := service.UpdateTask(ctx, "user-1", b.ID, tasksvc.UpdateTaskInput{ParentID: a.ID}); !errors.Is(err, domain.ErrConcurrentUpdate) {
+		t.Fatalf("expected ErrConcurrentUpdate, got %v", err)
+	}
+}
+
+func TestCheckRestoredParents(t *testing.T) {
+	ctx := context.Background()
+	service := tasksvc.New(newFakeTaskRepo())
+
+	// A stored three-level subtree and a stored chain three levels deep.
+	create := func(title, parentID string) *domain.Task {
+		t.Helper()
+		task, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: title, ParentID: parentID})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return task
+	}
+	root := create("root", "")
+	child := create("child", root.ID)
+	create("grandchild", child.ID)
+	top := create("top", "")
+	second := create("second", top.ID)
+	third := create("third", second.ID)
+
+	const restored = "0f8fad5b-d9cb-469f-a165-70867728950e"
+	cases := []struct {
+		name    string
+		parents map[string]string
+		want    error
+	}{
+		{"new subtask of a stored task", map[string]string{restored: second.ID}, nil},
+		{"stored subtree moved under a new task", map[string]string{restored: top.ID, root.ID: restored}, nil},
+		{"stored subtree moved under a deeper new task", map[string]string{restored: second.ID, root.ID: restored}, tasksvc.ErrTooDeep},
+		{"stored subtree moved to the top", map[string]string{root.ID: ""}, nil},
+		{"stored subtree moved too deep", map[string]string{root.ID: third.ID}, tasksvc.ErrTooDeep},
+		{"cycle through a stored subtask", map[string]string{root.ID: child.ID}, tasksvc.ErrParentCycle},
+		{"unknown parent", map[string]string{restored: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}, tasksvc.ErrUnknownParent},
+	}
+	for _, tc := range cases {
+		if err := service.CheckRestoredParents(ctx, "user-1", tc.parents); !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestListTasksAsTree(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeTaskRepo()
+	service := tasksvc.New(repo)
+	at := time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)
+	service.WithNow(func() time.Time {
+		at = at.Add(time.Minute)
+		return at
+	})
+
+	create := func(title, parentID string) *domain.Task {
+		t.Helper()
+		task, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: title, ParentID: parentID})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return task
+	}
+	garden := create("Garden", "")
+	weed := create("Weed", garden.ID)
+	create("Beds", weed.ID)
+	create("Mow", garden.ID)
+	create("Taxes", "")
+
+	page, err := service.ListTasks(ctx, "user-1", tasksvc.ListOptions{Tree: true, Sort: "created_at:asc"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Tree) != 2 || page.Tree[0].Task.Title != "Garden" || page.Tree[1].Task.Title != "Taxes" {
+		t.Fatalf("expected two top-level tasks, got %+v", page.Tree)
+	}
+	children := page.Tree[0].Children
+	if len(children) != 2 || children[0].Task.Title != "Weed" || children[1].Task.Title != "Mow" {
+		t.Fatalf("unexpected children: %+v", children)
+	}
+	if len(children[0].Children) != 1 || children[0].Children[0].Task.Title != "Beds" {
+		t.Fatalf("unexpected grandchildren: %+v", children[0].Children)
+	}
+	if page.Tree[0].Task.Subtasks.Total != 2 || page.Tasks[0].Subtasks.Total != 2 {
+		t.Fatalf("expected roll-up on tree nodes and tasks, got %+v and %+v", page.Tree[0].Task.Subtasks, page.Tasks[0].Subtasks)
+	}
+}
+
+// pendingTasks lists the user's pending tasks.
+func pendingTasks(t *testing.T, service *tasksvc.Service, userID string) []domain.Task {
+	t.Helper()
+	page, err := service.ListTasks(context.Background(), userID, tasksvc.ListOptions{Status: string(domain.TaskStatusPending)})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	return page.Tasks
+}
+
+func TestRecurringTaskFromDueDateKeepsLocalTimeAcrossDST(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeTaskRepo()
+	tags := newFakeTagRepo(domain.Tag{ID: "tag-home", UserID: "user-1", Name: "home"})
+	repo.tagRepo = tags
+	service := tasksvc.New(repo)
+	service.WithTags(tags)
+	service.WithUsers(&fakeUserRepo{users: map[string]*domain.User{
+		"user-1": {ID: "user-1", TimeZone: "America/New_York"},
+	}})
+	// Done on Wednesday, November 4, 2026; clocks went back on Sunday.
+	service.WithNow(func() time.Time { return time.Date(2026, 11, 4, 15, 0, 0, 0, time.UTC) })
+
+	due := time.Date(2026, 10, 30, 13, 0, 0, 0, time.UTC) // Friday 09:00 EDT
+	task, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{
+		Title:      "Take out the bins",
+		DueAt:      &due,
+		Tags:       []string{"home"},
+		Recurrence: "rrule:freq=weekly;byday=fr;count=3",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.Recurrence != "FREQ=WEEKLY;BYDAY=FR;COUNT=3" || task.RecurrenceBasis != domain.RecurFromDue {
+		t.Fatalf("expected a normalised rule scheduled from the due date, got %q %q", task.Recurrence, task.RecurrenceBasis)
+	}
+
+	done, err := service.UpdateTask(ctx, "user-1", task.ID, tasksvc.UpdateTaskInput{Status: "done"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	next := done.NextOccurrence
+	if next == nil {
+		t.Fatalf("expected a next occurrence")
+	}
+	if want := time.Date(2026, 11, 6, 14, 0, 0, 0, time.UTC); !next.DueAt.Equal(want) {
+		t.Fatalf("expected 09:00 EST on Friday (%v), got %v", want, next.DueAt)
+	}
+	if next.Status != domain.TaskStatusPending || next.Title != task.Title || len(next.Tags) != 1 {
+		t.Fatalf("expected a pending copy with the tags, got %+v", next)
+	}
+	if next.Recurrence != "FREQ=WEEKLY;BYDAY=FR;COUNT=2" || done.Recurrence != "" {
+		t.Fatalf("expected the series to move on with one occurrence left, got %q and %q", next.Recurrence, done.Recurrence)
+	}
+	if stored, err := service.GetTask(ctx, "user-1", next.ID); err != nil || len(stored.Tags) != 1 {
+		t.Fatalf("expected the next occurrence to be stored with the tags, got %+v, %v", stored, err)
+	}
+
+	// Reopening and completing again does not repeat the task twice.
+	if _, err := service.UpdateTask(ctx, "user-1", task.ID, tasksvc.UpdateTaskInput{Status: "pending"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.UpdateTask(ctx, "user-1", task.ID, tasksvc.UpdateTaskInput{Status: "done"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pending := pendingTasks(t, service, "user-1"); len(pending) != 1 || pending[0].ID != next.ID {
+		t.Fatalf("expected only the next occurrence to be pending, got %v", taskIDs(pending))
+	}
+
+	// The third occurrence is the last one.
+	last, err := service.UpdateTask(ctx, "user-1", next.ID, tasksvc.UpdateTaskInput{Status: "done"})
+	if err != nil || last.NextOccurrence == nil {
+		t.Fatalf("expected a third occurrence, got %+v, %v", last, err)
+	}
+	final, err := service.UpdateTask(ctx, "user-1", last.NextOccurrence.ID, tasksvc.UpdateTaskInput{Status: "done"})
+	if err != nil || final.NextOccurrence != nil {
+		t.Fatalf("expected the series to end after three occurrences, got %+v, %v", final, err)
+	}
+}
+
+// failingCompletionRepo refuses to store completed occurrences.
+type failingCompletionRepo struct {
+	*fakeTaskRepo
+}
+
+func (r *failingCompletionRepo) CompleteOccurrence(ctx context.Context, done, next *domain.Task) error {
+	return errors.New("connection lost")
+}
+
+func TestRecurringTaskCompletionIsAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	repo := &failingCompletionRepo{fakeTaskRepo: newFakeTaskRepo()}
+	service := tasksvc.New(repo)
+
+	due := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	task, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "Water plants", DueAt: &due, Recurrence: "FREQ=DAILY"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.UpdateTask(ctx, "user-1", task.ID, tasksvc.UpdateTaskInput{Status: "done"}); err == nil {
+		t.Fatal("expected the failed write to be reported")
+	}
+	stored, err := service.GetTask(ctx, "user-1", task.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored.Status != domain.TaskStatusPending || stored.Recurrence != "FREQ=DAILY" || len(repo.tasks) != 1 {
+		t.Fatalf("expected the series to be left as it was, got %+v and %d tasks", stored, len(repo.tasks))
+	}
+}
+
+func TestRecurringTaskFromCompletionDate(t *testing.T) {
+	ctx := context.Background()
+	service := tasksvc.New(newFakeTaskRepo())
+	service.WithUsers(&fakeUserRepo{users: map[string]*domain.User{
+		"user-1": {ID: "user-1", TimeZone: "Asia/Tokyo"},
+	}})
+	// 08:00 on Saturday, October 17 in Tokyo, still Friday in UTC.
+	service.WithNow(func() time.Time { return time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC) })
+
+	due := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+	task, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{
+		Title:           "Water the plants",
+		DueAt:           &due,
+		DueAllDay:       true,
+		Recurrence:      "FREQ=DAILY;INTERVAL=3",
+		RecurrenceBasis: "completion",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	done, err := service.UpdateTask(ctx, "user-1", task.ID, tasksvc.UpdateTaskInput{Status: "done"})
+	if err != nil || done.NextOccurrence == nil {
+		t.Fatalf("expected a next occurrence, got %+v, %v", done, err)
+	}
+	next := done.NextOccurrence
+	if want := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC); !next.DueAt.Equal(want) || !next.DueAllDay {
+		t.Fatalf("expected all-day on October 20, three days after completion, got %v", next.DueAt)
+	}
+	if next.RecurrenceBasis != domain.RecurFromCompletion {
+		t.Fatalf("expected the basis to carry over, got %q", next.RecurrenceBasis)
+	}
+
+	// Scheduling from the due date instead would have kept the old rhythm.
+	updated, err := service.UpdateTask(ctx, "user-1", next.ID, tasksvc.UpdateTaskInput{RecurrenceBasis: "due", Status: "done"})
+	if err != nil || updated.NextOccurrence == nil {
+		t.Fatalf("expected a next occurrence, got %+v, %v", updated, err)
+	}
+	if want := time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC); !updated.NextOccurrence.DueAt.Equal(want) {
+		t.Fatalf("expected October 23, got %v", updated.NextOccurrence.DueAt)
+	}
+}
+
+func TestRecurrenceValidation(t *testing.T) {
+	ctx := context.Background()
+	service := tasksvc.New(newFakeTaskRepo())
+	due := time.Date(2026, 10, 30, 9, 0, 0, 0, time.UTC)
+
+	if _, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "Title", Recurrence: "FREQ=DAILY"}); !errors.Is(err, tasksvc.ErrRecurrenceNeedsDue) {
+		t.Fatalf("expected ErrRecurrenceNeedsDue, got %v", err)
+	}
+	if _, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "Title", DueAt: &due, Recurrence: "FREQ=HOURLY"}); !errors.Is(err, tasksvc.ErrInvalidRecurrence) {
+		t.Fatalf("expected ErrInvalidRecurrence, got %v", err)
+	}
+	if _, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "Title", DueAt: &due, Recurrence: "FREQ=DAILY", RecurrenceBasis: "whenever"}); !errors.Is(err, tasksvc.ErrInvalidRecurrenceBasis) {
+		t.Fatalf("expected ErrInvalidRecurrenceBasis, got %v", err)
+	}
+
+	task, err := service.CreateTask(ctx, "user-1", tasksvc.CreateTaskInput{Title: "Title", DueAt: &due, Recurrence: "FREQ=DAILY"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.UpdateTask(ctx, "user-1", task.ID, tasksvc.UpdateTaskInput{ClearDue: true}); !errors.Is(err, tasksvc.ErrRecurrenceNeedsDue) {
+		t.Fatalf("expected ErrRecurrenceNeedsDue when clearing the due date, got %v", err)
+	}
+	updated, err := service.UpdateTask(ctx, "user-1", task.ID, tasksvc.UpdateTaskInput{ClearRecurrence: true, ClearDue: true})
+	if err != nil || updated.Recurrence != "" || updated.DueAt != nil {
+		t.Fatalf("expected a one-off task without a due date, got %+v, %v", updated, err)
+	}
+	done, err := service.UpdateTask(ctx, "user-1", task.ID, tasksvc.UpdateTaskInput{Status: "done"})
+	if err != nil || done.NextOccurrence != nil {
+		t.Fatalf("expected no next occurrence for a one-off task, got %+v, %v", done, err)
+	}
+}
@@ -0,0 +1,229 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+
+	"go-todo-service/internal/domain"
+	"go-todo-service/pkg/uuid"
+)
+
+// TaskNode is a task together with its subtasks, oldest first.
+type TaskNode struct {
+	Task     domain.Task
+	Children []TaskNode
+}
+
+// ownedTask fetches a task, reporting tasks of other users as not found.
+func (s *Service) ownedTask(ctx context.Context, userID, id string) (*domain.Task, error) {
+	if !uuid.Valid(id) {
+		return nil, domain.ErrNotFound
+	}
+	task, err := s.tasks.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if task.UserID != userID {
+		return nil, domain.ErrNotFound
+	}
+	return task, nil
+}
+
+// checkParent validates placing the task taskID, or a new task when taskID
+// is empty, under parentID. The parent must belong to the user and must not
+// be the task or one of its subtasks, and the task's subtree must still fit
+// within MaxDepth levels.
+func (s *Service) checkParent(ctx context.Context, userID, taskID, parentID string) (*domain.Task, error) {
+	parent, err := s.ownedTask(ctx, userID, parentID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrUnknownParent
+		}
+		return nil, err
+	}
+	if parent.ID == taskID {
+		return nil, ErrParentCycle
+	}
+	ancestors, err := s.tasks.ListAncestors(ctx, parent.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, ancestor := range ancestors {
+		if ancestor.ID == taskID {
+			return nil, ErrParentCycle
+		}
+	}
+
+	height := 1
+	if taskID != "" {
+		descendants, err := s.tasks.ListDescendants(ctx, []string{taskID})
+		if err != nil {
+			return nil, err
+		}
+		height = subtreeHeight(taskID, descendants)
+	}
+	// The parent sits below its ancestors, and the task's subtree below it.
+	if len(ancestors)+1+height > MaxDepth {
+		return nil, ErrTooDeep
+	}
+	return parent, nil
+}
+
+// placementError explains a write the repository turned down with
+// domain.ErrConcurrentUpdate because tasks were moved after checkParent
+// passed. Checking again against the committed tasks names the cycle or
+// depth violation; should the conflict be gone by now, the original error
+// is kept so the caller can retry.
+func (s *Service) placementError(ctx context.Context, userID, taskID, parentID string, err error) error {
+	if !errors.Is(err, domain.ErrConcurrentUpdate) || parentID == "" {
+		return err
+	}
+	if _, checkErr := s.checkParent(ctx, userID, taskID, parentID); checkErr != nil {
+		return checkErr
+	}
+	return err
+}
+
+// CheckRestoredParents validates the parents given to tasks restored from
+// an export archive, keyed by task id with an empty parent for top-level
+// tasks. Together with the parents the user's other tasks keep, no task may
+// end up nested under itself and no hierarchy may grow beyond MaxDepth
+// levels.
+func (s *Service) CheckRestoredParents(ctx context.Context, userID string, parents map[string]string) error {
+	known := maps.Clone(parents)
+	descendants, err := s.tasks.ListDescendants(ctx, slices.Collect(maps.Keys(parents)))
+	if err != nil {
+		return err
+	}
+	for _, task := range descendants {
+		if _, ok := known[task.ID]; !ok {
+			known[task.ID] = task.ParentID
+		}
+	}
+
+	depths := make(map[string]int, len(known))
+	for _, id := range slices.Collect(maps.Keys(known)) {
+		// Walk up until a task of known depth or a top-level task.
+		var path []string
+		onPath := make(map[string]bool)
+		depth := 0
+		for current := id; current != ""; {
+			if d, ok := depths[current]; ok {
+				depth = d
+				break
+			}
+			if onPath[current] {
+				return ErrParentCycle
+			}
+			if len(path) == MaxDepth {
+				return ErrTooDeep
+			}
+			path = append(path, current)
+			onPath[current] = true
+
+			parent, ok := known[current]
+			if !ok {
+				task, err := s.ownedTask(ctx, userID, current)
+				if err != nil {
+					if errors.Is(err, domain.ErrNotFound) {
+						return ErrUnknownParent
+					}
+					return err
+				}
+				parent = task.ParentID
+				known[current] = parent
+			}
+			current = parent
+		}
+		for i := len(path) - 1; i >= 0; i-- {
+			depth++
+			if depth > MaxDepth {
+				return ErrTooDeep
+			}
+			depths[path[i]] = depth
+		}
+	}
+	return nil
+}
+
+// subtreeHeight counts the levels of the subtree rooted at rootID, the root
+// included.
+func subtreeHeight(rootID string, descendants []domain.Task) int {
+	children := make(map[string][]string)
+	for _, task := range descendants {
+		children[task.ParentID] = append(children[task.ParentID], task.ID)
+	}
+	height := 0
+	level := []string{rootID}
+	for len(level) > 0 && height <= MaxDepth {
+		height++
+		var next []string
+		for _, id := range level {
+			next = append(next, children[id]...)
+		}
+		level = next
+	}
+	return height
+}
+
+// loadTree attaches the complete subtree to each of the tasks.
+func (s *Service) loadTree(ctx context.Context, roots []domain.Task) ([]TaskNode, error) {
+	ids := make([]string, len(roots))
+	for i, task := range roots {
+		ids[i] = task.ID
+	}
+	descendants, err := s.tasks.ListDescendants(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	all := append(append([]domain.Task(nil), roots...), descendants...)
+	if err := s.enrich(ctx, all); err != nil {
+		return nil, err
+	}
+	// Hand the enriched roots back to the caller's page as well.
+	copy(roots, all)
+
+	children := make(map[string][]domain.Task)
+	for _, task := range all[len(roots):] {
+		children[task.ParentID] = append(children[task.ParentID], task)
+	}
+	var build func(task domain.Task, depth int) TaskNode
+	build = func(task domain.Task, depth int) TaskNode {
+		node := TaskNode{Task: task}
+		if depth < MaxDepth {
+			for _, child := range children[task.ID] {
+				node.Children = append(node.Children, build(child, depth+1))
+			}
+		}
+		return node
+	}
+	nodes := make([]TaskNode, len(roots))
+	for i, task := range roots {
+		nodes[i] = build(task, 1)
+	}
+	return nodes, nil
+}
+
+// enrich fills in the tags and subtask progress of each task.
+func (s *Service) enrich(ctx context.Context, tasks []domain.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	if err := s.loadTags(ctx, tasks); err != nil {
+		return err
+	}
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	progress, err := s.tasks.CountSubtasks(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		tasks[i].Subtasks = progress[tasks[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go-todo-service/internal/domain"
+)
+
+// resolveTags looks up the user's tags by name, ignoring case and
+// duplicates. Every name must belong to an existing tag.
+func (s *Service) resolveTags(ctx context.Context, userID string, names []string) ([]domain.Tag, error) {
+	wanted := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		wanted = append(wanted, key)
+	}
+	if len(wanted) == 0 {
+		return nil, nil
+	}
+	if s.tags == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTag, wanted[0])
+	}
+
+	tags, err := s.tags.GetByNames(ctx, userID, wanted)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		found[strings.ToLower(tag.Name)] = true
+	}
+	for _, key := range wanted {
+		if !found[key] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownTag, key)
+		}
+	}
+	return tags, nil
+}
+
+// setTags replaces the task's tags and records them on the task.
+func (s *Service) setTags(ctx context.Context, task *domain.Task, tags []domain.Tag) error {
+	if s.tags == nil {
+		return nil
+	}
+	ids := make([]string, len(tags))
+	for i, tag := range tags {
+		ids[i] = tag.ID
+	}
+	if err := s.tags.SetTaskTags(ctx, task.ID, ids); err != nil {
+		return err
+	}
+	task.Tags = tags
+	return nil
+}
+
+// loadTags fills in the tags of each task with a single lookup.
+func (s *Service) loadTags(ctx context.Context, tasks []domain.Task) error {
+	if s.tags == nil || len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	byTask, err := s.tags.ListByTasks(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		tasks[i].Tags = byTask[tasks[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_tasks_user_due_at;
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS due_all_day,
+    DROP COLUMN IF EXISTS due_at;
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_tasks_user_created_at ON tasks(user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_tasks_user_updated_at ON tasks(user_id, updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_tasks_user_title ON tasks(user_id, title, id);
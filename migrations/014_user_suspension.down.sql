@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_until;
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_reason;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_tasks_user_priority_due_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS priority;
@@ -0,0 +1,5 @@
+-- Priorities are stored by rank, 0 (none) to 4 (urgent), so they sort directly.
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 0 CHECK (priority BETWEEN 0 AND 4);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_priority_due_at ON tasks(user_id, priority, due_at, id);
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS projects (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    color TEXT NOT NULL,
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    inbox BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_projects_user_sort_order ON projects(user_id, sort_order);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_projects_user_inbox ON projects(user_id) WHERE inbox;
+
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS project_id UUID REFERENCES projects(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_project_id ON tasks(project_id);
+
+-- Existing users get an inbox holding all of their tasks.
+INSERT INTO projects (id, user_id, name, color, inbox)
+SELECT gen_random_uuid(), id, 'Inbox', '#808080', TRUE
+FROM users
+ON CONFLICT DO NOTHING;
+
+UPDATE tasks t
+SET project_id = p.id
+FROM projects p
+WHERE p.user_id = t.user_id AND p.inbox AND t.project_id IS NULL;
@@ -0,0 +1,4 @@
+-- recurrence holds an RFC 5545 RRULE; an empty string marks a one-off task.
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS recurrence TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS recurrence_basis TEXT NOT NULL DEFAULT 'due' CHECK (recurrence_basis IN ('due', 'completion'));
@@ -0,0 +1,103 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// JWK is the JSON Web Key representation of a public verification key.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	// RSA parameters.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519) parameters.
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set document.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set. Symmetric keys are never exported.
+func (s *KeySet) JWKS() JWKS {
+	doc := JWKS{Keys: []JWK{}}
+	for _, key := range s.order {
+		switch key.Algorithm {
+		case RS256:
+			doc.Keys = append(doc.Keys, JWK{
+				KeyType:   "RSA",
+				KeyID:     key.ID,
+				Algorithm: string(RS256),
+				Use:       "sig",
+				N:         base64.RawURLEncoding.EncodeToString(key.rsaPublic.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.rsaPublic.E)).Bytes()),
+			})
+		case EdDSA:
+			doc.Keys = append(doc.Keys, JWK{
+				KeyType:   "OKP",
+				KeyID:     key.ID,
+				Algorithm: string(EdDSA),
+				Use:       "sig",
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(key.edPublic),
+			})
+		}
+	}
+	return doc
+}
+
+// PublicKey converts the JWK into a verification-only key. RSA and Ed25519
+// keys are supported.
+func (k JWK) PublicKey() (*Key, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil || len(n) == 0 {
+			return nil, errors.New("invalid rsa modulus")
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid rsa exponent")
+		}
+		exponent := int(new(big.Int).SetBytes(e).Int64())
+		return NewPublicKey(k.KeyID, &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent})
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, errors.New("invalid ed25519 key")
+		}
+		return NewPublicKey(k.KeyID, ed25519.PublicKey(x))
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+}
+
+// KeySet builds a verification-only key set from the document's signing
+// keys. Encryption keys and keys of unsupported types are skipped.
+func (doc JWKS) KeySet() (*KeySet, error) {
+	var keys []*Key
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return NewVerifyingKeySet(keys...)
+}
@@ -1,13 +1,13 @@
 package jwt
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"strings"
 	"time"
+
+	"go-todo-service/pkg/uuid"
 )
 
 var (
@@ -15,54 +15,82 @@ var (
 	ErrExpiredToken = errors.New("token expired")
 )
 
-var headerSegment = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+type header struct {
+	Algorithm Algorithm `json:"alg"`
+	KeyID     string    `json:"kid,omitempty"`
+	Type      string    `json:"typ,omitempty"`
+}
 
 // Claims represents a subset of standard JWT claims the service relies on.
 type Claims struct {
+	// ID is the unique token identifier used for revocation.
+	ID        string `json:"jti"`
 	Subject   string `json:"sub"`
 	IssuedAt  int64  `json:"iat"`
 	ExpiresAt int64  `json:"exp"`
+	// SessionID identifies the login the token descends from, if any.
+	SessionID string `json:"sid,omitempty"`
+	// Role is the subject's role when the token was issued. Tokens issued
+	// before roles existed carry none.
+	Role string `json:"role,omitempty"`
+	// Purpose marks special-purpose tokens such as MFA challenges. It is empty
+	// for access tokens.
+	Purpose string `json:"purpose,omitempty"`
 }
 
-// GenerateToken issues a signed JWT for the given subject.
-func GenerateToken(subject, secret string, ttl time.Duration, now time.Time) (string, error) {
+// GenerateToken issues a JWT for the given subject signed with the set's current key.
+func GenerateToken(subject string, keys *KeySet, ttl time.Duration, now time.Time) (string, error) {
 	if subject == "" {
 		return "", errors.New("empty subject")
 	}
 	if ttl <= 0 {
 		return "", errors.New("invalid ttl")
 	}
-	claims := Claims{
+	id, err := uuid.NewString()
+	if err != nil {
+		return "", err
+	}
+	return keys.Sign(Claims{
+		ID:        id,
 		Subject:   subject,
 		IssuedAt:  now.Unix(),
 		ExpiresAt: now.Add(ttl).Unix(),
+	})
+}
+
+// Sign serialises the claims and signs them with the current signing key.
+func (s *KeySet) Sign(claims Claims) (string, error) {
+	return s.SignPayload(claims)
+}
+
+// SignPayload signs an arbitrary JSON-serialisable claim set with the
+// current signing key.
+func (s *KeySet) SignPayload(payload any) (string, error) {
+	if s.signing == nil {
+		return "", errors.New("key set has no signing key")
 	}
-	payloadBytes, err := json.Marshal(claims)
+	headerBytes, err := json.Marshal(header{Algorithm: s.signing.Algorithm, KeyID: s.signing.ID, Type: "JWT"})
 	if err != nil {
 		return "", err
 	}
-	payload := base64.RawURLEncoding.EncodeToString(payloadBytes)
-	unsigned := headerSegment + "." + payload
-	signature := sign(unsigned, secret)
-	return unsigned + "." + signature, nil
-}
-
-// ParseAndValidate parses the token string, validates the signature and returns the claims.
-func ParseAndValidate(token, secret string, now time.Time) (*Claims, error) {
-	parts := strings.Split(token, ".")
-	if len(parts) != 3 {
-		return nil, ErrInvalidToken
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
 	}
-
-	unsigned := parts[0] + "." + parts[1]
-	expectedSig := sign(unsigned, secret)
-	if !hmac.Equal([]byte(parts[2]), []byte(expectedSig)) {
-		return nil, ErrInvalidToken
+	unsigned := base64.RawURLEncoding.EncodeToString(headerBytes) + "." + base64.RawURLEncoding.EncodeToString(payloadBytes)
+	signature, err := s.signing.sign([]byte(unsigned))
+	if err != nil {
+		return "", err
 	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
 
-	payloadBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+// ParseAndValidate parses the token string, validates the signature against
+// the key named by its kid header and returns the claims.
+func ParseAndValidate(token string, keys *KeySet, now time.Time) (*Claims, error) {
+	payloadBytes, err := Verify(token, keys)
 	if err != nil {
-		return nil, ErrInvalidToken
+		return nil, err
 	}
 
 	var claims Claims
@@ -77,8 +105,42 @@ func ParseAndValidate(token, secret string, now time.Time) (*Claims, error) {
 	return &claims, nil
 }
 
-func sign(data, secret string) string {
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write([]byte(data))
-	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+// Verify checks the token's signature against the key named by its kid
+// header and returns the raw JSON payload. Callers validate the claims.
+func Verify(token string, keys *KeySet) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var hdr header
+	if err := json.Unmarshal(headerBytes, &hdr); err != nil {
+		return nil, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	unsigned := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range keys.candidates(hdr.Algorithm, hdr.KeyID) {
+		if key.verify(unsigned, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, ErrInvalidToken
+	}
+
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return payloadBytes, nil
 }
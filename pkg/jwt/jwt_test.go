@@ -0,0 +1,187 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustHMACKey(t *testing.T, id string) *Key {
+	t.Helper()
+	key, err := NewHMACKey(id, []byte(id+"-secret-secret-secret-secret-secret"))
+	if err != nil {
+		t.Fatalf("hmac key: %v", err)
+	}
+	return key
+}
+
+func mustRSAKey(t *testing.T, id string) *Key {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa generate: %v", err)
+	}
+	key, err := NewRSAKey(id, private)
+	if err != nil {
+		t.Fatalf("rsa key: %v", err)
+	}
+	return key
+}
+
+func mustEd25519Key(t *testing.T, id string) *Key {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519 generate: %v", err)
+	}
+	key, err := NewEd25519Key(id, private)
+	if err != nil {
+		t.Fatalf("ed25519 key: %v", err)
+	}
+	return key
+}
+
+func TestGenerateAndParseAllAlgorithms(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, key := range []*Key{mustHMACKey(t, "hs"), mustRSAKey(t, "rs"), mustEd25519Key(t, "ed")} {
+		t.Run(string(key.Algorithm), func(t *testing.T) {
+			keys, err := NewKeySet(key.ID, key)
+			if err != nil {
+				t.Fatalf("key set: %v", err)
+			}
+			token, err := GenerateToken("user-1", keys, time.Minute, now)
+			if err != nil {
+				t.Fatalf("generate: %v", err)
+			}
+
+			headerBytes, _ := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+			var hdr header
+			if err := json.Unmarshal(headerBytes, &hdr); err != nil {
+				t.Fatalf("header: %v", err)
+			}
+			if hdr.KeyID != key.ID || hdr.Algorithm != key.Algorithm {
+				t.Fatalf("unexpected header %+v", hdr)
+			}
+
+			claims, err := ParseAndValidate(token, keys, now)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if claims.Subject != "user-1" || claims.ID == "" {
+				t.Fatalf("unexpected claims %+v", claims)
+			}
+			if _, err := ParseAndValidate(token, keys, now.Add(2*time.Minute)); err != ErrExpiredToken {
+				t.Fatalf("expected ErrExpiredToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRotationKeepsOldTokensValid(t *testing.T) {
+	now := time.Now()
+	oldKey := mustHMACKey(t, "old")
+	newKey := mustEd25519Key(t, "new")
+
+	before, _ := NewKeySet("old", oldKey)
+	oldToken, err := GenerateToken("user-1", before, time.Minute, now)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	rotated, err := NewKeySet("new", oldKey, newKey)
+	if err != nil {
+		t.Fatalf("key set: %v", err)
+	}
+	if _, err := ParseAndValidate(oldToken, rotated, now); err != nil {
+		t.Fatalf("expected old token to verify after rotation: %v", err)
+	}
+
+	retired, _ := NewKeySet("new", newKey)
+	if _, err := ParseAndValidate(oldToken, retired, now); err != ErrInvalidToken {
+		t.Fatalf("expected ErrInvalidToken once the old key is retired, got %v", err)
+	}
+}
+
+func TestParseRejectsAlgorithmMismatch(t *testing.T) {
+	now := time.Now()
+	rsaKey := mustRSAKey(t, "shared")
+	keys, _ := NewKeySet("shared", rsaKey)
+
+	// Forge an HS256 token using the RSA key id; it must not be accepted.
+	forger, _ := NewKeySet("shared", mustHMACKey(t, "shared"))
+	forged, err := GenerateToken("user-1", forger, time.Minute, now)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if _, err := ParseAndValidate(forged, keys, now); err != ErrInvalidToken {
+		t.Fatalf("expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestJWKSExportsOnlyPublicKeys(t *testing.T) {
+	keys, err := NewKeySet("rs", mustHMACKey(t, "hs"), mustRSAKey(t, "rs"), mustEd25519Key(t, "ed"))
+	if err != nil {
+		t.Fatalf("key set: %v", err)
+	}
+	doc := keys.JWKS()
+	if len(doc.Keys) != 2 {
+		t.Fatalf("expected 2 public keys, got %d", len(doc.Keys))
+	}
+	for _, jwk := range doc.Keys {
+		if jwk.KeyID == "hs" {
+			t.Fatal("hmac secret must not be published")
+		}
+	}
+}
+
+func TestJWKSRoundTripVerifies(t *testing.T) {
+	signer, err := NewKeySet("rs", mustRSAKey(t, "rs"), mustEd25519Key(t, "ed"))
+	if err != nil {
+		t.Fatalf("key set: %v", err)
+	}
+	published, err := json.Marshal(signer.JWKS())
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var doc JWKS
+	if err := json.Unmarshal(published, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	verifier, err := doc.KeySet()
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	token, err := signer.SignPayload(map[string]any{"sub": "user-1", "nonce": "n"})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	payload, err := Verify(token, verifier)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil || claims["nonce"] != "n" {
+		t.Fatalf("unexpected payload %s", payload)
+	}
+
+	if _, err := verifier.SignPayload(map[string]any{}); err == nil {
+		t.Fatal("expected verifying key set to refuse signing")
+	}
+	hmacSet, err := NewKeySet("hs", mustHMACKey(t, "hs"))
+	if err != nil {
+		t.Fatalf("key set: %v", err)
+	}
+	forged, err := hmacSet.SignPayload(map[string]any{"sub": "user-1"})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if _, err := Verify(forged, verifier); err != ErrInvalidToken {
+		t.Fatalf("expected token signed with an unknown key to fail, got %v", err)
+	}
+}
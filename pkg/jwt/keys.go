@@ -0,0 +1,238 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Algorithm identifies a JWS signing algorithm.
+type Algorithm string
+
+const (
+	HS256 Algorithm = "HS256"
+	RS256 Algorithm = "RS256"
+	EdDSA Algorithm = "EdDSA"
+)
+
+// minRSABits is the smallest RSA modulus accepted for signing or verification.
+const minRSABits = 2048
+
+// Key is a signing or verification key identified by a key id (kid).
+// Keys built from public material can only verify.
+type Key struct {
+	ID        string
+	Algorithm Algorithm
+
+	secret     []byte
+	rsaPrivate *rsa.PrivateKey
+	rsaPublic  *rsa.PublicKey
+	edPrivate  ed25519.PrivateKey
+	edPublic   ed25519.PublicKey
+}
+
+// NewHMACKey returns an HS256 key using the shared secret.
+func NewHMACKey(id string, secret []byte) (*Key, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("empty hmac secret")
+	}
+	return &Key{ID: id, Algorithm: HS256, secret: secret}, nil
+}
+
+// NewRSAKey returns an RS256 signing key.
+func NewRSAKey(id string, private *rsa.PrivateKey) (*Key, error) {
+	if private == nil || private.N.BitLen() < minRSABits {
+		return nil, fmt.Errorf("rsa key must be at least %d bits", minRSABits)
+	}
+	return &Key{ID: id, Algorithm: RS256, rsaPrivate: private, rsaPublic: &private.PublicKey}, nil
+}
+
+// NewEd25519Key returns an EdDSA signing key.
+func NewEd25519Key(id string, private ed25519.PrivateKey) (*Key, error) {
+	if len(private) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid ed25519 private key")
+	}
+	return &Key{ID: id, Algorithm: EdDSA, edPrivate: private, edPublic: private.Public().(ed25519.PublicKey)}, nil
+}
+
+// NewPublicKey returns a verification-only key for an RSA or Ed25519 public key.
+func NewPublicKey(id string, public crypto.PublicKey) (*Key, error) {
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("rsa key must be at least %d bits", minRSABits)
+		}
+		return &Key{ID: id, Algorithm: RS256, rsaPublic: pub}, nil
+	case ed25519.PublicKey:
+		if len(pub) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 public key")
+		}
+		return &Key{ID: id, Algorithm: EdDSA, edPublic: pub}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", public)
+	}
+}
+
+// ParsePrivateKeyPEM reads a PEM-encoded RSA (PKCS#1 or PKCS#8) or Ed25519
+// (PKCS#8) private key.
+func ParsePrivateKeyPEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if block.Type == "RSA PRIVATE KEY" {
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return NewRSAKey(id, private)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		return NewRSAKey(id, private)
+	case ed25519.PrivateKey:
+		return NewEd25519Key(id, private)
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+}
+
+// CanSign reports whether the key holds private material.
+func (k *Key) CanSign() bool {
+	return k.secret != nil || k.rsaPrivate != nil || k.edPrivate != nil
+}
+
+func (k *Key) sign(data []byte) ([]byte, error) {
+	switch {
+	case k.Algorithm == HS256 && k.secret != nil:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(data)
+		return mac.Sum(nil), nil
+	case k.Algorithm == RS256 && k.rsaPrivate != nil:
+		digest := sha256.Sum256(data)
+		return rsa.SignPKCS1v15(rand.Reader, k.rsaPrivate, crypto.SHA256, digest[:])
+	case k.Algorithm == EdDSA && k.edPrivate != nil:
+		return ed25519.Sign(k.edPrivate, data), nil
+	default:
+		return nil, errors.New("key cannot sign")
+	}
+}
+
+func (k *Key) verify(data, signature []byte) bool {
+	switch k.Algorithm {
+	case HS256:
+		if k.secret == nil {
+			return false
+		}
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(data)
+		return hmac.Equal(signature, mac.Sum(nil))
+	case RS256:
+		if k.rsaPublic == nil {
+			return false
+		}
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(k.rsaPublic, crypto.SHA256, digest[:], signature) == nil
+	case EdDSA:
+		if k.edPublic == nil {
+			return false
+		}
+		return ed25519.Verify(k.edPublic, data, signature)
+	default:
+		return false
+	}
+}
+
+// KeySet holds every key accepted for verification and the one used to sign
+// new tokens. Rotating keys means adding a new signing key while keeping the
+// previous one until the tokens it signed have expired.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	order   []*Key
+}
+
+// NewKeySet builds a key set signing with the key identified by signingKeyID.
+func NewKeySet(signingKeyID string, keys ...*Key) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*Key, len(keys))}
+	for _, key := range keys {
+		if key == nil {
+			continue
+		}
+		if _, exists := set.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		set.keys[key.ID] = key
+		set.order = append(set.order, key)
+	}
+
+	signing, ok := set.keys[signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q not found", signingKeyID)
+	}
+	if !signing.CanSign() {
+		return nil, fmt.Errorf("signing key %q has no private material", signingKeyID)
+	}
+	set.signing = signing
+	return set, nil
+}
+
+// NewVerifyingKeySet builds a key set that only verifies, for example from
+// an identity provider's published keys. Keys without an id are only tried
+// for tokens without a kid header.
+func NewVerifyingKeySet(keys ...*Key) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*Key, len(keys))}
+	for _, key := range keys {
+		if key == nil {
+			continue
+		}
+		if key.ID != "" {
+			if _, exists := set.keys[key.ID]; exists {
+				return nil, fmt.Errorf("duplicate key id %q", key.ID)
+			}
+			set.keys[key.ID] = key
+		}
+		set.order = append(set.order, key)
+	}
+	return set, nil
+}
+
+// SigningKeyID returns the kid stamped on newly issued tokens.
+func (s *KeySet) SigningKeyID() string {
+	if s.signing == nil {
+		return ""
+	}
+	return s.signing.ID
+}
+
+// candidates returns the keys that may have produced a token with the given
+// header. Tokens without a kid predate key ids and are tried against every
+// key of the matching algorithm.
+func (s *KeySet) candidates(alg Algorithm, kid string) []*Key {
+	if kid != "" {
+		key, ok := s.keys[kid]
+		if !ok || key.Algorithm != alg {
+			return nil
+		}
+		return []*Key{key}
+	}
+	var out []*Key
+	for _, key := range s.order {
+		if key.Algorithm == alg {
+			out = append(out, key)
+		}
+	}
+	return out
+}
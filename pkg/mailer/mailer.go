@@ -0,0 +1,160 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email messages.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPMailer delivers messages through an SMTP relay using PLAIN auth when
+// credentials are configured.
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer constructs an SMTP mailer for host:port.
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	m := &SMTPMailer{
+		addr: host + ":" + port,
+		from: from,
+	}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+// Send delivers the message.
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := render(m.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, data)
+}
+
+// FileMailer writes each message to its own .eml file in a directory instead
+// of sending it, for local development and tests.
+type FileMailer struct {
+	dir  string
+	from string
+	seq  atomic.Uint64
+}
+
+// NewFileMailer constructs a file mailer writing into dir, creating it if needed.
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+// Send writes the message to disk.
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	now := time.Now()
+	data, err := render(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%04d.eml", now.UTC().Format("20060102T150405.000000000"), m.seq.Add(1))
+	return os.WriteFile(filepath.Join(m.dir, name), data, 0o640)
+}
+
+// ErrQueueFull indicates a Queue has no room for another message.
+var ErrQueueFull = errors.New("mail queue full")
+
+// Queue hands messages to another Mailer from a background worker, so
+// callers do not wait on a slow relay and their response time does not show
+// whether a message was sent. Delivery errors go to the error callback.
+type Queue struct {
+	next    Mailer
+	msgs    chan Message
+	onError func(Message, error)
+	done    chan struct{}
+}
+
+// NewQueue starts a worker delivering through next with room for size
+// pending messages. onError may be nil.
+func NewQueue(next Mailer, size int, onError func(Message, error)) *Queue {
+	q := &Queue{
+		next:    next,
+		msgs:    make(chan Message, size),
+		onError: onError,
+		done:    make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Send enqueues the message, returning ErrQueueFull when no room is left.
+func (q *Queue) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case q.msgs <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close delivers the messages still queued and stops the worker. Send must
+// not be called afterwards.
+func (q *Queue) Close() {
+	close(q.msgs)
+	<-q.done
+}
+
+func (q *Queue) run() {
+	defer close(q.done)
+	for msg := range q.msgs {
+		if err := q.next.Send(context.Background(), msg); err != nil && q.onError != nil {
+			q.onError(msg, err)
+		}
+	}
+}
+
+func render(from string, msg Message, now time.Time) ([]byte, error) {
+	for _, value := range []string{from, msg.To, msg.Subject} {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, errors.New("mail header contains a line break")
+		}
+	}
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("Date: " + now.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String()), nil
+}
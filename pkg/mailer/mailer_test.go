@@ -0,0 +1,82 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFileMailerWritesMessage(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewFileMailer(dir, "noreply@example.com")
+	if err != nil {
+		t.Fatalf("new mailer: %v", err)
+	}
+
+	if err := m.Send(context.Background(), Message{To: "user@example.com", Subject: "Hello", Body: "line one\nline two"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one message file, got %d (err %v)", len(entries), err)
+	}
+	data, err := os.ReadFile(dir + "/" + entries[0].Name())
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{"To: user@example.com\r\n", "Subject: Hello\r\n", "line one\r\nline two"} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("expected %q in message:\n%s", want, content)
+		}
+	}
+}
+
+func TestRenderRejectsHeaderInjection(t *testing.T) {
+	m, err := NewFileMailer(t.TempDir(), "noreply@example.com")
+	if err != nil {
+		t.Fatalf("new mailer: %v", err)
+	}
+	err = m.Send(context.Background(), Message{To: "user@example.com\r\nBcc: victim@example.com", Subject: "x"})
+	if err == nil {
+		t.Fatal("expected header injection to be rejected")
+	}
+}
+
+type recordingMailer struct {
+	sent []Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg Message) error {
+	if msg.To == "bounce@example.com" {
+		return errors.New("rejected")
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestQueueDeliversInBackground(t *testing.T) {
+	next := &recordingMailer{}
+	var failed []string
+	q := NewQueue(next, 2, func(msg Message, err error) {
+		failed = append(failed, msg.To)
+	})
+
+	if err := q.Send(context.Background(), Message{To: "user@example.com"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if err := q.Send(context.Background(), Message{To: "bounce@example.com"}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	q.Close()
+
+	if len(next.sent) != 1 || next.sent[0].To != "user@example.com" {
+		t.Fatalf("expected one delivered message, got %+v", next.sent)
+	}
+	if len(failed) != 1 || failed[0] != "bounce@example.com" {
+		t.Fatalf("expected the failed delivery to be reported, got %v", failed)
+	}
+}
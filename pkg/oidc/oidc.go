@@ -0,0 +1,400 @@
+// Package oidc implements the relying-party side of the OpenID Connect
+// authorization code flow with PKCE: discovery, the token exchange and ID
+// token validation against the provider's published keys.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go-todo-service/pkg/jwt"
+)
+
+const (
+	// clockSkew is the leeway allowed when checking exp and iat.
+	clockSkew = time.Minute
+	// keyRefreshInterval is the minimum gap between JWKS fetches triggered by
+	// tokens signed with an unknown key.
+	keyRefreshInterval = time.Minute
+	// maxResponseBytes caps documents read from the provider.
+	maxResponseBytes = 1 << 20
+)
+
+var (
+	// ErrInvalidIDToken indicates an ID token with a bad signature or claims.
+	ErrInvalidIDToken = errors.New("invalid id token")
+	// ErrExchangeFailed indicates the provider refused the authorization code.
+	ErrExchangeFailed = errors.New("authorization code exchange failed")
+)
+
+// DefaultScopes are requested when Config.Scopes is empty.
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+// Config describes a client registered with an OpenID provider.
+type Config struct {
+	// IssuerURL is the provider's issuer identifier. The discovery document
+	// is loaded from IssuerURL + "/.well-known/openid-configuration" and must
+	// name the same issuer.
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// HTTPClient is used for requests to the provider. It defaults to a
+	// client with a ten second timeout.
+	HTTPClient *http.Client
+}
+
+// IDToken holds the validated claims of an ID token.
+type IDToken struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	ExpiresAt     time.Time
+}
+
+// Provider talks to one OpenID provider. The discovery document and keys are
+// loaded on first use so the service can start while the provider is down.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+	now    func() time.Time
+
+	mu            sync.Mutex
+	metadata      *metadata
+	keys          *jwt.KeySet
+	keysFetchedAt time.Time
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// NewProvider validates the configuration and returns a provider.
+func NewProvider(cfg Config) (*Provider, error) {
+	if cfg.IssuerURL == "" {
+		return nil, errors.New("oidc: issuer url is required")
+	}
+	if cfg.ClientID == "" {
+		return nil, errors.New("oidc: client id is required")
+	}
+	if cfg.RedirectURL == "" {
+		return nil, errors.New("oidc: redirect url is required")
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = DefaultScopes
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Provider{cfg: cfg, client: client, now: time.Now}, nil
+}
+
+// WithNow overrides the time source (primarily for testing).
+func (p *Provider) WithNow(fn func() time.Time) {
+	if fn != nil {
+		p.now = fn
+	}
+}
+
+// AuthCodeURL returns the provider URL the user is sent to. state and nonce
+// are echoed back in the redirect and the ID token; codeChallenge is the
+// S256 challenge of the verifier later passed to Exchange.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return meta.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems an authorization code together with the PKCE verifier
+// and returns the validated ID token, which must carry nonce.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*IDToken, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	if p.cfg.ClientSecret == "" {
+		form.Set("client_id", p.cfg.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%w: status %d", ErrExchangeFailed, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrExchangeFailed, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return nil, fmt.Errorf("%w: response has no id_token", ErrExchangeFailed)
+	}
+	return p.Verify(ctx, body.IDToken, nonce)
+}
+
+// Verify validates an ID token issued to this client: its signature against
+// the provider's keys, the issuer, audience, expiry and nonce.
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*IDToken, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := p.verifySignature(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims struct {
+		Issuer        string      `json:"iss"`
+		Subject       string      `json:"sub"`
+		Audience      audience    `json:"aud"`
+		AuthorizedFor string      `json:"azp"`
+		ExpiresAt     json.Number `json:"exp"`
+		IssuedAt      json.Number `json:"iat"`
+		Nonce         string      `json:"nonce"`
+		Email         string      `json:"email"`
+		EmailVerified flexBool    `json:"email_verified"`
+		Name          string      `json:"name"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidIDToken
+	}
+
+	switch {
+	case claims.Issuer != meta.Issuer:
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, claims.Issuer)
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	case !claims.Audience.contains(p.cfg.ClientID):
+		return nil, fmt.Errorf("%w: not issued to this client", ErrInvalidIDToken)
+	case len(claims.Audience) > 1 && claims.AuthorizedFor != p.cfg.ClientID:
+		return nil, fmt.Errorf("%w: not authorized for this client", ErrInvalidIDToken)
+	case nonce == "" || claims.Nonce != nonce:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	exp, err := claims.ExpiresAt.Int64()
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing expiry", ErrInvalidIDToken)
+	}
+	now := p.now()
+	expiresAt := time.Unix(exp, 0)
+	if !now.Before(expiresAt.Add(clockSkew)) {
+		return nil, fmt.Errorf("%w: expired", ErrInvalidIDToken)
+	}
+	if iat, err := claims.IssuedAt.Int64(); err == nil && time.Unix(iat, 0).After(now.Add(clockSkew)) {
+		return nil, fmt.Errorf("%w: issued in the future", ErrInvalidIDToken)
+	}
+
+	return &IDToken{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+		ExpiresAt:     expiresAt.UTC(),
+	}, nil
+}
+
+// S256Challenge derives the PKCE code challenge for a verifier.
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// verifySignature checks the token against the cached keys, fetching them
+// again when the token names a key the cache does not hold, as happens after
+// the provider rotates keys.
+func (p *Provider) verifySignature(ctx context.Context, rawIDToken string) ([]byte, error) {
+	keys, fetchedAt, err := p.cachedKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := jwt.Verify(rawIDToken, keys)
+	if err == nil {
+		return payload, nil
+	}
+	if p.now().Sub(fetchedAt) < keyRefreshInterval {
+		return nil, ErrInvalidIDToken
+	}
+	if keys, err = p.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+	if payload, err = jwt.Verify(rawIDToken, keys); err != nil {
+		return nil, ErrInvalidIDToken
+	}
+	return payload, nil
+}
+
+func (p *Provider) cachedKeys(ctx context.Context) (*jwt.KeySet, time.Time, error) {
+	p.mu.Lock()
+	keys, fetchedAt := p.keys, p.keysFetchedAt
+	p.mu.Unlock()
+	if keys != nil {
+		return keys, fetchedAt, nil
+	}
+	keys, err := p.refreshKeys(ctx)
+	return keys, p.now(), err
+}
+
+func (p *Provider) refreshKeys(ctx context.Context) (*jwt.KeySet, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var doc jwt.JWKS
+	if err := p.getJSON(ctx, meta.JWKSURI, &doc); err != nil {
+		return nil, fmt.Errorf("oidc: fetch keys: %w", err)
+	}
+	keys, err := doc.KeySet()
+	if err != nil {
+		return nil, fmt.Errorf("oidc: fetch keys: %w", err)
+	}
+	p.mu.Lock()
+	p.keys, p.keysFetchedAt = keys, p.now()
+	p.mu.Unlock()
+	return keys, nil
+}
+
+// discover loads the provider metadata once. Failures are not cached.
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	meta := p.metadata
+	p.mu.Unlock()
+	if meta != nil {
+		return meta, nil
+	}
+
+	var doc metadata
+	wellKnown := strings.TrimRight(p.cfg.IssuerURL, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, &doc); err != nil {
+		return nil, fmt.Errorf("oidc: discovery: %w", err)
+	}
+	if doc.Issuer != p.cfg.IssuerURL {
+		return nil, fmt.Errorf("oidc: discovery: issuer %q does not match %q", doc.Issuer, p.cfg.IssuerURL)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery: document is missing endpoints")
+	}
+
+	p.mu.Lock()
+	p.metadata = &doc
+	p.mu.Unlock()
+	return &doc, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, target string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(out)
+}
+
+// audience accepts the aud claim as a single string or a list.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a audience) contains(clientID string) bool {
+	for _, aud := range a {
+		if aud == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// flexBool accepts booleans and the strings "true" and "false", which some
+// providers send for email_verified.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var value bool
+	if err := json.Unmarshal(data, &value); err == nil {
+		*b = flexBool(value)
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*b = flexBool(strings.EqualFold(text, "true"))
+	return nil
+}
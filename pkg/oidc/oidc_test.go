@@ -0,0 +1,150 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-todo-service/pkg/oidc"
+	"go-todo-service/pkg/oidc/oidctest"
+)
+
+const redirectURL = "https://app.example.com/auth/oidc/callback"
+
+var alice = oidctest.Identity{Subject: "alice-1", Email: "alice@example.com", EmailVerified: true, Name: "Alice"}
+
+func newProvider(t *testing.T, idp *oidctest.Server) *oidc.Provider {
+	t.Helper()
+	provider, err := oidc.NewProvider(idp.Config(redirectURL))
+	if err != nil {
+		t.Fatalf("provider: %v", err)
+	}
+	return provider
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	for name, secret := range map[string]string{"confidential": "s3cret:&", "public": ""} {
+		t.Run(name, func(t *testing.T) {
+			idp := oidctest.NewServer(t, "todo-app", secret)
+			provider := newProvider(t, idp)
+			ctx := context.Background()
+
+			verifier := "verifier-with-enough-entropy-0123456789"
+			authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", oidc.S256Challenge(verifier))
+			if err != nil {
+				t.Fatalf("auth url: %v", err)
+			}
+			code, state := idp.Authorize(t, authURL, alice)
+			if state != "state-1" {
+				t.Fatalf("expected state to round-trip, got %q", state)
+			}
+
+			idToken, err := provider.Exchange(ctx, code, verifier, "nonce-1")
+			if err != nil {
+				t.Fatalf("exchange: %v", err)
+			}
+			if idToken.Issuer != idp.Issuer || idToken.Subject != "alice-1" || idToken.Email != "alice@example.com" ||
+				!idToken.EmailVerified || idToken.Name != "Alice" {
+				t.Fatalf("unexpected id token %+v", idToken)
+			}
+
+			if _, err := provider.Exchange(ctx, code, verifier, "nonce-1"); !errors.Is(err, oidc.ErrExchangeFailed) {
+				t.Fatalf("expected a used code to be refused, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExchangeRequiresMatchingVerifier(t *testing.T) {
+	idp := oidctest.NewServer(t, "todo-app", "secret")
+	provider := newProvider(t, idp)
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", oidc.S256Challenge("right-verifier"))
+	if err != nil {
+		t.Fatalf("auth url: %v", err)
+	}
+	code, _ := idp.Authorize(t, authURL, alice)
+	if _, err := provider.Exchange(ctx, code, "wrong-verifier", "nonce"); !errors.Is(err, oidc.ErrExchangeFailed) {
+		t.Fatalf("expected exchange failure, got %v", err)
+	}
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	idp := oidctest.NewServer(t, "todo-app", "secret")
+	other := oidctest.NewServer(t, "todo-app", "secret")
+	provider := newProvider(t, idp)
+	ctx := context.Background()
+	now := time.Now()
+
+	with := func(key string, value any) map[string]any {
+		claims := idp.Claims(alice, "nonce", now)
+		claims[key] = value
+		return claims
+	}
+	cases := map[string]string{
+		"wrong nonce":        idp.SignIDToken(t, with("nonce", "other")),
+		"wrong audience":     idp.SignIDToken(t, with("aud", "someone-else")),
+		"untrusted azp":      idp.SignIDToken(t, with("aud", []string{"todo-app", "someone-else"})),
+		"wrong issuer":       idp.SignIDToken(t, with("iss", "https://evil.example.com")),
+		"expired":            idp.SignIDToken(t, with("exp", now.Add(-time.Hour).Unix())),
+		"issued later":       idp.SignIDToken(t, with("iat", now.Add(time.Hour).Unix())),
+		"missing subject":    idp.SignIDToken(t, with("sub", "")),
+		"foreign signature":  other.SignIDToken(t, idp.Claims(alice, "nonce", now)),
+		"not a jwt":          "not-a-token",
+		"unsigned algorithm": "eyJhbGciOiJub25lIn0.eyJzdWIiOiJhbGljZS0xIn0.",
+	}
+	for name, raw := range cases {
+		if _, err := provider.Verify(ctx, raw, "nonce"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+			t.Errorf("%s: expected ErrInvalidIDToken, got %v", name, err)
+		}
+	}
+
+	multi := with("aud", []string{"todo-app", "someone-else"})
+	multi["azp"] = "todo-app"
+	if _, err := provider.Verify(ctx, idp.SignIDToken(t, multi), "nonce"); err != nil {
+		t.Fatalf("expected token authorized for this client to verify: %v", err)
+	}
+	claims := with("email_verified", "true")
+	idToken, err := provider.Verify(ctx, idp.SignIDToken(t, claims), "nonce")
+	if err != nil || !idToken.EmailVerified {
+		t.Fatalf("expected string email_verified to be accepted, got %+v, %v", idToken, err)
+	}
+}
+
+func TestVerifyRefetchesKeysAfterRotation(t *testing.T) {
+	idp := oidctest.NewServer(t, "todo-app", "secret")
+	provider := newProvider(t, idp)
+	ctx := context.Background()
+	now := time.Now()
+	provider.WithNow(func() time.Time { return now })
+
+	if _, err := provider.Verify(ctx, idp.SignIDToken(t, idp.Claims(alice, "nonce", now)), "nonce"); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	idp.RotateKey(t)
+	rotated := idp.SignIDToken(t, idp.Claims(alice, "nonce", now))
+	if _, err := provider.Verify(ctx, rotated, "nonce"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Fatalf("expected keys not to be refetched within the refresh interval, got %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := provider.Verify(ctx, rotated, "nonce"); err != nil {
+		t.Fatalf("expected rotated key to be fetched: %v", err)
+	}
+}
+
+func TestDiscoveryRequiresMatchingIssuer(t *testing.T) {
+	idp := oidctest.NewServer(t, "todo-app", "")
+	cfg := idp.Config(redirectURL)
+	cfg.IssuerURL += "/"
+	provider, err := oidc.NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("provider: %v", err)
+	}
+	if _, err := provider.AuthCodeURL(context.Background(), "s", "n", "c"); err == nil {
+		t.Fatal("expected discovery to refuse a different issuer")
+	}
+}
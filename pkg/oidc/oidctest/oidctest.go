@@ -0,0 +1,255 @@
+// Package oidctest provides an in-process OpenID provider for tests. It
+// serves discovery, the JWKS and the token endpoint, and stands in for the
+// user at the authorization endpoint via Authorize.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"go-todo-service/pkg/jwt"
+	"go-todo-service/pkg/oidc"
+)
+
+// Identity is the account the fake user signs in with.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Server is a fake OpenID provider.
+type Server struct {
+	// Issuer is the provider's issuer identifier and base URL.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+
+	srv  *httptest.Server
+	now  func() time.Time
+	mu   sync.Mutex
+	keys *jwt.KeySet
+	// codes maps issued authorization codes to the request they answer.
+	codes map[string]grant
+}
+
+type grant struct {
+	identity    Identity
+	redirectURI string
+	challenge   string
+	nonce       string
+}
+
+// NewServer starts a provider for one client and stops it when the test
+// ends. An empty clientSecret registers a public client.
+func NewServer(t testing.TB, clientID, clientSecret string) *Server {
+	t.Helper()
+	s := &Server{ClientID: clientID, ClientSecret: clientSecret, codes: make(map[string]grant), now: time.Now}
+	s.RotateKey(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("GET /jwks", s.jwks)
+	mux.HandleFunc("POST /token", s.token)
+	s.srv = httptest.NewServer(mux)
+	s.Issuer = s.srv.URL
+	t.Cleanup(s.srv.Close)
+	return s
+}
+
+// WithNow overrides the time stamped on issued ID tokens.
+func (s *Server) WithNow(fn func() time.Time) {
+	if fn != nil {
+		s.now = fn
+	}
+}
+
+// Config returns a client configuration for this provider.
+func (s *Server) Config(redirectURL string) oidc.Config {
+	return oidc.Config{
+		IssuerURL:    s.Issuer,
+		ClientID:     s.ClientID,
+		ClientSecret: s.ClientSecret,
+		RedirectURL:  redirectURL,
+		HTTPClient:   s.srv.Client(),
+	}
+}
+
+// RotateKey replaces the signing key. Tokens signed afterwards carry a kid
+// the relying party has not seen yet.
+func (s *Server) RotateKey(t testing.TB) {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	kid := randomString(t)
+	key, err := jwt.NewRSAKey(kid, private)
+	if err != nil {
+		t.Fatalf("rsa key: %v", err)
+	}
+	keys, err := jwt.NewKeySet(kid, key)
+	if err != nil {
+		t.Fatalf("key set: %v", err)
+	}
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+}
+
+// Authorize plays the user approving the request at authURL, as built by
+// oidc.Provider.AuthCodeURL, and returns the code and state the provider
+// would redirect back with.
+func (s *Server) Authorize(t testing.TB, authURL string, id Identity) (code, state string) {
+	t.Helper()
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse authorization url: %v", err)
+	}
+	query := parsed.Query()
+	switch {
+	case query.Get("response_type") != "code":
+		t.Fatalf("unexpected response_type %q", query.Get("response_type"))
+	case query.Get("client_id") != s.ClientID:
+		t.Fatalf("unexpected client_id %q", query.Get("client_id"))
+	case query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "":
+		t.Fatalf("authorization request without an S256 code challenge")
+	}
+
+	code = randomString(t)
+	s.mu.Lock()
+	s.codes[code] = grant{
+		identity:    id,
+		redirectURI: query.Get("redirect_uri"),
+		challenge:   query.Get("code_challenge"),
+		nonce:       query.Get("nonce"),
+	}
+	s.mu.Unlock()
+	return code, query.Get("state")
+}
+
+// SignIDToken signs arbitrary claims with the current key, for tests of
+// malformed or forged tokens.
+func (s *Server) SignIDToken(t testing.TB, claims map[string]any) string {
+	t.Helper()
+	s.mu.Lock()
+	keys := s.keys
+	s.mu.Unlock()
+	token, err := keys.SignPayload(claims)
+	if err != nil {
+		t.Fatalf("sign id token: %v", err)
+	}
+	return token
+}
+
+// Claims returns the ID token claims the provider issues for id.
+func (s *Server) Claims(id Identity, nonce string, now time.Time) map[string]any {
+	return map[string]any{
+		"iss":            s.Issuer,
+		"sub":            id.Subject,
+		"aud":            s.ClientID,
+		"exp":            now.Add(time.Hour).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          nonce,
+		"email":          id.Email,
+		"email_verified": id.EmailVerified,
+		"name":           id.Name,
+	}
+}
+
+func (s *Server) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.Issuer,
+		"authorization_endpoint":                s.Issuer + "/authorize",
+		"token_endpoint":                        s.Issuer + "/token",
+		"jwks_uri":                              s.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Server) jwks(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	keys := s.keys
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, keys.JWKS())
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+	clientID, secret, basic := r.BasicAuth()
+	if basic {
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID = r.PostForm.Get("client_id")
+	}
+	if clientID != s.ClientID || secret != s.ClientSecret {
+		tokenError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	s.mu.Lock()
+	g, ok := s.codes[code]
+	// Codes work once.
+	delete(s.codes, code)
+	s.mu.Unlock()
+	if !ok || g.redirectURI != r.PostForm.Get("redirect_uri") ||
+		oidc.S256Challenge(r.PostForm.Get("code_verifier")) != g.challenge {
+		tokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	s.mu.Lock()
+	keys := s.keys
+	s.mu.Unlock()
+	idToken, err := keys.SignPayload(s.Claims(g.identity, g.nonce, s.now()))
+	if err != nil {
+		tokenError(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "unused",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func tokenError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func randomString(t testing.TB) string {
+	t.Helper()
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		t.Fatalf("random: %v", err)
+	}
+	return hex.EncodeToString(buf)
+}
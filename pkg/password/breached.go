@@ -0,0 +1,62 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RangeDir checks passwords against SHA-1 hashes stored in the layout of
+// the Pwned Passwords range API: one file per five-character hex prefix
+// (e.g. 21BD1.txt), each line holding the remaining 35 characters and an
+// optional ":count". Only the file for the password's prefix is read, so the
+// corpus can be far larger than memory.
+type RangeDir struct {
+	dir string
+}
+
+// NewRangeDir validates that dir exists and returns a checker for it.
+func NewRangeDir(dir string) (*RangeDir, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return &RangeDir{dir: dir}, nil
+}
+
+// Contains reports whether the password's SHA-1 digest is listed.
+func (d *RangeDir) Contains(plain string) (bool, error) {
+	sum := sha1.Sum([]byte(plain))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := digest[:5], digest[5:]
+
+	file, err := os.Open(filepath.Join(d.dir, prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		file, err = os.Open(filepath.Join(d.dir, prefix))
+	}
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(line, suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
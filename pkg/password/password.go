@@ -1,30 +1,166 @@
+// Package password hashes and verifies user passwords. New hashes use
+// argon2id in the PHC string format; bcrypt hashes created before the switch
+// still verify and are reported by NeedsRehash so callers can upgrade them.
 package password
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// Hash returns a bcrypt hash for the provided plaintext password.
+// ErrUnsupportedHash indicates a stored hash in an unknown or malformed format.
+var ErrUnsupportedHash = errors.New("unsupported password hash format")
+
+const (
+	argon2idPrefix = "$argon2id$"
+	saltLength     = 16
+	keyLength      = 32
+)
+
+// Params are the argon2id cost parameters.
+type Params struct {
+	// Memory in KiB.
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// DefaultParams follows the OWASP baseline for argon2id: 19 MiB, two
+// iterations, one lane.
+func DefaultParams() Params {
+	return Params{Memory: 19 * 1024, Iterations: 2, Parallelism: 1}
+}
+
+// Validate reports parameters argon2 cannot use.
+func (p Params) Validate() error {
+	if p.Memory < 8*uint32(p.Parallelism) || p.Iterations < 1 || p.Parallelism < 1 {
+		return errors.New("argon2id parameters require iterations >= 1, parallelism >= 1 and memory >= 8 KiB per lane")
+	}
+	return nil
+}
+
+// Hasher creates argon2id hashes with fixed parameters.
+type Hasher struct {
+	params Params
+}
+
+// NewHasher constructs a Hasher after validating the parameters.
+func NewHasher(params Params) (*Hasher, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &Hasher{params: params}, nil
+}
+
+var defaultHasher = &Hasher{params: DefaultParams()}
+
+// DefaultHasher returns the Hasher used by the package-level functions.
+func DefaultHasher() *Hasher {
+	return defaultHasher
+}
+
+// Hash returns an argon2id hash with the default parameters.
 func Hash(plain string) (string, error) {
+	return defaultHasher.Hash(plain)
+}
+
+// Compare verifies a password against an argon2id or bcrypt hash.
+func Compare(hashed, plain string) (bool, error) {
+	return defaultHasher.Compare(hashed, plain)
+}
+
+// NeedsRehash reports whether the hash differs from what Hash produces.
+func NeedsRehash(hashed string) bool {
+	return defaultHasher.NeedsRehash(hashed)
+}
+
+// Hash returns a PHC-formatted argon2id hash of the password with a random salt.
+func (h *Hasher) Hash(plain string) (string, error) {
 	if len(plain) == 0 {
 		return "", errors.New("empty password")
 	}
-	hashed, err := bcrypt.GenerateFromPassword([]byte(plain), bcrypt.DefaultCost)
-	if err != nil {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
 		return "", err
 	}
-	return string(hashed), nil
+	key := argon2.IDKey([]byte(plain), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, keyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		h.params.Memory,
+		h.params.Iterations,
+		h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
 }
 
-// Compare verifies that the provided password matches the stored bcrypt hash.
-func Compare(hashed, plain string) (bool, error) {
-	if err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte(plain)); err != nil {
-		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-			return false, nil
+// Compare verifies that the password matches the stored hash. The hash's own
+// parameters are used, so hashes made with older settings keep working.
+func (h *Hasher) Compare(hashed, plain string) (bool, error) {
+	if isBcrypt(hashed) {
+		if err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte(plain)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, nil
+			}
+			return false, err
 		}
+		return true, nil
+	}
+
+	params, salt, key, err := decodeArgon2id(hashed)
+	if err != nil {
 		return false, err
 	}
-	return true, nil
+	candidate := argon2.IDKey([]byte(plain), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(candidate, key) == 1, nil
+}
+
+// NeedsRehash reports whether the hash uses another algorithm or other
+// parameters than the Hasher and should be replaced after a successful login.
+func (h *Hasher) NeedsRehash(hashed string) bool {
+	params, salt, key, err := decodeArgon2id(hashed)
+	if err != nil {
+		return true
+	}
+	return params != h.params || len(salt) != saltLength || len(key) != keyLength
+}
+
+func isBcrypt(hashed string) bool {
+	return strings.HasPrefix(hashed, "$2a$") || strings.HasPrefix(hashed, "$2b$") || strings.HasPrefix(hashed, "$2y$")
+}
+
+// decodeArgon2id parses $argon2id$v=19$m=...,t=...,p=...$salt$key.
+func decodeArgon2id(hashed string) (Params, []byte, []byte, error) {
+	parts := strings.Split(hashed, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return Params{}, nil, nil, ErrUnsupportedHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Params{}, nil, nil, ErrUnsupportedHash
+	}
+	var params Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return Params{}, nil, nil, ErrUnsupportedHash
+	}
+	if params.Validate() != nil {
+		return Params{}, nil, nil, ErrUnsupportedHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(salt) == 0 {
+		return Params{}, nil, nil, ErrUnsupportedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Params{}, nil, nil, ErrUnsupportedHash
+	}
+	return params, salt, key, nil
 }
@@ -1,6 +1,11 @@
 package password
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
 
 func TestHashAndCompare(t *testing.T) {
 	hash, err := Hash("supersafe")
@@ -39,3 +44,73 @@ func TestHashEmptyPassword(t *testing.T) {
 		t.Fatal("expected error for empty password")
 	}
 }
+
+func TestHashUsesArgon2idPHC(t *testing.T) {
+	hash, err := Hash("supersafe")
+	if err != nil {
+		t.Fatalf("hash error: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Fatalf("expected argon2id PHC string, got %s", hash)
+	}
+	if defaultHasher.NeedsRehash(hash) {
+		t.Fatal("expected fresh hash not to need rehashing")
+	}
+}
+
+func TestCompareLegacyBcrypt(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("supersafe"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt: %v", err)
+	}
+	ok, err := Compare(string(legacy), "supersafe")
+	if err != nil || !ok {
+		t.Fatalf("expected bcrypt hash to verify, got %v (err %v)", ok, err)
+	}
+	if ok, _ := Compare(string(legacy), "wrong"); ok {
+		t.Fatal("expected bcrypt mismatch")
+	}
+	if !defaultHasher.NeedsRehash(string(legacy)) {
+		t.Fatal("expected bcrypt hash to need rehashing")
+	}
+}
+
+func TestNeedsRehashOnParameterChange(t *testing.T) {
+	weak, err := NewHasher(Params{Memory: 64, Iterations: 1, Parallelism: 1})
+	if err != nil {
+		t.Fatalf("hasher: %v", err)
+	}
+	hash, err := weak.Hash("supersafe")
+	if err != nil {
+		t.Fatalf("hash error: %v", err)
+	}
+	if ok, err := Compare(hash, "supersafe"); err != nil || !ok {
+		t.Fatalf("expected hash with other parameters to verify, got %v (err %v)", ok, err)
+	}
+	if !defaultHasher.NeedsRehash(hash) {
+		t.Fatal("expected parameter change to require rehash")
+	}
+	if weak.NeedsRehash(hash) {
+		t.Fatal("expected matching parameters not to require rehash")
+	}
+}
+
+func TestLongPasswordsAreNotTruncated(t *testing.T) {
+	long := strings.Repeat("a", 100)
+	hash, err := Hash(long)
+	if err != nil {
+		t.Fatalf("hash error: %v", err)
+	}
+	if ok, _ := Compare(hash, long[:72]); ok {
+		t.Fatal("expected bytes beyond 72 to matter")
+	}
+}
+
+func TestCompareRejectsUnknownFormat(t *testing.T) {
+	if _, err := Compare("$argon2i$v=19$m=64,t=1,p=1$c2FsdA$a2V5", "x"); err != ErrUnsupportedHash {
+		t.Fatalf("expected ErrUnsupportedHash, got %v", err)
+	}
+	if _, err := NewHasher(Params{Memory: 64, Iterations: 0, Parallelism: 1}); err == nil {
+		t.Fatal("expected invalid parameters to be rejected")
+	}
+}
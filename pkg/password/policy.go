@@ -0,0 +1,110 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrPolicyViolation is matched by every *PolicyError.
+var ErrPolicyViolation = errors.New("password does not meet the policy")
+
+// Violation codes returned in PolicyError.
+const (
+	ViolationTooShort      = "too_short"
+	ViolationTooLong       = "too_long"
+	ViolationContainsEmail = "contains_email"
+	ViolationBreached      = "breached"
+)
+
+// Violation is one reason a password was rejected, suitable for display.
+type Violation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PolicyError lists every rule a password failed.
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return "password rejected: " + strings.Join(messages, "; ")
+}
+
+// Unwrap lets errors.Is match ErrPolicyViolation.
+func (e *PolicyError) Unwrap() error {
+	return ErrPolicyViolation
+}
+
+// BreachedChecker reports whether a password appears in a breach corpus.
+type BreachedChecker interface {
+	Contains(plain string) (bool, error)
+}
+
+// Policy describes which passwords are acceptable. Lengths count characters,
+// not bytes.
+type Policy struct {
+	MinLength int
+	// MaxLength bounds hashing cost; zero disables the limit.
+	MaxLength int
+	// Breached is optional.
+	Breached BreachedChecker
+}
+
+// DefaultPolicy returns the length limits used when nothing is configured.
+func DefaultPolicy() Policy {
+	return Policy{MinLength: 6, MaxLength: 128}
+}
+
+// Check validates plain for the account identified by email. It returns a
+// *PolicyError describing every failed rule, or another error if the
+// breached-password lookup fails.
+func (p Policy) Check(plain, email string) error {
+	var violations []Violation
+	length := utf8.RuneCountInString(plain)
+	if length < p.MinLength {
+		violations = append(violations, Violation{
+			Code:    ViolationTooShort,
+			Message: fmt.Sprintf("must be at least %d characters", p.MinLength),
+		})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, Violation{
+			Code:    ViolationTooLong,
+			Message: fmt.Sprintf("must be at most %d characters", p.MaxLength),
+		})
+	}
+	if local := emailLocalPart(email); len(local) >= 3 && strings.Contains(strings.ToLower(plain), local) {
+		violations = append(violations, Violation{
+			Code:    ViolationContainsEmail,
+			Message: "must not contain your email address",
+		})
+	}
+	if p.Breached != nil && plain != "" {
+		breached, err := p.Breached.Contains(plain)
+		if err != nil {
+			return err
+		}
+		if breached {
+			violations = append(violations, Violation{
+				Code:    ViolationBreached,
+				Message: "appears in a list of breached or common passwords",
+			})
+		}
+	}
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+func emailLocalPart(email string) string {
+	local, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+	return local
+}
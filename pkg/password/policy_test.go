@@ -0,0 +1,72 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func violationCodes(t *testing.T, err error) []string {
+	t.Helper()
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("expected PolicyError, got %v", err)
+	}
+	if !errors.Is(err, ErrPolicyViolation) {
+		t.Fatal("expected PolicyError to match ErrPolicyViolation")
+	}
+	codes := make([]string, len(policyErr.Violations))
+	for i, v := range policyErr.Violations {
+		codes[i] = v.Code
+	}
+	return codes
+}
+
+func TestPolicyCheck(t *testing.T) {
+	policy := Policy{MinLength: 10, MaxLength: 20}
+
+	if err := policy.Check("correct horse", "user@example.com"); err != nil {
+		t.Fatalf("expected acceptable password, got %v", err)
+	}
+	if codes := violationCodes(t, policy.Check("Jane.Doe1", "jane.doe@example.com")); !reflect.DeepEqual(codes, []string{ViolationTooShort, ViolationContainsEmail}) {
+		t.Fatalf("unexpected violations %v", codes)
+	}
+	if codes := violationCodes(t, policy.Check(strings.Repeat("x", 21), "user@example.com")); !reflect.DeepEqual(codes, []string{ViolationTooLong}) {
+		t.Fatalf("unexpected violations %v", codes)
+	}
+	if err := policy.Check("ünïcødé pass", "ab@example.com"); err != nil {
+		t.Fatalf("expected characters to be counted, not bytes, and short local parts ignored: %v", err)
+	}
+}
+
+func TestRangeDirBreachedCheck(t *testing.T) {
+	dir := t.TempDir()
+	sum := sha1.Sum([]byte("P@ssw0rd123"))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	content := "0018A45C4D1DEF81644B54AB7F969B88D65:1\n" + digest[5:] + ":52579\n"
+	if err := os.WriteFile(filepath.Join(dir, digest[:5]+".txt"), []byte(content), 0o600); err != nil {
+		t.Fatalf("write range file: %v", err)
+	}
+
+	breached, err := NewRangeDir(dir)
+	if err != nil {
+		t.Fatalf("range dir: %v", err)
+	}
+	policy := Policy{MinLength: 8, Breached: breached}
+
+	if codes := violationCodes(t, policy.Check("P@ssw0rd123", "user@example.com")); !reflect.DeepEqual(codes, []string{ViolationBreached}) {
+		t.Fatalf("unexpected violations %v", codes)
+	}
+	if err := policy.Check("P@ssw0rd124", "user@example.com"); err != nil {
+		t.Fatalf("expected unlisted password to pass, got %v", err)
+	}
+
+	if _, err := NewRangeDir(filepath.Join(dir, "missing")); err == nil {
+		t.Fatal("expected missing directory to be rejected")
+	}
+}
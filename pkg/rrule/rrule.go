@@ -0,0 +1,376 @@
+// Package rrule parses and evaluates the subset of RFC 5545 recurrence rules
+// used for repeating tasks: FREQ (DAILY, WEEKLY, MONTHLY or YEARLY),
+// INTERVAL, COUNT, UNTIL, BYDAY and WKST.
+//
+// Occurrences are computed on the wall clock of the series start, so a task
+// due at 09:00 stays at 09:00 local time across daylight saving changes.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRule indicates a rule that cannot be parsed or uses an
+// unsupported part. Errors returned by Parse wrap it with the reason.
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// Frequency is the base unit a rule repeats in.
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxPeriods bounds how many periods Next inspects, so that rules which
+// rarely match, such as every 29 February, still terminate.
+const maxPeriods = 10000
+
+// Weekday is a BYDAY entry. N selects the Nth such weekday of the month or
+// year, counting from the end when negative; zero selects every one.
+type Weekday struct {
+	N   int
+	Day time.Weekday
+}
+
+var dayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+func dayCode(day time.Weekday) string {
+	return strings.ToUpper(day.String()[:2])
+}
+
+// untilKind records how UNTIL was written so String can reproduce it.
+type untilKind int
+
+const (
+	untilNone untilKind = iota
+	// untilDate is a calendar date, inclusive, in the series' time zone.
+	untilDate
+	// untilLocal is a wall clock time in the series' time zone.
+	untilLocal
+	// untilUTC is an absolute instant.
+	untilUTC
+)
+
+// Rule is a parsed recurrence rule.
+type Rule struct {
+	Freq Frequency
+	// Interval is the number of periods between repetitions, at least 1.
+	Interval int
+	// Count limits the series to its first Count occurrences, the series
+	// start being the first. Zero means unbounded.
+	Count int
+	ByDay []Weekday
+	// WeekStart is the first day of the week for WEEKLY rules, Monday by
+	// default.
+	WeekStart time.Weekday
+	// until holds UNTIL as a wall clock in UTC for date and local forms and
+	// as an instant for the UTC form.
+	until     time.Time
+	untilKind untilKind
+}
+
+// Parse reads a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE". An
+// optional "RRULE:" prefix is accepted and names are case-insensitive.
+func Parse(value string) (*Rule, error) {
+	value = strings.TrimSpace(value)
+	if len(value) >= 6 && strings.EqualFold(value[:6], "RRULE:") {
+		value = value[6:]
+	}
+	if value == "" {
+		return nil, fmt.Errorf("%w: rule is empty", ErrInvalidRule)
+	}
+
+	rule := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(value, ";") {
+		name, val, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		val = strings.ToUpper(strings.TrimSpace(val))
+		if !ok || name == "" || val == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: %s given more than once", ErrInvalidRule, name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			rule.Freq = Frequency(val)
+			switch rule.Freq {
+			case Daily, Weekly, Monthly, Yearly:
+			default:
+				err = errors.New("FREQ must be one of DAILY, WEEKLY, MONTHLY, YEARLY")
+			}
+		case "INTERVAL":
+			if rule.Interval, err = strconv.Atoi(val); err != nil || rule.Interval < 1 {
+				err = errors.New("INTERVAL must be a positive integer")
+			}
+		case "COUNT":
+			if rule.Count, err = strconv.Atoi(val); err != nil || rule.Count < 1 {
+				err = errors.New("COUNT must be a positive integer")
+			}
+		case "UNTIL":
+			err = rule.parseUntil(val)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+		case "WKST":
+			day, ok := dayCodes[val]
+			if !ok {
+				err = errors.New("WKST must be a weekday such as MO")
+			}
+			rule.WeekStart = day
+		default:
+			err = fmt.Errorf("%s is not supported", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if rule.Count > 0 && rule.untilKind != untilNone {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL cannot be combined", ErrInvalidRule)
+	}
+	for _, day := range rule.ByDay {
+		switch {
+		case day.N == 0:
+		case rule.Freq == Daily || rule.Freq == Weekly:
+			return nil, fmt.Errorf("%w: BYDAY ordinals such as %dMO need FREQ=MONTHLY or YEARLY", ErrInvalidRule, day.N)
+		case rule.Freq == Monthly && (day.N < -5 || day.N > 5):
+			return nil, fmt.Errorf("%w: monthly BYDAY ordinals range from -5 to 5", ErrInvalidRule)
+		}
+	}
+	return rule, nil
+}
+
+func (r *Rule) parseUntil(value string) error {
+	var err error
+	switch {
+	case len(value) == len("20060102"):
+		r.until, err = time.Parse("20060102", value)
+		r.untilKind = untilDate
+	case strings.HasSuffix(value, "Z"):
+		r.until, err = time.Parse("20060102T150405Z", value)
+		r.untilKind = untilUTC
+	default:
+		r.until, err = time.Parse("20060102T150405", value)
+		r.untilKind = untilLocal
+	}
+	if err != nil {
+		return errors.New("UNTIL must be a date such as 20261231 or a time such as 20261231T170000Z")
+	}
+	return nil
+}
+
+func parseByDay(value string) ([]Weekday, error) {
+	var days []Weekday
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) < 2 {
+			return nil, fmt.Errorf("unknown BYDAY value %q", item)
+		}
+		day, ok := dayCodes[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("unknown BYDAY value %q", item)
+		}
+		var n int
+		if ordinal := item[:len(item)-2]; ordinal != "" {
+			var err error
+			n, err = strconv.Atoi(ordinal)
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("unknown BYDAY value %q", item)
+			}
+		}
+		weekday := Weekday{N: n, Day: day}
+		if !slices.Contains(days, weekday) {
+			days = append(days, weekday)
+		}
+	}
+	return days, nil
+}
+
+// String formats the rule in canonical form, without the "RRULE:" prefix.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = dayCode(day.Day)
+			if day.N != 0 {
+				days[i] = strconv.Itoa(day.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+dayCode(r.WeekStart))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	switch r.untilKind {
+	case untilDate:
+		parts = append(parts, "UNTIL="+r.until.Format("20060102"))
+	case untilLocal:
+		parts = append(parts, "UNTIL="+r.until.Format("20060102T150405"))
+	case untilUTC:
+		parts = append(parts, "UNTIL="+r.until.Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence of the series beginning at start that
+// falls strictly after after, or false when the series has ended by then.
+// Occurrences share start's location and wall clock time of day; a time that
+// does not exist on some day because of a daylight saving change is
+// normalised by time.Date.
+func (r *Rule) Next(start, after time.Time) (time.Time, bool) {
+	loc := start.Location()
+	hour, minute, second := start.Clock()
+	at := func(date time.Time) time.Time {
+		y, m, d := date.Date()
+		return time.Date(y, m, d, hour, minute, second, start.Nanosecond(), loc)
+	}
+	startDate := dateOf(start)
+
+	if start.After(after) {
+		return start, r.withinUntil(start)
+	}
+	// start is the first occurrence whether or not it matches the rule.
+	seen := 1
+
+	for period := 0; period < maxPeriods; period++ {
+		for _, date := range r.candidates(startDate, period*r.Interval) {
+			if !date.After(startDate) {
+				continue
+			}
+			occurrence := at(date)
+			if !r.withinUntil(occurrence) {
+				return time.Time{}, false
+			}
+			seen++
+			if r.Count > 0 && seen > r.Count {
+				return time.Time{}, false
+			}
+			if occurrence.After(after) {
+				return occurrence, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// withinUntil reports whether occurrence is not past UNTIL.
+func (r *Rule) withinUntil(occurrence time.Time) bool {
+	switch r.untilKind {
+	case untilDate:
+		return !dateOf(occurrence).After(r.until)
+	case untilLocal:
+		y, m, d := occurrence.Date()
+		hour, minute, second := occurrence.Clock()
+		wall := time.Date(y, m, d, hour, minute, second, occurrence.Nanosecond(), time.UTC)
+		return !wall.After(r.until)
+	case untilUTC:
+		return !occurrence.After(r.until)
+	}
+	return true
+}
+
+// candidates lists, in order, the dates the rule selects in the period
+// offset periods after the one containing start. Dates are midnight UTC.
+func (r *Rule) candidates(start time.Time, offset int) []time.Time {
+	y, m, d := start.Date()
+	switch r.Freq {
+	case Daily:
+		date := time.Date(y, m, d+offset, 0, 0, 0, 0, time.UTC)
+		if len(r.ByDay) > 0 && !slices.ContainsFunc(r.ByDay, func(day Weekday) bool { return day.Day == date.Weekday() }) {
+			return nil
+		}
+		return []time.Time{date}
+	case Weekly:
+		first := d - (int(start.Weekday())-int(r.WeekStart)+7)%7 + 7*offset
+		var dates []time.Time
+		for i := range 7 {
+			date := time.Date(y, m, first+i, 0, 0, 0, 0, time.UTC)
+			if (len(r.ByDay) == 0 && date.Weekday() == start.Weekday()) ||
+				slices.ContainsFunc(r.ByDay, func(day Weekday) bool { return day.Day == date.Weekday() }) {
+				dates = append(dates, date)
+			}
+		}
+		return dates
+	case Monthly:
+		first := time.Date(y, m+time.Month(offset), 1, 0, 0, 0, 0, time.UTC)
+		if len(r.ByDay) > 0 {
+			return r.expandByDay(first, first.AddDate(0, 1, 0))
+		}
+		return dayIfValid(first.Year(), first.Month(), d)
+	case Yearly:
+		first := time.Date(y+offset, time.January, 1, 0, 0, 0, 0, time.UTC)
+		if len(r.ByDay) > 0 {
+			return r.expandByDay(first, first.AddDate(1, 0, 0))
+		}
+		return dayIfValid(first.Year(), m, d)
+	}
+	return nil
+}
+
+// expandByDay selects the BYDAY dates in [first, end).
+func (r *Rule) expandByDay(first, end time.Time) []time.Time {
+	var dates []time.Time
+	for _, day := range r.ByDay {
+		var matches []time.Time
+		offset := (int(day.Day) - int(first.Weekday()) + 7) % 7
+		for date := first.AddDate(0, 0, offset); date.Before(end); date = date.AddDate(0, 0, 7) {
+			matches = append(matches, date)
+		}
+		switch {
+		case day.N == 0:
+			dates = append(dates, matches...)
+		case day.N > 0 && day.N <= len(matches):
+			dates = append(dates, matches[day.N-1])
+		case day.N < 0 && -day.N <= len(matches):
+			dates = append(dates, matches[len(matches)+day.N])
+		}
+	}
+	slices.SortFunc(dates, func(a, b time.Time) int { return a.Compare(b) })
+	return slices.Compact(dates)
+}
+
+// dayIfValid returns the date when the month has such a day, so that rules
+// starting on the 31st skip shorter months as RFC 5545 requires.
+func dayIfValid(year int, month time.Month, day int) []time.Time {
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if date.Month() != month {
+		return nil
+	}
+	return []time.Time{date}
+}
+
+// dateOf returns t's calendar date, in t's location, as midnight UTC.
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
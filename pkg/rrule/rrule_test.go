@@ -0,0 +1,166 @@
+package rrule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseNormalisesRule(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"RRULE:freq=weekly;byday=mo,we;interval=2", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"},
+		{"FREQ=MONTHLY;BYDAY=-1FR;COUNT=6", "FREQ=MONTHLY;BYDAY=-1FR;COUNT=6"},
+		{"FREQ=YEARLY;INTERVAL=1;UNTIL=20301231", "FREQ=YEARLY;UNTIL=20301231"},
+		{"FREQ=WEEKLY;WKST=SU;UNTIL=20261231T170000Z", "FREQ=WEEKLY;WKST=SU;UNTIL=20261231T170000Z"},
+	}
+	for _, tc := range cases {
+		rule, err := Parse(tc.in)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.in, err)
+		}
+		if got := rule.String(); got != tc.want {
+			t.Errorf("%s: expected %s, got %s", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestParseRejectsInvalidRules(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;COUNT=3;UNTIL=20301231",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYMONTHDAY=1",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ",
+	} {
+		if _, err := Parse(in); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("%q: expected ErrInvalidRule, got %v", in, err)
+		}
+	}
+}
+
+// occurrences lists the first n occurrences after start.
+func occurrences(t *testing.T, rule string, start time.Time, n int) []time.Time {
+	t.Helper()
+	parsed, err := Parse(rule)
+	if err != nil {
+		t.Fatalf("parse %s: %v", rule, err)
+	}
+	var got []time.Time
+	after := start
+	for range n {
+		next, ok := parsed.Next(start, after)
+		if !ok {
+			break
+		}
+		got = append(got, next)
+		after = next
+	}
+	return got
+}
+
+func dates(times []time.Time) []string {
+	out := make([]string, len(times))
+	for i, t := range times {
+		out[i] = t.Format("2006-01-02")
+	}
+	return out
+}
+
+func TestNextExpandsFrequencies(t *testing.T) {
+	cases := []struct {
+		name  string
+		rule  string
+		start string
+		want  []string
+	}{
+		{"every other day", "FREQ=DAILY;INTERVAL=2", "2026-01-30", []string{"2026-02-01", "2026-02-03", "2026-02-05"}},
+		{"weekdays", "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", "2026-10-16", []string{"2026-10-19", "2026-10-20", "2026-10-21"}},
+		{"weekly on start day", "FREQ=WEEKLY", "2026-10-14", []string{"2026-10-21", "2026-10-28", "2026-11-04"}},
+		{"fortnightly mon and thu", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", "2026-10-13", []string{"2026-10-15", "2026-10-26", "2026-10-29"}},
+		{"monthly on the 31st", "FREQ=MONTHLY", "2026-01-31", []string{"2026-03-31", "2026-05-31", "2026-07-31"}},
+		{"last friday", "FREQ=MONTHLY;BYDAY=-1FR", "2026-10-01", []string{"2026-10-30", "2026-11-27", "2026-12-25"}},
+		{"second tuesday quarterly", "FREQ=MONTHLY;INTERVAL=3;BYDAY=2TU", "2026-01-13", []string{"2026-04-14", "2026-07-14", "2026-10-13"}},
+		{"leap day", "FREQ=YEARLY", "2024-02-29", []string{"2028-02-29", "2032-02-29"}},
+		{"first monday of the year", "FREQ=YEARLY;BYDAY=1MO", "2026-01-05", []string{"2027-01-04", "2028-01-03"}},
+	}
+	for _, tc := range cases {
+		start, _ := time.Parse("2006-01-02", tc.start)
+		got := dates(occurrences(t, tc.rule, start, len(tc.want)))
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+				break
+			}
+		}
+	}
+}
+
+func TestNextHonoursCountAndUntil(t *testing.T) {
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	// The start is the first of the three occurrences.
+	if got := occurrences(t, "FREQ=DAILY;COUNT=3", start, 10); len(got) != 2 {
+		t.Fatalf("expected 2 occurrences after the start, got %v", got)
+	}
+	if got := dates(occurrences(t, "FREQ=DAILY;UNTIL=20261004", start, 10)); len(got) != 3 || got[2] != "2026-10-04" {
+		t.Fatalf("expected the UNTIL date to be inclusive, got %v", got)
+	}
+	if got := occurrences(t, "FREQ=DAILY;UNTIL=20261003T085959Z", start, 10); len(got) != 1 {
+		t.Fatalf("expected UNTIL to cut the series before 09:00 on the 3rd, got %v", got)
+	}
+
+	rule, _ := Parse("FREQ=WEEKLY;COUNT=2")
+	if _, ok := rule.Next(start, start.AddDate(0, 1, 0)); ok {
+		t.Fatalf("expected the series to have ended")
+	}
+}
+
+func TestNextSkipsToTheFirstOccurrenceAfter(t *testing.T) {
+	rule, _ := Parse("FREQ=WEEKLY;BYDAY=MO")
+	start := time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC)
+	next, ok := rule.Next(start, time.Date(2026, 10, 21, 12, 0, 0, 0, time.UTC))
+	if !ok || !next.Equal(time.Date(2026, 10, 26, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected 2026-10-26 09:00, got %v %v", next, ok)
+	}
+	if next, _ := rule.Next(start, start.Add(-time.Hour)); !next.Equal(start) {
+		t.Fatalf("expected the start itself, got %v", next)
+	}
+}
+
+func TestNextKeepsWallClockAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	// Clocks go back on 1 November 2026 and forward on 14 March 2027.
+	start := time.Date(2026, 10, 30, 9, 0, 0, 0, loc)
+	got := occurrences(t, "FREQ=WEEKLY", start, 1)
+	if len(got) != 1 || got[0].Hour() != 9 || !got[0].Equal(time.Date(2026, 11, 6, 14, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected 09:00 EST (14:00 UTC), got %v", got)
+	}
+
+	start = time.Date(2027, 3, 13, 8, 30, 0, 0, loc)
+	got = occurrences(t, "FREQ=DAILY", start, 1)
+	if len(got) != 1 || !got[0].Equal(time.Date(2027, 3, 14, 12, 30, 0, 0, time.UTC)) {
+		t.Fatalf("expected 08:30 EDT (12:30 UTC), got %v", got)
+	}
+	if elapsed := got[0].Sub(start); elapsed != 23*time.Hour {
+		t.Fatalf("expected a 23 hour day, got %v", elapsed)
+	}
+}
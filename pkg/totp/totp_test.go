@@ -0,0 +1,73 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 seed "12345678901234567890" from RFC 6238 appendix B.
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestRFC6238Vectors(t *testing.T) {
+	key, err := decodeSecret(rfcSecret)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tc := range cases {
+		if got := generate(key, Step(time.Unix(tc.unix, 0)), 8); got != tc.want {
+			t.Errorf("t=%d: expected %s, got %s", tc.unix, tc.want, got)
+		}
+	}
+}
+
+func TestValidateWithSkew(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	code, err := Code(rfcSecret, now)
+	if err != nil {
+		t.Fatalf("code: %v", err)
+	}
+	if code != "081804" {
+		t.Fatalf("expected six-digit truncation 081804, got %s", code)
+	}
+
+	step, ok := Validate(rfcSecret, code, now.Add(Period), 1)
+	if !ok || step != Step(now) {
+		t.Fatalf("expected code from previous step to validate as step %d, got %d %v", Step(now), step, ok)
+	}
+	if _, ok := Validate(rfcSecret, code, now.Add(2*Period), 1); ok {
+		t.Fatal("expected code outside skew window to fail")
+	}
+	if _, ok := Validate(rfcSecret, "12345", now, 1); ok {
+		t.Fatal("expected short code to fail")
+	}
+}
+
+func TestGenerateSecretAndURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if len(secret) != 32 {
+		t.Fatalf("expected 32 base32 characters, got %d", len(secret))
+	}
+	if _, err := Code(secret, time.Now()); err != nil {
+		t.Fatalf("generated secret unusable: %v", err)
+	}
+
+	uri := URI("Todo App", "user@example.com", "ABC")
+	want := "otpauth://totp/Todo%20App:user@example.com?algorithm=SHA1&digits=6&issuer=Todo+App&period=30&secret=ABC"
+	if uri != want {
+		t.Fatalf("expected %s, got %s", want, uri)
+	}
+}
@@ -29,3 +29,24 @@ func NewString() (string, error) {
 	builder.WriteString(hexStr[20:])
 	return builder.String(), nil
 }
+
+// Valid reports whether s is a UUID in the canonical 8-4-4-4-12 hex form.
+func Valid(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			c := s[i]
+			if (c < '0' || c > '9') && (c < 'a' || c > 'f') && (c < 'A' || c > 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}